@@ -92,5 +92,67 @@ const (
 	SHORT_LOGGER = internal.SHORT_LOGGER
 )
 
+// Policies that determine what happens to new connections once the server has reached its maximum number of connections.
+const (
+	// New connections are rejected with "503 - Service Unavailable" and a "Retry-After" header.
+	OVERLOAD_REJECT = internal.OVERLOAD_REJECT
+	// New connections wait in a bounded queue until an active connection is closed.
+	OVERLOAD_QUEUE = internal.OVERLOAD_QUEUE
+)
+
+// Methods used by a proxy to choose the upstream server for each request.
+const (
+	// Upstream servers are chosen one after the other.
+	BALANCE_ROUND_ROBIN = internal.BALANCE_ROUND_ROBIN
+	// The upstream server with the fewest requests in progress is chosen.
+	BALANCE_LEAST_CONNECTIONS = internal.BALANCE_LEAST_CONNECTIONS
+	// The upstream server is chosen using a hash of the client IP address.
+	BALANCE_IP_HASH = internal.BALANCE_IP_HASH
+)
+
+// Value of a size limit configured for a route using Router.Limit(), which lifts the limit configured for the server so that the requests for the route are not limited at all.
+const NO_LIMIT = internal.NO_LIMIT
+
+// Commands sent in a PROXY protocol header.
+const (
+	// The connection is relayed on behalf of a client.
+	PROXY_COMMAND_PROXY = internal.PROXY_COMMAND_PROXY
+	// The connection was opened by the load balancer itself, like a health check.
+	PROXY_COMMAND_LOCAL = internal.PROXY_COMMAND_LOCAL
+)
+
+// Types of the TLV fields sent in a PROXY protocol v2 header.
+const (
+	PROXY_TLV_ALPN = internal.PROXY_TLV_ALPN
+	PROXY_TLV_AUTHORITY = internal.PROXY_TLV_AUTHORITY
+	PROXY_TLV_CRC32C = internal.PROXY_TLV_CRC32C
+	PROXY_TLV_NOOP = internal.PROXY_TLV_NOOP
+	PROXY_TLV_UNIQUE_ID = internal.PROXY_TLV_UNIQUE_ID
+	PROXY_TLV_SSL = internal.PROXY_TLV_SSL
+	PROXY_TLV_NETNS = internal.PROXY_TLV_NETNS
+)
+
+// Types of the data messages exchanged over a WebSocket connection.
+const (
+	// Message containing UTF-8 encoded text.
+	WEBSOCKET_TEXT_MESSAGE = internal.WEBSOCKET_TEXT_MESSAGE
+	// Message containing binary data.
+	WEBSOCKET_BINARY_MESSAGE = internal.WEBSOCKET_BINARY_MESSAGE
+)
+
+// Status codes sent in the close frame of a WebSocket connection.
+const (
+	WEBSOCKET_CLOSE_NORMAL = internal.WEBSOCKET_CLOSE_NORMAL
+	WEBSOCKET_CLOSE_GOING_AWAY = internal.WEBSOCKET_CLOSE_GOING_AWAY
+	WEBSOCKET_CLOSE_PROTOCOL_ERROR = internal.WEBSOCKET_CLOSE_PROTOCOL_ERROR
+	WEBSOCKET_CLOSE_UNSUPPORTED_DATA = internal.WEBSOCKET_CLOSE_UNSUPPORTED_DATA
+	WEBSOCKET_CLOSE_NO_STATUS = internal.WEBSOCKET_CLOSE_NO_STATUS
+	WEBSOCKET_CLOSE_ABNORMAL = internal.WEBSOCKET_CLOSE_ABNORMAL
+	WEBSOCKET_CLOSE_INVALID_PAYLOAD = internal.WEBSOCKET_CLOSE_INVALID_PAYLOAD
+	WEBSOCKET_CLOSE_POLICY_VIOLATION = internal.WEBSOCKET_CLOSE_POLICY_VIOLATION
+	WEBSOCKET_CLOSE_MESSAGE_TOO_BIG = internal.WEBSOCKET_CLOSE_MESSAGE_TOO_BIG
+	WEBSOCKET_CLOSE_INTERNAL_ERROR = internal.WEBSOCKET_CLOSE_INTERNAL_ERROR
+)
+
 // Exposes member functions to apply colors for texts before being logged to any ANSI-supported terminals.
 var TextColor = internal.TextColor
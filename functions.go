@@ -11,3 +11,19 @@ var CreateServer = internal.NewServer
 // Creates a new router to declare endpoints and associated handlers.
 // The created router instance must be mapped to a server instance for the route paths to be functional.
 var CreateRouter = internal.NewRouter
+
+// Creates a new listener for the given address, which can be served by a server instance using the Serve() method.
+// Addresses of the form "unix:/path/to/socket" create a Unix domain socket, "fd:3" creates a listener from an inherited file descriptor and all other addresses create a TCP listener.
+var NewListener = internal.NewListener
+
+// Creates a new listener from an inherited file descriptor referring to a listening socket.
+var ListenerFromFd = internal.ListenerFromFd
+
+// Returns the listeners passed to the process by systemd using socket activation. The list is empty if the process was not socket activated.
+var SystemdListeners = internal.SystemdListeners
+
+// Creates a new reverse proxy forwarding requests to the given upstream servers, given as base URLs like "http://10.0.0.1:8080".
+var NewProxyHandler = internal.NewProxyHandler
+
+// Creates a new listener reading the PROXY protocol header (v1 or v2) sent by the trusted load balancers at the start of each connection, given as networks in CIDR notation like "10.0.0.0/8".
+var NewProxyProtocolListener = internal.NewProxyProtocolListener
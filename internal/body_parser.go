@@ -16,13 +16,15 @@ func JsonParser() Middleware {
 		if ok {
 			reqContentType = strings.TrimSpace(reqContentType)
 			if strings.EqualFold(reqContentType, "application/json") {
-				sourceBytes := request.BodyBytes
-				err := json.Unmarshal(sourceBytes, &request.Body)
-				if err != nil {
-					request.Server.Log(fmt.Sprintf("Error occurred while parsing JSON request payload: %s", err.Error()), ERROR_LEVEL)
-					request.Server.Log(fmt.Sprintf("HTTP Request :: %s %s", request.Method, request.ResourcePath), ERROR_LEVEL)
-					return
-				}
+				request.whenBodyLoaded(func() {
+					sourceBytes := request.BodyBytes
+					err := json.Unmarshal(sourceBytes, &request.Body)
+					if err != nil {
+						request.Server.Log(fmt.Sprintf("Error occurred while parsing JSON request payload: %s", err.Error()), ERROR_LEVEL)
+						request.Server.Log(fmt.Sprintf("HTTP Request :: %s %s", request.Method, request.ResourcePath), ERROR_LEVEL)
+						return
+					}
+				})
 			}
 		}
 	}
@@ -35,16 +37,18 @@ func UrlEncoded() Middleware {
 		if ok {
 			reqContentType = strings.TrimSpace(reqContentType)
 			if strings.EqualFold(reqContentType, "application/x-www-form-urlencoded") {
-				payloadString := string(request.BodyBytes)
-				payloadString = strings.TrimSpace(payloadString)
-				parsedParams, err := url.ParseQuery(payloadString)
-				if err != nil {
-					request.Server.Log(fmt.Sprintf("Error occurred while parsing url encoded request payload: %s", err.Error()), ERROR_LEVEL)
-					request.Server.Log(fmt.Sprintf("HTTP Request :: %s %s", request.Method, request.ResourcePath), ERROR_LEVEL)
-					return
-				}
+				request.whenBodyLoaded(func() {
+					payloadString := string(request.BodyBytes)
+					payloadString = strings.TrimSpace(payloadString)
+					parsedParams, err := url.ParseQuery(payloadString)
+					if err != nil {
+						request.Server.Log(fmt.Sprintf("Error occurred while parsing url encoded request payload: %s", err.Error()), ERROR_LEVEL)
+						request.Server.Log(fmt.Sprintf("HTTP Request :: %s %s", request.Method, request.ResourcePath), ERROR_LEVEL)
+						return
+					}
 
-				request.Body = map[string][]string(parsedParams)
+					request.Body = map[string][]string(parsedParams)
+				})
 			}
 		}
 	}
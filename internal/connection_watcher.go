@@ -0,0 +1,286 @@
+package internal
+
+import (
+	"net"
+	"sync"
+)
+
+// Structure to capture a snapshot of the connections being handled by a server instance.
+type ServerStats struct {
+	// Number of connections currently being handled by the server.
+	ActiveConnections int
+	// Number of active connections that are idle, waiting for the next request.
+	IdleConnections int
+	// Number of connections waiting in the queue for an active connection to be closed.
+	QueuedConnections int
+	// Total number of connections accepted by the server since it started.
+	AcceptedConnections int64
+	// Total number of connections rejected by the server because a connection limit was reached.
+	RejectedConnections int64
+	// Number of active connections for each client IP address.
+	ConnectionsPerIP map[string]int
+}
+
+// Structure to represent a connection waiting in the queue for an active connection to be closed.
+type queuedConnection struct {
+	// The connection waiting in the queue.
+	conn net.Conn
+	// IP address of the client that opened the connection.
+	clientIP string
+	// Channel closed once the connection has been admitted.
+	admitted chan struct{}
+}
+
+// Structure to track all the active connections maintained by the server.
+type ConnectionWatcher struct {
+	// Mutex to synchronize read-write activities for tracking the number of active connections.
+	mu sync.RWMutex
+	// Contains the number of active connections with the server.
+	connCount int
+	// Collection of all the active connections, mapped to a flag that is true when the connection is idle and waiting for the next request.
+	conns map[net.Conn]bool
+	// Number of active connections for each client IP address.
+	ipCounts map[string]int
+	// List of connections waiting for an active connection to be closed, in the order in which they were accepted.
+	queue []*queuedConnection
+	// Number of connections waiting in the queue for each client IP address.
+	queuedIPCounts map[string]int
+	// Maximum number of active connections allowed. A zero value means there is no limit.
+	maxConns int
+	// Maximum number of active connections allowed from a single client IP address. A zero value means there is no limit.
+	maxPerIP int
+	// Total number of connections admitted since the server started.
+	acceptedCount int64
+	// Total number of connections rejected since the server started.
+	rejectedCount int64
+}
+
+// Increases the connection count by the specified delta.
+func (cw *ConnectionWatcher) UpdateCount(delta int) {
+	cw.mu.Lock()
+	cw.connCount += delta
+	cw.mu.Unlock()
+}
+
+// Returns the connection count value for the ConnectionWatcher instance..
+func (cw *ConnectionWatcher) GetCount() int {
+	cw.mu.RLock()
+	count := cw.connCount
+	cw.mu.RUnlock()
+	return count
+}
+
+// Sets the maximum number of active connections allowed in total and from a single client IP address. A zero value means there is no limit.
+func (cw *ConnectionWatcher) SetLimits(maxConns int, maxPerIP int) {
+	cw.mu.Lock()
+	cw.maxConns = maxConns
+	cw.maxPerIP = maxPerIP
+	cw.mu.Unlock()
+}
+
+// Adds the given connection to the collection of active connections and increases the connection count by one, regardless of the connection limits.
+func (cw *ConnectionWatcher) Add(conn net.Conn) {
+	cw.mu.Lock()
+	cw.add(conn, getRemoteIP(conn))
+	cw.mu.Unlock()
+}
+
+// Adds the given connection to the collection of active connections, if neither of the connection limits have been reached.
+// Connections from a client IP address waiting in the queue count towards the limit for that address, along with its active connections.
+// The first value returned is true if the connection was added. The second value returned is true if the connection was not added because the limit for the client IP address was reached.
+func (cw *ConnectionWatcher) TryAdd(conn net.Conn) (bool, bool) {
+	clientIP := getRemoteIP(conn)
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	if cw.maxPerIP > 0 && clientIP != "" && cw.ipCounts[clientIP] + cw.queuedIPCounts[clientIP] >= cw.maxPerIP {
+		return false, true
+	}
+
+	if (cw.maxConns > 0 && cw.connCount >= cw.maxConns) || len(cw.queue) > 0 {
+		return false, false
+	}
+
+	cw.add(conn, clientIP)
+	return true, false
+}
+
+// Adds the given connection to the queue of connections waiting for an active connection to be closed, if the queue has fewer than the given number of connections.
+// It returns a channel that is closed once the connection is admitted, and false if the queue is full.
+func (cw *ConnectionWatcher) Enqueue(conn net.Conn, maxQueued int) (chan struct{}, bool) {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	if len(cw.queue) >= maxQueued {
+		return nil, false
+	}
+
+	waiting := &queuedConnection{ conn: conn, clientIP: getRemoteIP(conn), admitted: make(chan struct{}) }
+	cw.queue = append(cw.queue, waiting)
+	if waiting.clientIP != "" {
+		if cw.queuedIPCounts == nil {
+			cw.queuedIPCounts = make(map[string]int)
+		}
+		cw.queuedIPCounts[waiting.clientIP] += 1
+	}
+	return waiting.admitted, true
+}
+
+// Removes the given connection from the queue of waiting connections.
+// It returns false if the connection has already been admitted, in which case it must be handled as an active connection.
+func (cw *ConnectionWatcher) Dequeue(conn net.Conn) bool {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	for index, waiting := range cw.queue {
+		if waiting.conn == conn {
+			cw.unqueue(index)
+			return true
+		}
+	}
+	return false
+}
+
+// Increases the count of connections rejected because a connection limit was reached.
+func (cw *ConnectionWatcher) Reject() {
+	cw.mu.Lock()
+	cw.rejectedCount += 1
+	cw.mu.Unlock()
+}
+
+// Removes the given connection from the collection of active connections and decreases the connection count by one.
+// Connections waiting in the queue are admitted in order as long as the connection limits allow, skipping the connections whose client IP address has reached its limit.
+func (cw *ConnectionWatcher) Remove(conn net.Conn) {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	_, ok := cw.conns[conn]
+	if !ok {
+		return
+	}
+
+	delete(cw.conns, conn)
+	cw.connCount -= 1
+	clientIP := getRemoteIP(conn)
+	if clientIP != "" {
+		cw.ipCounts[clientIP] -= 1
+		if cw.ipCounts[clientIP] <= 0 {
+			delete(cw.ipCounts, clientIP)
+		}
+	}
+
+	index := 0
+	for index < len(cw.queue) && (cw.maxConns <= 0 || cw.connCount < cw.maxConns) {
+		waiting := cw.queue[index]
+		if cw.maxPerIP > 0 && waiting.clientIP != "" && cw.ipCounts[waiting.clientIP] >= cw.maxPerIP {
+			index += 1
+			continue
+		}
+		cw.unqueue(index)
+		cw.add(waiting.conn, waiting.clientIP)
+		close(waiting.admitted)
+	}
+}
+
+// Marks the given connection as idle (waiting for the next request) or active (processing a request).
+func (cw *ConnectionWatcher) SetIdle(conn net.Conn, idle bool) {
+	cw.mu.Lock()
+	_, ok := cw.conns[conn]
+	if ok {
+		cw.conns[conn] = idle
+	}
+	cw.mu.Unlock()
+}
+
+// Closes all the connections that are idle and returns the number of connections that are still processing a request.
+func (cw *ConnectionWatcher) CloseIdle() int {
+	activeCount := 0
+	cw.mu.Lock()
+	for conn, idle := range cw.conns {
+		if idle {
+			conn.Close()
+		} else {
+			activeCount += 1
+		}
+	}
+	cw.mu.Unlock()
+	return activeCount
+}
+
+// Closes all the active connections regardless of their state.
+func (cw *ConnectionWatcher) CloseAll() {
+	cw.mu.Lock()
+	for conn := range cw.conns {
+		conn.Close()
+	}
+	cw.mu.Unlock()
+}
+
+// Returns a snapshot of the connections tracked by the ConnectionWatcher instance.
+func (cw *ConnectionWatcher) GetStats() ServerStats {
+	cw.mu.RLock()
+	defer cw.mu.RUnlock()
+
+	stats := ServerStats{
+		ActiveConnections: cw.connCount,
+		QueuedConnections: len(cw.queue),
+		AcceptedConnections: cw.acceptedCount,
+		RejectedConnections: cw.rejectedCount,
+		ConnectionsPerIP: make(map[string]int),
+	}
+	for _, idle := range cw.conns {
+		if idle {
+			stats.IdleConnections += 1
+		}
+	}
+	for clientIP, count := range cw.ipCounts {
+		stats.ConnectionsPerIP[clientIP] = count
+	}
+	return stats
+}
+
+// Adds the given connection to the collection of active connections. The caller must hold the lock.
+func (cw *ConnectionWatcher) add(conn net.Conn, clientIP string) {
+	if cw.conns == nil {
+		cw.conns = make(map[net.Conn]bool)
+	}
+	if cw.ipCounts == nil {
+		cw.ipCounts = make(map[string]int)
+	}
+
+	cw.conns[conn] = false
+	cw.connCount += 1
+	cw.acceptedCount += 1
+	if clientIP != "" {
+		cw.ipCounts[clientIP] += 1
+	}
+}
+
+// Removes the connection at the given position from the queue of waiting connections. The caller must hold the lock.
+func (cw *ConnectionWatcher) unqueue(index int) {
+	clientIP := cw.queue[index].clientIP
+	cw.queue = append(cw.queue[:index], cw.queue[index + 1:]...)
+	if clientIP != "" {
+		cw.queuedIPCounts[clientIP] -= 1
+		if cw.queuedIPCounts[clientIP] <= 0 {
+			delete(cw.queuedIPCounts, clientIP)
+		}
+	}
+}
+
+// Returns the IP address of the client at the other end of the given connection.
+// An empty string is returned for connections without an IP address, like connections over Unix domain sockets.
+func getRemoteIP(conn net.Conn) string {
+	switch addr := conn.RemoteAddr().(type) {
+	case *net.TCPAddr:
+		return addr.IP.String()
+	case *net.UDPAddr:
+		return addr.IP.String()
+	}
+
+	host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil || net.ParseIP(host) == nil {
+		return ""
+	}
+	return host
+}
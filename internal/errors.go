@@ -12,6 +12,8 @@ type RequestParseError struct {
 	Value string
 	// Refers to the actual error message raised.
 	Message string
+	// Response status code to be sent back to the client for the error. If not set, "400 - Bad Request" is sent.
+	Status StatusCode
 }
 
 // Returns the error message associated with the instance of RequestParseError.
@@ -19,12 +21,24 @@ func (rpe *RequestParseError) Error() string {
 	return fmt.Sprintf("RequestParseError :: Section: (%s) :: Value: (%s) :: %s", rpe.Section, rpe.Value, rpe.Message)
 }
 
+// Returns the response status code to be sent back to the client for the error.
+func (rpe *RequestParseError) GetStatus() StatusCode {
+	if rpe.Status == 0 {
+		return Status400
+	}
+	return rpe.Status
+}
+
 // Custom error to track errors raised by the router associated with the web server.
 type RoutingError struct {
 	// The target route path which has caused the issue.
 	RoutePath string
 	// The actual error message raised
 	Message string
+	// List of HTTP methods that can be used for the route path, when endpoints have been declared for the route path but not for the requested method. It is empty otherwise.
+	AllowedMethods []string
+	// Response status code to be sent back to the client for the error. If not set, "405 - Method Not Allowed" is sent when allowed methods are listed, and "404 - Not Found" otherwise.
+	Status StatusCode
 }
 
 // Returns the error message associated with the RoutingError instance.
@@ -55,6 +69,14 @@ func (rte *ReadTimeoutError) Error() string {
 	return "Read timeout error occurred on the underlying TCP Connection."
 }
 
+// Custom error returned by the functions accepting connections once the server has been shutdown or closed.
+type ServerClosedError struct {}
+
+// Error message associated with the server closed error.
+func (sce *ServerClosedError) Error() string {
+	return "Server has been closed and is not accepting new connections."
+}
+
 // A custom error to track file system related errors raised.
 type FileSystemError struct {
 	// The target file path that is causing the error.
@@ -0,0 +1,215 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Structure to represent a single event sent to the client in a Server-Sent Events stream.
+type ServerSentEvent struct {
+	// Type of the event, sent in the "event" field. If empty, the client dispatches the event as a "message" event.
+	Event string
+	// ID of the event, sent in the "id" field. The client sends the ID of the last event it received in the "Last-Event-ID" header when it reconnects.
+	ID string
+	// Data of the event. Data spanning multiple lines is sent as multiple "data" fields.
+	Data string
+	// Duration the client waits before reconnecting once the stream is closed, sent in the "retry" field. A zero value leaves the duration unchanged.
+	Retry time.Duration
+}
+
+// Returns the event formatted as per the "text/event-stream" format, including the blank line dispatching the event.
+func (sse ServerSentEvent) format() string {
+	var builder strings.Builder
+	sanitize := strings.NewReplacer("\r", "", "\n", "", "\x00", "")
+	if sse.ID != "" {
+		builder.WriteString("id: " + sanitize.Replace(sse.ID) + "\n")
+	}
+	if sse.Event != "" {
+		builder.WriteString("event: " + sanitize.Replace(sse.Event) + "\n")
+	}
+	if sse.Retry > 0 {
+		builder.WriteString(fmt.Sprintf("retry: %d\n", sse.Retry.Milliseconds()))
+	}
+	if sse.Data != "" {
+		data := strings.ReplaceAll(strings.ReplaceAll(sse.Data, "\r\n", "\n"), "\r", "\n")
+		for _, line := range strings.Split(data, "\n") {
+			builder.WriteString("data: " + line + "\n")
+		}
+	}
+	builder.WriteString("\n")
+	return builder.String()
+}
+
+// Structure to represent a stream of Server-Sent Events sent as the body of a response.
+type EventStream struct {
+	// The response whose body carries the events.
+	response *HttpResponse
+	// Mutex to ensure that events and heartbeats are written to the response one at a time.
+	mu sync.Mutex
+	// Flag to determine if the stream has been closed. It is guarded by the mutex.
+	closed bool
+	// Channel closed once the client has disconnected, the server is shutting down or the stream has been closed.
+	done chan struct{}
+	// Ensures that the done channel is closed only once.
+	doneOnce sync.Once
+}
+
+// Starts a Server-Sent Events stream as the body of the response, by sending the status line and the headers with the "text/event-stream" content type.
+// A comment is sent to the client every heartbeat interval, so that proxies do not close the idle connection. A zero heartbeat interval disables the comments.
+// The stream is completed once the route handler returns. HTTP/1.x connections are closed afterwards, since the client cannot send another request while the stream is open.
+func (res *HttpResponse) EventStream(heartbeat time.Duration) (*EventStream, error) {
+	if res.headersSent {
+		resErr := new(ResponseError)
+		resErr.Section = "Header"
+		resErr.Value = ""
+		resErr.Message = "Event stream cannot be started once the response headers have been sent"
+		return nil, resErr
+	}
+
+	if res.StatusCode == 0 {
+		res.Status(Status200)
+	}
+	delete(res.Headers, "Content-Length")
+	delete(res.Headers, "Keep-Alive")
+	res.Headers["Content-Type"] = []string{ "text/event-stream" }
+	res.Headers["Cache-Control"] = []string{ "no-cache" }
+	if res.stream == nil {
+		res.Headers["Connection"] = []string{ "close" }
+	}
+
+	es := new(EventStream)
+	es.response = res
+	es.done = make(chan struct{})
+	res.eventStream = es
+	// The write deadline set for the response would end a long running stream, so every write is given its own deadline instead.
+	if res.conn != nil {
+		res.conn.SetWriteDeadline(time.Time{})
+	}
+	err := es.write(func() error {
+		return res.Flush()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go es.watch(heartbeat)
+	return es, nil
+}
+
+// Sends the given event to the client.
+func (es *EventStream) Send(event ServerSentEvent) error {
+	return es.write(func() error {
+		_, err := es.response.Stream([]byte(event.format()))
+		if err != nil {
+			return err
+		}
+		return es.response.Flush()
+	})
+}
+
+// Sends the given data to the client as an event without a type or an ID.
+func (es *EventStream) SendData(data string) error {
+	return es.Send(ServerSentEvent{ Data: data })
+}
+
+// Sends the given text to the client as a comment, which the client ignores.
+func (es *EventStream) Comment(text string) error {
+	return es.write(func() error {
+		lines := strings.Split(strings.ReplaceAll(text, "\r", ""), "\n")
+		_, err := es.response.Stream([]byte(": " + strings.Join(lines, "\n: ") + "\n\n"))
+		if err != nil {
+			return err
+		}
+		return es.response.Flush()
+	})
+}
+
+// Returns a channel which is closed once the client has disconnected, the server is shutting down or the stream has been closed.
+// The route handler should stop sending events once the channel is closed.
+func (es *EventStream) Done() <-chan struct{} {
+	return es.done
+}
+
+// Closes the stream, after which no more events can be sent. The response is completed once the route handler returns.
+func (es *EventStream) Close() {
+	es.mu.Lock()
+	es.closed = true
+	es.mu.Unlock()
+	es.doneOnce.Do(func() {
+		close(es.done)
+	})
+}
+
+// Executes the given function, which writes to the response, unless the stream has been closed. A failed write closes the stream.
+func (es *EventStream) write(writeFunc func() error) error {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	if es.closed {
+		resErr := new(ResponseError)
+		resErr.Section = "Body"
+		resErr.Value = ""
+		resErr.Message = "Event stream has been closed"
+		return resErr
+	}
+
+	server := es.response.Server
+	if es.response.conn != nil && server != nil && server.WriteTimeout > 0 {
+		es.response.conn.SetWriteDeadline(time.Now().Add(server.WriteTimeout))
+	}
+	err := writeFunc()
+	if err != nil {
+		es.closed = true
+		es.doneOnce.Do(func() {
+			close(es.done)
+		})
+	}
+	return err
+}
+
+// Sends the heartbeat comments and closes the stream once the client disconnects or the server shuts down.
+func (es *EventStream) watch(heartbeat time.Duration) {
+	var ticks <-chan time.Time
+	if heartbeat > 0 {
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	var shutdown chan struct{}
+	if es.response.Server != nil {
+		shutdown = es.response.Server.shutdown
+	}
+
+	// Over HTTP/1.x, the client does not send anything once the stream has started, so a completed read means that the client has disconnected.
+	// Over HTTP/2, the client resets the stream instead.
+	var disconnected <-chan struct{}
+	if es.response.stream != nil {
+		disconnected = es.response.stream.resetCh
+	} else if es.response.conn != nil {
+		readDone := make(chan struct{})
+		conn := es.response.conn
+		go func() {
+			buffer := make([]byte, 1)
+			conn.Read(buffer)
+			close(readDone)
+		}()
+		disconnected = readDone
+	}
+
+	for {
+		select {
+		case <-es.done:
+			return
+		case <-shutdown:
+			es.Close()
+			return
+		case <-disconnected:
+			es.Close()
+			return
+		case <-ticks:
+			es.Comment("heartbeat")
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package internal
+
+import (
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Contains the parameters of a single element of the "Forwarded" header, added by one of the proxies that forwarded the request, as per RFC 7239.
+type forwardedElement struct {
+	// The node that sent the request to the proxy, given by the "for" parameter.
+	For string
+	// The host requested by the client of the proxy, given by the "host" parameter.
+	Host string
+	// The protocol used by the client of the proxy, given by the "proto" parameter.
+	Proto string
+}
+
+// Determines the client IP address, scheme, host and port of the request.
+// The values are taken from the "Forwarded" header, or the "X-Forwarded-For", "X-Forwarded-Proto" and "X-Forwarded-Host" headers if it is absent, only when the request was sent by one of the trusted proxies of the server.
+// The addresses listed in the headers are followed from the nearest one, skipping the trusted proxies, until the first address that is not trusted, which is taken to be the client.
+func (req *HttpRequest) resolveOrigin() {
+	peerIP := getAddressIP(req.PeerAddress)
+	req.clientIP = peerIP
+	req.scheme = "http"
+	if req.TLS != nil {
+		req.scheme = "https"
+	}
+	req.host, _ = req.Headers.Get("Host")
+	req.host = strings.TrimSpace(req.host)
+
+	if req.Server != nil && req.Server.isTrustedProxy(req.clientIP) {
+		forwarded, ok := req.Headers.Get("Forwarded")
+		if ok {
+			req.resolveForwarded(parseForwarded(forwarded))
+		} else {
+			req.resolveXForwarded()
+		}
+	}
+
+	req.host, req.port = splitHostPort(req.host, req.scheme)
+	if req.clientIP != peerIP {
+		req.ClientAddress = req.clientIP
+		if req.clientPort != "" {
+			req.ClientAddress = net.JoinHostPort(req.clientIP, req.clientPort)
+		}
+	}
+}
+
+// Determines the client IP address, scheme and host of the request from the elements of the "Forwarded" header.
+// The scheme and host are taken from the element added by the proxy that received the request from the client.
+func (req *HttpRequest) resolveForwarded(elements []forwardedElement) {
+	if len(elements) == 0 {
+		return
+	}
+
+	index := len(elements) - 1
+	for ; index > 0; index-- {
+		nodeIP, _ := parseForwardedNode(elements[index].For)
+		if nodeIP == "" || !req.Server.isTrustedProxy(nodeIP) {
+			break
+		}
+	}
+
+	element := elements[index]
+	if element.For != "" {
+		req.clientIP, req.clientPort = parseForwardedNode(element.For)
+		if req.clientIP == "" {
+			// Obfuscated identifiers like "_hidden" and the "unknown" identifier are used as they are.
+			req.clientIP = element.For
+		}
+	}
+	if element.Proto != "" {
+		req.scheme = strings.ToLower(element.Proto)
+	}
+	if element.Host != "" {
+		req.host = element.Host
+	}
+}
+
+// Determines the client IP address, scheme and host of the request from the "X-Forwarded-For", "X-Forwarded-Proto" and "X-Forwarded-Host" headers.
+// If the scheme and host headers list as many values as the addresses, the values matching the client address are used. Otherwise, the values added by the nearest proxy are used.
+func (req *HttpRequest) resolveXForwarded() {
+	addresses := splitHeaderList(req.Headers, "X-Forwarded-For")
+	protos := splitHeaderList(req.Headers, "X-Forwarded-Proto")
+	hosts := splitHeaderList(req.Headers, "X-Forwarded-Host")
+
+	index := len(addresses) - 1
+	for ; index > 0; index-- {
+		if !req.Server.isTrustedProxy(getAddressIP(addresses[index])) {
+			break
+		}
+	}
+
+	if index >= 0 {
+		req.clientIP = getAddressIP(addresses[index])
+		if req.clientIP == "" {
+			req.clientIP = addresses[index]
+		}
+	}
+	if len(protos) > 0 {
+		req.scheme = strings.ToLower(pickForwardedValue(protos, len(addresses), index))
+	}
+	if len(hosts) > 0 {
+		req.host = pickForwardedValue(hosts, len(addresses), index)
+	}
+}
+
+// Picks the value of a "X-Forwarded-*" header matching the address at the given index of the "X-Forwarded-For" header, or the last value if the lists do not match.
+func pickForwardedValue(values []string, addressCount int, index int) string {
+	if len(values) == addressCount && index >= 0 {
+		return values[index]
+	}
+	return values[len(values) - 1]
+}
+
+// Splits the comma separated list of values of the given header, removing the empty values.
+func splitHeaderList(headers Headers, key string) []string {
+	value, ok := headers.Get(key)
+	if !ok {
+		return make([]string, 0)
+	}
+
+	values := make([]string, 0)
+	for item := range strings.SplitSeq(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+// Parses the value of a "Forwarded" header into its elements, handling the quoted strings in the parameter values.
+func parseForwarded(value string) []forwardedElement {
+	elements := make([]forwardedElement, 0)
+	element := forwardedElement{}
+	var pair strings.Builder
+	addPair := func() {
+		key, paramValue, found := strings.Cut(strings.TrimSpace(pair.String()), "=")
+		pair.Reset()
+		if !found {
+			return
+		}
+		paramValue = strings.TrimSpace(paramValue)
+		unquoted, err := strconv.Unquote(paramValue)
+		if err == nil && strings.HasPrefix(paramValue, "\"") {
+			paramValue = unquoted
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "for":
+			element.For = paramValue
+		case "host":
+			element.Host = paramValue
+		case "proto":
+			element.Proto = paramValue
+		}
+	}
+
+	quoted := false
+	escaped := false
+	for _, char := range value {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && char == '\\':
+			escaped = true
+		case char == '"':
+			quoted = !quoted
+		case !quoted && char == ';':
+			addPair()
+			continue
+		case !quoted && char == ',':
+			addPair()
+			elements = append(elements, element)
+			element = forwardedElement{}
+			continue
+		}
+		pair.WriteRune(char)
+	}
+	addPair()
+	elements = append(elements, element)
+	return elements
+}
+
+// Parses a node of the "Forwarded" header, like "192.0.2.43", "[2001:db8::17]:4711" or "unknown", into its IP address and port.
+// An empty IP address is returned if the node is not an IP address, like obfuscated identifiers.
+func parseForwardedNode(node string) (string, string) {
+	node = strings.TrimSpace(node)
+	host, port, err := net.SplitHostPort(node)
+	if err != nil {
+		host = strings.TrimSuffix(strings.TrimPrefix(node, "["), "]")
+		port = ""
+	}
+	if net.ParseIP(host) == nil {
+		return "", ""
+	}
+	if _, err := strconv.Atoi(port); err != nil {
+		port = ""
+	}
+	return host, port
+}
+
+// Returns the IP address contained in the given address, which can either be a bare IP address or include a port number. It returns an empty string if the address does not contain an IP address.
+func getAddressIP(address string) string {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(address))
+	if err != nil {
+		host = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(address), "["), "]")
+	}
+	if net.ParseIP(host) == nil {
+		return ""
+	}
+	return host
+}
+
+// Splits the given host into its name and port number. If the host does not include a port number, the default port number of the given scheme is returned.
+func splitHostPort(host string, scheme string) (string, int) {
+	name, portValue, err := net.SplitHostPort(host)
+	if err == nil {
+		port, err := strconv.Atoi(portValue)
+		if err == nil {
+			return name, port
+		}
+	}
+
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	switch scheme {
+	case "https", "wss":
+		return host, 443
+	case "http", "ws":
+		return host, 80
+	}
+	return host, 0
+}
@@ -6,6 +6,8 @@ const (
 	REQUEST_LINE_SEPERATOR = " "
 	HEADER_KEY_VALUE_SEPERATOR = ":"
 	ROUTE_SEPERATOR = "/"
+	// Method of the endpoints declared for every HTTP method.
+	ANY_METHOD = "*"
 
 	// Informational data logged to the terminal.
 	INFO_LEVEL = "INFO"
@@ -32,10 +34,52 @@ const (
 	//
 	// :remote-addr :method :url HTTP/:http-version :status :res[content-length] - :response-time ms
 	SHORT_LOGGER = "short"
+
+	// New connections are rejected with "503 - Service Unavailable" once the maximum number of connections is reached.
+	OVERLOAD_REJECT = "reject"
+	// New connections wait in a bounded queue once the maximum number of connections is reached, and are rejected if the queue is full or they wait for too long.
+	OVERLOAD_QUEUE = "queue"
+
+	// Upstream servers of a proxy are chosen one after the other.
+	BALANCE_ROUND_ROBIN = "round-robin"
+	// The upstream server of a proxy with the fewest requests in progress is chosen.
+	BALANCE_LEAST_CONNECTIONS = "least-connections"
+	// The upstream server of a proxy is chosen using a hash of the client IP address, so that a client keeps being sent to the same server while it is available.
+	BALANCE_IP_HASH = "ip-hash"
+
+	// Value of a size limit configured for a route, which lifts the limit configured for the server so that the requests for the route are not limited at all.
+	NO_LIMIT = -1
+)
+
+const (
+	// Command of a PROXY protocol header sent for a connection relayed on behalf of a client.
+	PROXY_COMMAND_PROXY = "PROXY"
+	// Command of a PROXY protocol header sent for a connection opened by the load balancer itself, like a health check.
+	PROXY_COMMAND_LOCAL = "LOCAL"
+	// Maximum length of a PROXY protocol v1 header, including the CRLF at the end.
+	PROXY_V1_MAX_LENGTH = 107
+	// Type of the PROXY protocol v2 TLV field carrying the application protocol negotiated by the client using ALPN.
+	PROXY_TLV_ALPN byte = 0x01
+	// Type of the PROXY protocol v2 TLV field carrying the host name sent by the client, like the TLS server name.
+	PROXY_TLV_AUTHORITY byte = 0x02
+	// Type of the PROXY protocol v2 TLV field carrying the CRC32C checksum of the header.
+	PROXY_TLV_CRC32C byte = 0x03
+	// Type of the PROXY protocol v2 TLV field used for padding, which is ignored.
+	PROXY_TLV_NOOP byte = 0x04
+	// Type of the PROXY protocol v2 TLV field carrying an opaque identifier of the connection, assigned by the load balancer.
+	PROXY_TLV_UNIQUE_ID byte = 0x05
+	// Type of the PROXY protocol v2 TLV field carrying the details of the TLS session between the client and the load balancer.
+	PROXY_TLV_SSL byte = 0x20
+	// Type of the PROXY protocol v2 TLV field carrying the name of the network namespace of the connection.
+	PROXY_TLV_NETNS byte = 0x30
 )
 
 // Collection of headers supported by the server that has a date value.
 var DateHeaders []string
+// Collection of fields that are not allowed to be sent as trailers, since they are needed for framing, routing or authentication of the message.
+var ForbiddenTrailers []string
+// Collection of request headers whose values are redacted in the responses sent by the built-in TRACE responder, since they carry credentials.
+var TraceRedactedHeaders []string
 // List of content types supported by the web server.
 var AllowedContentTypes map[string]string
 // A map containing all the default server configuration values.
@@ -48,6 +92,8 @@ var ResponseStatusCodes []HttpStatus
 // Initializes the global variables used in this package.
 func init() {
 	DateHeaders = []string{"Date", "Expires", "If-Modified-Since", "Last-Modified"}
+	TraceRedactedHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+	ForbiddenTrailers = []string{"Authorization", "Cache-Control", "Content-Encoding", "Content-Length", "Content-Range", "Content-Type", "Expect", "Host", "Max-Forwards", "Set-Cookie", "Te", "Trailer", "Transfer-Encoding"}
 	AllowedContentTypes = map[string]string{
 		"pdf": "application/pdf",
         "htm": "text/html",
@@ -109,12 +155,45 @@ func init() {
 		"server_name": "proteus",
 		"content_type": "application/octet-stream",
 		"shutdown_timeout": 60,
+		"tls_handshake_timeout": 10,
+		"certificate_reload_interval": 30,
+		"read_header_timeout": 10,
+		"read_body_timeout": 60,
+		"write_timeout": 60,
+		"idle_timeout": 15,
+		"max_requests_per_connection": 100,
+		"max_queued_connections": 128,
+		"queue_timeout": 10,
+		"retry_after": 5,
+		"reject_timeout": 5,
+		"max_request_line_length": 8192,
+		"max_header_bytes": 1048576,
+		"max_header_count": 100,
+		"max_body_size": 10485760,
+		"max_chunk_line_length": 4096,
+		"http2_max_concurrent_streams": 100,
+		"http2_initial_window_size": 1048576,
+		"http2_max_header_block_size": 1048576,
+		"http2_max_buffered_body_size": 67108864,
+		"websocket_max_message_size": 16777216,
+		"websocket_close_timeout": 5,
+		"proxy_dial_timeout": 10,
+		"proxy_response_timeout": 60,
+		"proxy_idle_timeout": 90,
+		"proxy_max_idle_connections": 32,
+		"proxy_max_fails": 3,
+		"proxy_fail_timeout": 10,
+		"proxy_health_check_interval": 10,
+		"proxy_health_check_timeout": 5,
+		"proxy_protocol_timeout": 5,
+		"upgrade_timeout": 30,
 	}
 
 	Versions = map[string][]string {
 		"0.9":  { "GET" },
 		"1.0":  { "GET", "POST", "HEAD", "OPTIONS", "TRACE" },
 		"1.1":  { "GET", "HEAD", "POST", "PUT", "DELETE", "TRACE", "OPTIONS", "CONNECT", "PATCH" },
+		"2.0":  { "GET", "HEAD", "POST", "PUT", "DELETE", "TRACE", "OPTIONS", "CONNECT", "PATCH" },
 	}
 
 	ResponseStatusCodes = []HttpStatus {
@@ -0,0 +1,403 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Structure to represent a single header field encoded or decoded using HPACK.
+type hpackField struct {
+	// Name of the header field, in lowercase.
+	Name string
+	// Value of the header field.
+	Value string
+	// Flag to determine if the field must never be added to a dynamic table by intermediaries, like fields carrying credentials.
+	Sensitive bool
+}
+
+// Returns the size of the header field as accounted for in the dynamic table, as per RFC 7541.
+func (hf hpackField) size() int {
+	return len(hf.Name) + len(hf.Value) + 32
+}
+
+// Static table of HPACK containing the most common header fields, as defined in RFC 7541 Appendix A. The first entry has the index 1.
+var hpackStaticTable = []hpackField{
+	{ Name: ":authority" },
+	{ Name: ":method", Value: "GET" },
+	{ Name: ":method", Value: "POST" },
+	{ Name: ":path", Value: "/" },
+	{ Name: ":path", Value: "/index.html" },
+	{ Name: ":scheme", Value: "http" },
+	{ Name: ":scheme", Value: "https" },
+	{ Name: ":status", Value: "200" },
+	{ Name: ":status", Value: "204" },
+	{ Name: ":status", Value: "206" },
+	{ Name: ":status", Value: "304" },
+	{ Name: ":status", Value: "400" },
+	{ Name: ":status", Value: "404" },
+	{ Name: ":status", Value: "500" },
+	{ Name: "accept-charset" },
+	{ Name: "accept-encoding", Value: "gzip, deflate" },
+	{ Name: "accept-language" },
+	{ Name: "accept-ranges" },
+	{ Name: "accept" },
+	{ Name: "access-control-allow-origin" },
+	{ Name: "age" },
+	{ Name: "allow" },
+	{ Name: "authorization" },
+	{ Name: "cache-control" },
+	{ Name: "content-disposition" },
+	{ Name: "content-encoding" },
+	{ Name: "content-language" },
+	{ Name: "content-length" },
+	{ Name: "content-location" },
+	{ Name: "content-range" },
+	{ Name: "content-type" },
+	{ Name: "cookie" },
+	{ Name: "date" },
+	{ Name: "etag" },
+	{ Name: "expect" },
+	{ Name: "expires" },
+	{ Name: "from" },
+	{ Name: "host" },
+	{ Name: "if-match" },
+	{ Name: "if-modified-since" },
+	{ Name: "if-none-match" },
+	{ Name: "if-range" },
+	{ Name: "if-unmodified-since" },
+	{ Name: "last-modified" },
+	{ Name: "link" },
+	{ Name: "location" },
+	{ Name: "max-forwards" },
+	{ Name: "proxy-authenticate" },
+	{ Name: "proxy-authorization" },
+	{ Name: "range" },
+	{ Name: "referer" },
+	{ Name: "refresh" },
+	{ Name: "retry-after" },
+	{ Name: "server" },
+	{ Name: "set-cookie" },
+	{ Name: "strict-transport-security" },
+	{ Name: "transfer-encoding" },
+	{ Name: "user-agent" },
+	{ Name: "vary" },
+	{ Name: "via" },
+	{ Name: "www-authenticate" },
+}
+
+// Node of the binary tree used to decode Huffman encoded string literals.
+type hpackHuffmanNode struct {
+	// Child nodes for the next bit being zero and one respectively. Both are nil for leaf nodes.
+	children [2]*hpackHuffmanNode
+	// Byte value represented by a leaf node.
+	symbol byte
+}
+
+// Root of the binary tree used to decode Huffman encoded string literals.
+var hpackHuffmanRoot = buildHuffmanTree()
+
+// Builds the binary tree used to decode Huffman encoded string literals from the table of Huffman codes.
+func buildHuffmanTree() *hpackHuffmanNode {
+	root := new(hpackHuffmanNode)
+	for symbol, code := range hpackHuffmanCodes {
+		length := hpackHuffmanCodeLengths[symbol]
+		current := root
+		for bit := int(length) - 1; bit >= 0; bit-- {
+			next := (code >> uint(bit)) & 1
+			if current.children[next] == nil {
+				current.children[next] = new(hpackHuffmanNode)
+			}
+			current = current.children[next]
+		}
+		current.symbol = byte(symbol)
+	}
+	return root
+}
+
+// Decodes the given Huffman encoded string literal as per RFC 7541.
+func huffmanDecode(encoded []byte) (string, error) {
+	var decoded strings.Builder
+	current := hpackHuffmanRoot
+	paddingBits := 0
+	allOnes := true
+	for _, value := range encoded {
+		for bit := 7; bit >= 0; bit-- {
+			next := (value >> uint(bit)) & 1
+			current = current.children[next]
+			if current == nil {
+				return "", &CustomError{ Message: "Invalid Huffman code found in the HPACK string literal" }
+			}
+			paddingBits += 1
+			allOnes = allOnes && next == 1
+			if current.children[0] == nil && current.children[1] == nil {
+				decoded.WriteByte(current.symbol)
+				current = hpackHuffmanRoot
+				paddingBits = 0
+				allOnes = true
+			}
+		}
+	}
+
+	// The remaining bits must be the most significant bits of the EOS symbol (all ones), and there must be fewer than 8 of them.
+	if paddingBits > 7 || !allOnes {
+		return "", &CustomError{ Message: "Invalid padding found in the Huffman encoded HPACK string literal" }
+	}
+	return decoded.String(), nil
+}
+
+// Appends the given integer to the destination using the HPACK integer representation with the given prefix length (in bits).
+// The first byte is combined with the given flags, which occupy the bits before the prefix.
+func appendHpackInteger(destination []byte, flags byte, prefixBits uint, value uint64) []byte {
+	maxPrefix := uint64(1) << prefixBits - 1
+	if value < maxPrefix {
+		return append(destination, flags | byte(value))
+	}
+
+	destination = append(destination, flags | byte(maxPrefix))
+	value -= maxPrefix
+	for value >= 128 {
+		destination = append(destination, byte(value % 128) | 0x80)
+		value /= 128
+	}
+	return append(destination, byte(value))
+}
+
+// Reads an integer encoded using the HPACK integer representation with the given prefix length (in bits) from the start of the given bytes.
+// It returns the integer and the remaining bytes.
+func readHpackInteger(source []byte, prefixBits uint) (uint64, []byte, error) {
+	if len(source) == 0 {
+		return 0, nil, &CustomError{ Message: "HPACK integer is truncated" }
+	}
+
+	maxPrefix := uint64(1) << prefixBits - 1
+	value := uint64(source[0]) & maxPrefix
+	source = source[1:]
+	if value < maxPrefix {
+		return value, source, nil
+	}
+
+	var shift uint
+	for index, octet := range source {
+		if shift > 56 {
+			return 0, nil, &CustomError{ Message: "HPACK integer is too large" }
+		}
+		value += uint64(octet & 0x7f) << shift
+		shift += 7
+		if octet & 0x80 == 0 {
+			return value, source[index + 1:], nil
+		}
+	}
+	return 0, nil, &CustomError{ Message: "HPACK integer is truncated" }
+}
+
+// Reads a string literal, optionally Huffman encoded, from the start of the given bytes. It returns the string and the remaining bytes.
+func readHpackString(source []byte) (string, []byte, error) {
+	if len(source) == 0 {
+		return "", nil, &CustomError{ Message: "HPACK string literal is truncated" }
+	}
+
+	isHuffman := source[0] & 0x80 != 0
+	length, source, err := readHpackInteger(source, 7)
+	if err != nil {
+		return "", nil, err
+	}
+	if uint64(len(source)) < length {
+		return "", nil, &CustomError{ Message: "HPACK string literal is truncated" }
+	}
+
+	literal := source[:length]
+	source = source[length:]
+	if isHuffman {
+		decoded, err := huffmanDecode(literal)
+		return decoded, source, err
+	}
+	return string(literal), source, nil
+}
+
+// Structure to hold the dynamic table of a HPACK encoding context, as per RFC 7541.
+type hpackDynamicTable struct {
+	// Entries of the table, with the most recently added entry at the end.
+	entries []hpackField
+	// Sum of the sizes of all the entries in the table.
+	size int
+	// Maximum size of the table.
+	maxSize int
+}
+
+// Adds the given field to the dynamic table, evicting the oldest entries as needed.
+func (dt *hpackDynamicTable) add(field hpackField) {
+	dt.entries = append(dt.entries, field)
+	dt.size += field.size()
+	dt.evict()
+}
+
+// Changes the maximum size of the dynamic table, evicting the oldest entries as needed.
+func (dt *hpackDynamicTable) setMaxSize(maxSize int) {
+	dt.maxSize = maxSize
+	dt.evict()
+}
+
+// Evicts the oldest entries from the dynamic table until its size is within the maximum size.
+func (dt *hpackDynamicTable) evict() {
+	evicted := 0
+	for dt.size > dt.maxSize && evicted < len(dt.entries) {
+		dt.size -= dt.entries[evicted].size()
+		evicted += 1
+	}
+	dt.entries = dt.entries[evicted:]
+}
+
+// Returns the field at the given index of the combined static and dynamic tables. Indexes start at 1.
+func (dt *hpackDynamicTable) get(index uint64) (hpackField, bool) {
+	if index == 0 {
+		return hpackField{}, false
+	}
+	if index <= uint64(len(hpackStaticTable)) {
+		return hpackStaticTable[index - 1], true
+	}
+
+	dynamicIndex := index - uint64(len(hpackStaticTable))
+	if dynamicIndex > uint64(len(dt.entries)) {
+		return hpackField{}, false
+	}
+	return dt.entries[uint64(len(dt.entries)) - dynamicIndex], true
+}
+
+// Structure to decode header blocks received from a client, as per RFC 7541.
+type hpackDecoder struct {
+	// Dynamic table shared by all the header blocks received on the connection.
+	table hpackDynamicTable
+	// Maximum size of the dynamic table allowed by the server, as advertised in its SETTINGS frame.
+	maxAllowedSize int
+	// Maximum number of bytes allowed for the decoded header list. A zero value means there is no limit.
+	maxHeaderListSize int
+}
+
+// Creates a new HPACK decoder with the given maximum dynamic table size and returns a reference to the instance.
+func newHpackDecoder(maxTableSize int) *hpackDecoder {
+	decoder := new(hpackDecoder)
+	decoder.maxAllowedSize = maxTableSize
+	decoder.table.maxSize = maxTableSize
+	return decoder
+}
+
+// Decodes the given header block and returns the list of header fields in the order in which they were encoded.
+func (hd *hpackDecoder) decode(block []byte) ([]hpackField, error) {
+	fields := make([]hpackField, 0)
+	listSize := 0
+	var err error
+	for len(block) > 0 {
+		var field hpackField
+		var index uint64
+		representation := block[0]
+		switch {
+		case representation & 0x80 != 0:
+			// Indexed header field.
+			index, block, err = readHpackInteger(block, 7)
+			if err != nil {
+				return nil, err
+			}
+			var ok bool
+			field, ok = hd.table.get(index)
+			if !ok {
+				return nil, &CustomError{ Message: fmt.Sprintf("Invalid HPACK table index [%d]", index) }
+			}
+		case representation & 0xe0 == 0x20:
+			// Dynamic table size update.
+			var maxSize uint64
+			maxSize, block, err = readHpackInteger(block, 5)
+			if err != nil {
+				return nil, err
+			}
+			if maxSize > uint64(hd.maxAllowedSize) {
+				return nil, &CustomError{ Message: fmt.Sprintf("HPACK dynamic table size [%d] is larger than the allowed size", maxSize) }
+			}
+			hd.table.setMaxSize(int(maxSize))
+			continue
+		default:
+			// Literal header field with incremental indexing (6 bit prefix), without indexing or never indexed (4 bit prefix).
+			prefixBits := uint(4)
+			indexed := representation & 0xc0 == 0x40
+			if indexed {
+				prefixBits = 6
+			}
+			index, block, err = readHpackInteger(block, prefixBits)
+			if err != nil {
+				return nil, err
+			}
+			if index > 0 {
+				nameField, ok := hd.table.get(index)
+				if !ok {
+					return nil, &CustomError{ Message: fmt.Sprintf("Invalid HPACK table index [%d]", index) }
+				}
+				field.Name = nameField.Name
+			} else {
+				field.Name, block, err = readHpackString(block)
+				if err != nil {
+					return nil, err
+				}
+			}
+			field.Value, block, err = readHpackString(block)
+			if err != nil {
+				return nil, err
+			}
+			field.Sensitive = representation & 0xf0 == 0x10
+			if indexed {
+				hd.table.add(field)
+			}
+		}
+
+		listSize += field.size()
+		if hd.maxHeaderListSize > 0 && listSize > hd.maxHeaderListSize {
+			return nil, &CustomError{ Message: fmt.Sprintf("Header list is larger than the limit of %d bytes", hd.maxHeaderListSize) }
+		}
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// Structure to encode header blocks sent to a client, as per RFC 7541.
+// Fields are encoded using the static table where possible and are never added to the dynamic table, so the encoder does not need to track the dynamic table size of the client.
+type hpackEncoder struct {}
+
+// Encodes the given header fields into a header block.
+func (he *hpackEncoder) encode(fields []hpackField) []byte {
+	block := make([]byte, 0)
+	for _, field := range fields {
+		nameIndex := 0
+		fullMatch := false
+		for index, staticField := range hpackStaticTable {
+			if staticField.Name != field.Name {
+				continue
+			}
+			if nameIndex == 0 {
+				nameIndex = index + 1
+			}
+			if staticField.Value == field.Value {
+				nameIndex = index + 1
+				fullMatch = true
+				break
+			}
+		}
+
+		if fullMatch {
+			block = appendHpackInteger(block, 0x80, 7, uint64(nameIndex))
+			continue
+		}
+
+		// Literal header field without indexing, or never indexed for sensitive fields.
+		var flags byte = 0x00
+		if field.Sensitive {
+			flags = 0x10
+		}
+		block = appendHpackInteger(block, flags, 4, uint64(nameIndex))
+		if nameIndex == 0 {
+			block = appendHpackInteger(block, 0x00, 7, uint64(len(field.Name)))
+			block = append(block, field.Name...)
+		}
+		block = appendHpackInteger(block, 0x00, 7, uint64(len(field.Value)))
+		block = append(block, field.Value...)
+	}
+	return block
+}
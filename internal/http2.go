@@ -0,0 +1,1333 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Connection preface sent by HTTP/2 clients before any frame, as per RFC 9113.
+	HTTP2_PREFACE = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+	// Protocol identifier used to negotiate HTTP/2 over TLS using ALPN.
+	HTTP2_ALPN_PROTOCOL = "h2"
+	// Protocol identifier used to upgrade a cleartext HTTP/1.1 connection to HTTP/2.
+	HTTP2_CLEARTEXT_PROTOCOL = "h2c"
+)
+
+// Types of frames defined by HTTP/2.
+const (
+	http2FrameData byte = 0x0
+	http2FrameHeaders byte = 0x1
+	http2FramePriority byte = 0x2
+	http2FrameRstStream byte = 0x3
+	http2FrameSettings byte = 0x4
+	http2FramePushPromise byte = 0x5
+	http2FramePing byte = 0x6
+	http2FrameGoAway byte = 0x7
+	http2FrameWindowUpdate byte = 0x8
+	http2FrameContinuation byte = 0x9
+)
+
+// Flags that can be set on HTTP/2 frames.
+const (
+	http2FlagEndStream byte = 0x1
+	http2FlagAck byte = 0x1
+	http2FlagEndHeaders byte = 0x4
+	http2FlagPadded byte = 0x8
+	http2FlagPriority byte = 0x20
+)
+
+// Identifiers of the parameters exchanged in HTTP/2 SETTINGS frames.
+const (
+	http2SettingHeaderTableSize uint16 = 0x1
+	http2SettingEnablePush uint16 = 0x2
+	http2SettingMaxConcurrentStreams uint16 = 0x3
+	http2SettingInitialWindowSize uint16 = 0x4
+	http2SettingMaxFrameSize uint16 = 0x5
+	http2SettingMaxHeaderListSize uint16 = 0x6
+)
+
+// Error codes used in HTTP/2 RST_STREAM and GOAWAY frames.
+const (
+	http2NoError uint32 = 0x0
+	http2ProtocolError uint32 = 0x1
+	http2InternalError uint32 = 0x2
+	http2FlowControlError uint32 = 0x3
+	http2StreamClosedError uint32 = 0x5
+	http2FrameSizeError uint32 = 0x6
+	http2RefusedStreamError uint32 = 0x7
+	http2CancelError uint32 = 0x8
+	http2CompressionError uint32 = 0x9
+)
+
+const (
+	// Size of the header of every HTTP/2 frame.
+	http2FrameHeaderLength = 9
+	// Default value of the initial flow control window size and of the connection flow control window.
+	http2DefaultWindowSize = 65535
+	// Default and minimum value of the maximum frame size.
+	http2DefaultMaxFrameSize = 16384
+	// Largest value allowed for the maximum frame size.
+	http2MaxAllowedFrameSize = 1 << 24 - 1
+	// Largest value allowed for a flow control window.
+	http2MaxWindowSize = 1 << 31 - 1
+	// Default size of the HPACK dynamic table.
+	http2DefaultHeaderTableSize = 4096
+)
+
+// Header fields that are specific to a HTTP/1.x connection and must not be sent in HTTP/2 messages.
+var http2ConnectionHeaders = []string{ "connection", "keep-alive", "proxy-connection", "transfer-encoding", "upgrade" }
+
+// Custom error to track violations of the HTTP/2 protocol. A stream ID of zero refers to an error affecting the whole connection.
+type http2Error struct {
+	// Error code sent to the client in the RST_STREAM or GOAWAY frame.
+	Code uint32
+	// ID of the stream affected by the error, or zero for connection errors.
+	StreamID uint32
+	// The actual error message raised.
+	Message string
+}
+
+// Returns the error message associated with the http2Error instance.
+func (he *http2Error) Error() string {
+	return fmt.Sprintf("HTTP2Error :: Code: (%d) :: Stream: (%d) :: %s", he.Code, he.StreamID, he.Message)
+}
+
+// Structure to represent a single HTTP/2 frame.
+type http2Frame struct {
+	// Type of the frame.
+	Type byte
+	// Flags set for the frame.
+	Flags byte
+	// ID of the stream the frame belongs to, or zero for frames that apply to the connection.
+	StreamID uint32
+	// Payload of the frame.
+	Payload []byte
+}
+
+// Returns true if the given flag is set for the frame.
+func (hf *http2Frame) hasFlag(flag byte) bool {
+	return hf.Flags & flag != 0
+}
+
+// Removes the padding from the payload of a DATA, HEADERS or PUSH_PROMISE frame, if the frame is padded.
+func (hf *http2Frame) removePadding() error {
+	if !hf.hasFlag(http2FlagPadded) {
+		return nil
+	}
+	if len(hf.Payload) == 0 || int(hf.Payload[0]) >= len(hf.Payload) {
+		return &http2Error{ Code: http2ProtocolError, Message: "Padding is longer than the frame payload" }
+	}
+	padLength := int(hf.Payload[0])
+	hf.Payload = hf.Payload[1:len(hf.Payload) - padLength]
+	return nil
+}
+
+// Structure to represent a single stream of a HTTP/2 connection, which carries one request and its response.
+type http2Stream struct {
+	// ID of the stream.
+	id uint32
+	// The connection the stream belongs to.
+	conn *http2Conn
+	// Flow control window for the data sent to the client on the stream. It is guarded by the mutex of the connection.
+	sendWindow int64
+	// Flow control window for the data received from the client on the stream. It is guarded by the mutex of the connection.
+	recvWindow int64
+	// The request received on the stream. It is nil until the request headers have been received.
+	request *HttpRequest
+	// The route matched for the request, if any.
+	route *Route
+	// Error raised while matching the route for the request, if any.
+	matchErr error
+	// Flag to determine if the client has finished sending the request.
+	remoteClosed bool
+	// Flag to determine if the server has finished sending the response.
+	localClosed bool
+	// Flag to determine if the stream has been reset by either end, or the connection has been closed. It is guarded by the mutex of the connection.
+	reset bool
+	// Channel closed once the stream has been reset, so that handlers streaming a response can stop writing.
+	resetCh chan struct{}
+	// Flag to determine if the request has been answered with an error response, so that the remaining request data must be discarded.
+	rejected bool
+	// Flag to determine if a handler has been started for the stream, which removes the stream from the connection once it completes.
+	dispatched bool
+	// Value of the content-length header of the request, or -1 if it was not sent.
+	contentLength int64
+	// Pipe through which the request body is passed to a route handler streaming the body, or nil if the body is buffered in the request.
+	body *http2RequestBody
+	// Number of request body bytes buffered in the request, which count towards the limit of the connection. It is guarded by the mutex of the connection.
+	bufferedBytes int64
+}
+
+// Marks the stream as reset and notifies the handler of the stream. The caller must hold the mutex of the connection.
+func (hs *http2Stream) markReset() {
+	if !hs.reset {
+		hs.reset = true
+		close(hs.resetCh)
+	}
+}
+
+// Writes the status and headers of the response to the stream, as a HEADERS frame followed by CONTINUATION frames as needed.
+func (hs *http2Stream) writeHeaders(statusCode int, headers Headers, endStream bool) error {
+	fields := make([]hpackField, 0)
+	fields = append(fields, hpackField{ Name: ":status", Value: strconv.Itoa(statusCode) })
+	fields = append(fields, http2HeaderFields(headers)...)
+	return hs.conn.writeHeaderBlock(hs, fields, endStream)
+}
+
+// Completes the response sent on the stream, by sending the given trailer fields or an empty DATA frame with the END_STREAM flag set.
+func (hs *http2Stream) finish(trailers Headers) error {
+	trailerFields := make(Headers)
+	for key, values := range trailers {
+		if !slices.Contains(ForbiddenTrailers, textproto.CanonicalMIMEHeaderKey(key)) {
+			trailerFields[key] = values
+		}
+	}
+
+	if trailerFields.Length() > 0 {
+		return hs.conn.writeHeaderBlock(hs, http2HeaderFields(trailerFields), true)
+	}
+	return hs.writeData(nil, true)
+}
+
+// Writes the given bytes as DATA frames on the stream, waiting for the flow control windows of the stream and the connection to allow it.
+// The END_STREAM flag is set on the last frame if endStream is true.
+func (hs *http2Stream) writeData(data []byte, endStream bool) error {
+	hc := hs.conn
+	for {
+		hc.mu.Lock()
+		for len(data) > 0 && !hs.reset && !hc.closed && (hs.sendWindow <= 0 || hc.sendWindow <= 0) {
+			hc.cond.Wait()
+		}
+		if hs.reset || hc.closed || hs.localClosed {
+			hc.mu.Unlock()
+			return &http2Error{ Code: http2StreamClosedError, StreamID: hs.id, Message: "Stream has been closed and no more data can be sent" }
+		}
+
+		length := min(int64(len(data)), hs.sendWindow, hc.sendWindow, int64(hc.peerMaxFrameSize))
+		hs.sendWindow -= length
+		hc.sendWindow -= length
+		chunk := data[:length]
+		data = data[length:]
+		last := endStream && len(data) == 0
+		if last {
+			hs.localClosed = true
+		}
+		hc.mu.Unlock()
+
+		var flags byte
+		if last {
+			flags = http2FlagEndStream
+		}
+		err := hc.writeFrame(http2FrameData, flags, hs.id, chunk)
+		if err != nil {
+			return err
+		}
+		if len(data) == 0 {
+			return nil
+		}
+	}
+}
+
+// Adapter to stream the body of a response as DATA frames on a HTTP/2 stream.
+type http2BodyWriter struct {
+	// The stream on which the response body is sent.
+	stream *http2Stream
+}
+
+// Writes the given bytes as DATA frames on the stream.
+func (hbw *http2BodyWriter) Write(data []byte) (int, error) {
+	err := hbw.stream.writeData(data, false)
+	if err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// Pipe through which the request body received in DATA frames is passed to a route handler streaming the body, as the handler reads it.
+// The flow control window of the stream is restored only as the body is read, so that the client cannot send more data than the stream window ahead of the handler.
+type http2RequestBody struct {
+	// The stream on which the body is received.
+	stream *http2Stream
+	// Body bytes received from the client and not yet read by the handler. It is guarded by the mutex of the connection.
+	data bytes.Buffer
+	// Number of body bytes received from the client so far. It is used only by the goroutine reading frames.
+	received int64
+	// Number of body bytes read by the handler since the last WINDOW_UPDATE frame sent for the stream. It is guarded by the mutex of the connection.
+	consumed int64
+	// Error returned once the buffered bytes have been read, which is io.EOF once the client has sent the whole body. It is guarded by the mutex of the connection.
+	err error
+}
+
+// Reads the next part of the request body, waiting for the client to send it. A WINDOW_UPDATE frame is sent for the stream once half of its window has been read.
+func (hrb *http2RequestBody) Read(data []byte) (int, error) {
+	stream := hrb.stream
+	hc := stream.conn
+	hc.mu.Lock()
+	for hrb.data.Len() == 0 && hrb.err == nil && !stream.reset {
+		hc.cond.Wait()
+	}
+	if hrb.data.Len() == 0 {
+		err := hrb.err
+		hc.mu.Unlock()
+		if err == nil {
+			reqError := new(RequestParseError)
+			reqError.Section = "Body"
+			reqError.Value = "Request Body"
+			reqError.Message = "Stream has been reset before the request body was received"
+			err = reqError
+		}
+		return 0, err
+	}
+
+	count, _ := hrb.data.Read(data)
+	var increment int64
+	if hrb.err == nil && !stream.reset {
+		hrb.consumed += int64(count)
+		if hrb.consumed >= hc.initialWindowSize / 2 {
+			increment = hrb.consumed
+			hrb.consumed = 0
+			stream.recvWindow += increment
+		}
+	}
+	hc.mu.Unlock()
+
+	if increment > 0 {
+		hc.writeWindowUpdate(stream.id, uint32(increment))
+	}
+	return count, nil
+}
+
+// Returns true if the client has sent the whole request body.
+func (hrb *http2RequestBody) complete() bool {
+	hc := hrb.stream.conn
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	return hrb.err == io.EOF
+}
+
+// Structure to represent a HTTP/2 connection with a client, which multiplexes many concurrent streams.
+type http2Conn struct {
+	// The server instance processing the requests received on the connection.
+	srv *HttpServer
+	// The client connection.
+	conn net.Conn
+	// Buffered reader for the frames received from the client.
+	reader *bufio.Reader
+	// Size limits enforced while decoding the header blocks received from the client, before the route of each request is known.
+	headLimits RequestLimits
+	// Mutex to ensure that frames are written to the client one at a time, and that header blocks are not interleaved with other frames.
+	writeMu sync.Mutex
+	// Buffered writer for the frames sent to the client. It is guarded by writeMu.
+	writer *bufio.Writer
+	// HPACK encoder for the header blocks sent to the client. It is guarded by writeMu.
+	encoder *hpackEncoder
+	// HPACK decoder for the header blocks received from the client. It is used only by the goroutine reading frames.
+	decoder *hpackDecoder
+	// Mutex to synchronize access to the streams and the flow control windows.
+	mu sync.Mutex
+	// Condition signalled when a flow control window grows, a stream is closed or the connection is closed.
+	cond *sync.Cond
+	// Collection of all the streams that have not been closed yet, mapped by their ID.
+	streams map[uint32]*http2Stream
+	// ID of the last stream opened by the client.
+	lastStreamID uint32
+	// Flow control window for the data sent to the client on the connection.
+	sendWindow int64
+	// Flow control window for the data received from the client on the connection.
+	recvWindow int64
+	// Initial flow control window for the data sent to the client on new streams, as per the settings of the client.
+	peerInitialWindowSize int64
+	// Maximum size of the frames that can be sent to the client, as per the settings of the client.
+	peerMaxFrameSize int
+	// Maximum number of streams the client can open at the same time.
+	maxConcurrentStreams int
+	// Initial flow control window for the data received from the client on new streams.
+	initialWindowSize int64
+	// Number of request body bytes buffered in the requests of all the open streams. It is guarded by mu.
+	bufferedBytes int64
+	// Maximum number of request body bytes that can be buffered in the requests of all the open streams, which bounds the memory used by the connection.
+	maxBufferedBytes int64
+	// Flag to determine if the connection has been closed.
+	closed bool
+	// Flag to determine if a GOAWAY frame has been sent to the client, after which new streams are refused.
+	goingAway bool
+	// ID of the stream for which CONTINUATION frames are expected, or zero if no header block is being received.
+	continuationStream uint32
+	// Header block fragments received so far for the stream expecting CONTINUATION frames.
+	headerBlock []byte
+	// Flags of the HEADERS frame that started the header block being received.
+	headerFlags byte
+	// Waitgroup to synchronize the completion of all the stream handlers.
+	handlers sync.WaitGroup
+	// Channel closed once the connection has been closed.
+	done chan struct{}
+}
+
+// Creates a new HTTP/2 connection for the given client connection and returns a reference to the instance.
+func (srv *HttpServer) newHTTP2Conn(conn net.Conn, reader *bufio.Reader) *http2Conn {
+	hc := new(http2Conn)
+	hc.srv = srv
+	hc.conn = conn
+	hc.reader = reader
+	hc.writer = bufio.NewWriter(conn)
+	hc.encoder = new(hpackEncoder)
+	hc.decoder = newHpackDecoder(http2DefaultHeaderTableSize)
+	hc.headLimits = srv.getHeadLimits()
+	hc.decoder.maxHeaderListSize = hc.headLimits.MaxHeaderBytes
+	hc.cond = sync.NewCond(&hc.mu)
+	hc.streams = make(map[uint32]*http2Stream)
+	hc.sendWindow = http2DefaultWindowSize
+	hc.recvWindow = http2DefaultWindowSize
+	hc.peerInitialWindowSize = http2DefaultWindowSize
+	hc.peerMaxFrameSize = http2DefaultMaxFrameSize
+	hc.maxConcurrentStreams = GetServerDefaults("http2_max_concurrent_streams").(int)
+	hc.initialWindowSize = int64(GetServerDefaults("http2_initial_window_size").(int))
+	hc.maxBufferedBytes = int64(GetServerDefaults("http2_max_buffered_body_size").(int))
+	hc.done = make(chan struct{})
+	return hc
+}
+
+// Serves the HTTP/2 connection with the client until either end closes it.
+// If the connection was upgraded from HTTP/1.1, the request that asked for the upgrade is answered on stream 1, using the settings sent by the client in the upgrade request.
+func (srv *HttpServer) serveHTTP2(conn net.Conn, reader *bufio.Reader, upgradeRequest *HttpRequest, upgradeRoute *Route, upgradeMatchErr error, upgradeSettings []byte) {
+	hc := srv.newHTTP2Conn(conn, reader)
+	defer hc.close()
+
+	if upgradeRequest != nil {
+		err := hc.applySettings(upgradeSettings)
+		if err != nil {
+			srv.Log(err.Error(), ERROR_LEVEL)
+			return
+		}
+	}
+
+	err := hc.writeServerSettings()
+	if err != nil {
+		srv.Log(fmt.Sprintf("Error occurred while sending HTTP/2 settings to client [%s]: %s", conn.RemoteAddr().String(), err.Error()), ERROR_LEVEL)
+		return
+	}
+
+	if upgradeRequest != nil {
+		upgradeRequest.Version = "2.0"
+		upgradeRequest.conn = nil
+		stream := hc.newStream(1)
+		stream.request = upgradeRequest
+		stream.route = upgradeRoute
+		stream.matchErr = upgradeMatchErr
+		stream.remoteClosed = true
+		hc.lastStreamID = 1
+		hc.streams[1] = stream
+		hc.dispatch(stream)
+	}
+
+	if srv.ReadHeaderTimeout > 0 {
+		conn.SetReadDeadline(time.Now().Add(srv.ReadHeaderTimeout))
+	}
+	preface := make([]byte, len(HTTP2_PREFACE))
+	_, err = io.ReadFull(reader, preface)
+	if err != nil || string(preface) != HTTP2_PREFACE {
+		srv.Log(fmt.Sprintf("Client [%s] did not send a valid HTTP/2 connection preface", conn.RemoteAddr().String()), ERROR_LEVEL)
+		hc.goAway(http2ProtocolError)
+		return
+	}
+
+	go hc.watchShutdown()
+	for {
+		hc.mu.Lock()
+		idle := len(hc.streams) == 0
+		hc.mu.Unlock()
+		if idle && srv.IdleTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(srv.IdleTimeout))
+		} else {
+			conn.SetReadDeadline(time.Time{})
+		}
+
+		frame, err := hc.readFrame()
+		if err == nil {
+			err = hc.processFrame(frame)
+		}
+		if err != nil {
+			h2Err, ok := err.(*http2Error)
+			if ok && h2Err.StreamID != 0 {
+				hc.resetStream(h2Err.StreamID, h2Err.Code)
+				continue
+			}
+
+			if ok {
+				srv.Log(err.Error(), ERROR_LEVEL)
+				hc.goAway(h2Err.Code)
+			} else if netErr, isNetErr := err.(net.Error); isNetErr && netErr.Timeout() {
+				srv.Log(fmt.Sprintf("Client connection [%s] has timed out.", conn.RemoteAddr().String()), INFO_LEVEL)
+				hc.goAway(http2NoError)
+			}
+			return
+		}
+	}
+}
+
+// Returns true if the client has started the connection by sending the HTTP/2 connection preface, without negotiating HTTP/2 first (prior knowledge).
+// No HTTP/1.x method starts with "PRI", so the rest of the preface is awaited only if the first bytes match.
+func (srv *HttpServer) hasHTTP2Preface(reader *bufio.Reader) bool {
+	start, err := reader.Peek(3)
+	if err != nil || string(start) != HTTP2_PREFACE[:3] {
+		return false
+	}
+	preface, err := reader.Peek(len(HTTP2_PREFACE))
+	return err == nil && string(preface) == HTTP2_PREFACE
+}
+
+// Returns the settings sent by the client in the "HTTP2-Settings" header, if the request asks to switch the connection to HTTP/2 using "Upgrade: h2c".
+// The boolean value returned is false if the request does not ask for the upgrade or if the "HTTP2-Settings" header is not valid, in which case the request is processed over HTTP/1.1.
+func (req *HttpRequest) getHTTP2Upgrade() ([]byte, bool) {
+	if !strings.EqualFold(req.Version, "1.1") {
+		return nil, false
+	}
+
+	upgrade, ok := req.Headers.Get("Upgrade")
+	if !ok || !slices.ContainsFunc(strings.Split(upgrade, ","), func(protocol string) bool {
+		return strings.EqualFold(strings.TrimSpace(protocol), HTTP2_CLEARTEXT_PROTOCOL)
+	}) {
+		return nil, false
+	}
+
+	values, ok := req.Headers["Http2-Settings"]
+	if !ok || len(values) != 1 {
+		return nil, false
+	}
+	settings, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(strings.TrimSpace(values[0]), "="))
+	if err != nil || len(settings) % 6 != 0 {
+		return nil, false
+	}
+	return settings, true
+}
+
+// Closes the connection, resets all the streams that are still open and waits for their handlers to complete.
+func (hc *http2Conn) close() {
+	hc.mu.Lock()
+	if hc.closed {
+		hc.mu.Unlock()
+		return
+	}
+	hc.closed = true
+	for _, stream := range hc.streams {
+		stream.markReset()
+	}
+	hc.cond.Broadcast()
+	hc.mu.Unlock()
+
+	close(hc.done)
+	hc.conn.Close()
+	hc.handlers.Wait()
+}
+
+// Waits for the server to shutdown, after which the client is told not to open new streams and the connection is closed once the open streams are complete.
+func (hc *http2Conn) watchShutdown() {
+	select {
+	case <-hc.srv.shutdown:
+	case <-hc.done:
+		return
+	}
+
+	hc.goAway(http2NoError)
+	hc.mu.Lock()
+	for len(hc.streams) > 0 && !hc.closed {
+		hc.cond.Wait()
+	}
+	hc.mu.Unlock()
+	hc.conn.Close()
+}
+
+// Reads the next frame from the client.
+func (hc *http2Conn) readFrame() (*http2Frame, error) {
+	header := make([]byte, http2FrameHeaderLength)
+	_, err := io.ReadFull(hc.reader, header)
+	if err != nil {
+		return nil, err
+	}
+
+	length := int(header[0]) << 16 | int(header[1]) << 8 | int(header[2])
+	frame := new(http2Frame)
+	frame.Type = header[3]
+	frame.Flags = header[4]
+	frame.StreamID = binary.BigEndian.Uint32(header[5:]) & 0x7fffffff
+	if length > http2DefaultMaxFrameSize {
+		return nil, &http2Error{ Code: http2FrameSizeError, Message: fmt.Sprintf("Frame of %d bytes is larger than the maximum frame size", length) }
+	}
+
+	frame.Payload = make([]byte, length)
+	_, err = io.ReadFull(hc.reader, frame.Payload)
+	if err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+// Writes a single frame to the client.
+func (hc *http2Conn) writeFrame(frameType byte, flags byte, streamID uint32, payload []byte) error {
+	hc.writeMu.Lock()
+	defer hc.writeMu.Unlock()
+	err := hc.appendFrame(frameType, flags, streamID, payload)
+	if err != nil {
+		return err
+	}
+	return hc.writer.Flush()
+}
+
+// Writes a single frame to the buffered writer of the connection. The caller must hold writeMu and flush the writer.
+func (hc *http2Conn) appendFrame(frameType byte, flags byte, streamID uint32, payload []byte) error {
+	if hc.srv.WriteTimeout > 0 {
+		hc.conn.SetWriteDeadline(time.Now().Add(hc.srv.WriteTimeout))
+	}
+
+	header := make([]byte, http2FrameHeaderLength)
+	header[0] = byte(len(payload) >> 16)
+	header[1] = byte(len(payload) >> 8)
+	header[2] = byte(len(payload))
+	header[3] = frameType
+	header[4] = flags
+	binary.BigEndian.PutUint32(header[5:], streamID & 0x7fffffff)
+	_, err := hc.writer.Write(header)
+	if err != nil {
+		return err
+	}
+	_, err = hc.writer.Write(payload)
+	return err
+}
+
+// Encodes the given header fields and writes them to the client as a HEADERS frame, followed by CONTINUATION frames if the header block does not fit in a single frame.
+func (hc *http2Conn) writeHeaderBlock(stream *http2Stream, fields []hpackField, endStream bool) error {
+	hc.mu.Lock()
+	if stream.reset || hc.closed || stream.localClosed {
+		hc.mu.Unlock()
+		return &http2Error{ Code: http2StreamClosedError, StreamID: stream.id, Message: "Stream has been closed and no more headers can be sent" }
+	}
+	if endStream {
+		stream.localClosed = true
+	}
+	maxFrameSize := hc.peerMaxFrameSize
+	hc.mu.Unlock()
+
+	hc.writeMu.Lock()
+	defer hc.writeMu.Unlock()
+	block := hc.encoder.encode(fields)
+	frameType := http2FrameHeaders
+	for {
+		fragment := block[:min(len(block), maxFrameSize)]
+		block = block[len(fragment):]
+		var flags byte
+		if frameType == http2FrameHeaders && endStream {
+			flags |= http2FlagEndStream
+		}
+		if len(block) == 0 {
+			flags |= http2FlagEndHeaders
+		}
+
+		err := hc.appendFrame(frameType, flags, stream.id, fragment)
+		if err != nil {
+			return err
+		}
+		if len(block) == 0 {
+			return hc.writer.Flush()
+		}
+		frameType = http2FrameContinuation
+	}
+}
+
+// Sends the settings of the server to the client, along with a WINDOW_UPDATE frame to enlarge the connection flow control window.
+func (hc *http2Conn) writeServerSettings() error {
+	settings := []struct {
+		id uint16
+		value uint32
+	} {
+		{ http2SettingMaxConcurrentStreams, uint32(hc.maxConcurrentStreams) },
+		{ http2SettingInitialWindowSize, uint32(hc.initialWindowSize) },
+		{ http2SettingMaxFrameSize, http2DefaultMaxFrameSize },
+		{ http2SettingEnablePush, 0 },
+	}
+	if hc.headLimits.MaxHeaderBytes > 0 {
+		settings = append(settings, struct {
+			id uint16
+			value uint32
+		} { http2SettingMaxHeaderListSize, uint32(hc.headLimits.MaxHeaderBytes) })
+	}
+
+	payload := make([]byte, 0)
+	for _, setting := range settings {
+		payload = binary.BigEndian.AppendUint16(payload, setting.id)
+		payload = binary.BigEndian.AppendUint32(payload, setting.value)
+	}
+
+	err := hc.writeFrame(http2FrameSettings, 0, 0, payload)
+	if err != nil {
+		return err
+	}
+
+	if hc.initialWindowSize > http2DefaultWindowSize {
+		hc.recvWindow = hc.initialWindowSize
+		return hc.writeWindowUpdate(0, uint32(hc.initialWindowSize - http2DefaultWindowSize))
+	}
+	return nil
+}
+
+// Sends a WINDOW_UPDATE frame to the client for the given stream, or for the connection if the stream ID is zero.
+func (hc *http2Conn) writeWindowUpdate(streamID uint32, increment uint32) error {
+	payload := binary.BigEndian.AppendUint32(nil, increment)
+	return hc.writeFrame(http2FrameWindowUpdate, 0, streamID, payload)
+}
+
+// Sends a GOAWAY frame to the client with the given error code, after which no new streams are accepted on the connection.
+func (hc *http2Conn) goAway(code uint32) {
+	hc.mu.Lock()
+	if hc.goingAway {
+		hc.mu.Unlock()
+		return
+	}
+	hc.goingAway = true
+	lastStreamID := hc.lastStreamID
+	hc.mu.Unlock()
+
+	payload := binary.BigEndian.AppendUint32(nil, lastStreamID)
+	payload = binary.BigEndian.AppendUint32(payload, code)
+	hc.writeFrame(http2FrameGoAway, 0, 0, payload)
+}
+
+// Resets the given stream by sending a RST_STREAM frame with the given error code to the client.
+func (hc *http2Conn) resetStream(streamID uint32, code uint32) {
+	hc.mu.Lock()
+	stream, ok := hc.streams[streamID]
+	if ok {
+		stream.markReset()
+		if !stream.dispatched {
+			hc.removeStream(stream)
+		}
+		hc.cond.Broadcast()
+	}
+	hc.mu.Unlock()
+
+	payload := binary.BigEndian.AppendUint32(nil, code)
+	hc.writeFrame(http2FrameRstStream, 0, streamID, payload)
+}
+
+// Creates a new stream with the given ID, using the current flow control settings of the connection.
+func (hc *http2Conn) newStream(streamID uint32) *http2Stream {
+	stream := new(http2Stream)
+	stream.id = streamID
+	stream.conn = hc
+	stream.sendWindow = hc.peerInitialWindowSize
+	stream.recvWindow = hc.initialWindowSize
+	stream.contentLength = -1
+	stream.resetCh = make(chan struct{})
+	return stream
+}
+
+// Removes the given stream from the connection once both ends have finished with it.
+func (hc *http2Conn) closeStream(stream *http2Stream) {
+	hc.mu.Lock()
+	hc.removeStream(stream)
+	hc.cond.Broadcast()
+	hc.mu.Unlock()
+}
+
+// Removes the given stream from the connection and releases the request body bytes buffered for it. The caller must hold the mutex of the connection.
+func (hc *http2Conn) removeStream(stream *http2Stream) {
+	delete(hc.streams, stream.id)
+	hc.bufferedBytes -= stream.bufferedBytes
+	stream.bufferedBytes = 0
+}
+
+// Processes a single frame received from the client.
+func (hc *http2Conn) processFrame(frame *http2Frame) error {
+	if hc.continuationStream != 0 && (frame.Type != http2FrameContinuation || frame.StreamID != hc.continuationStream) {
+		return &http2Error{ Code: http2ProtocolError, Message: "Expected a CONTINUATION frame for the header block being received" }
+	}
+
+	switch frame.Type {
+	case http2FrameSettings:
+		return hc.processSettings(frame)
+	case http2FramePing:
+		if frame.StreamID != 0 {
+			return &http2Error{ Code: http2ProtocolError, Message: "PING frame must not be sent on a stream" }
+		}
+		if len(frame.Payload) != 8 {
+			return &http2Error{ Code: http2FrameSizeError, Message: "PING frame must have a payload of 8 bytes" }
+		}
+		if !frame.hasFlag(http2FlagAck) {
+			return hc.writeFrame(http2FramePing, http2FlagAck, 0, frame.Payload)
+		}
+		return nil
+	case http2FrameWindowUpdate:
+		return hc.processWindowUpdate(frame)
+	case http2FrameHeaders:
+		return hc.processHeaders(frame)
+	case http2FrameContinuation:
+		if hc.continuationStream == 0 {
+			return &http2Error{ Code: http2ProtocolError, Message: "CONTINUATION frame received without a preceding HEADERS frame" }
+		}
+		hc.headerBlock = append(hc.headerBlock, frame.Payload...)
+		// The header block is capped even when the header size is not limited, since it is buffered until the last CONTINUATION frame is received.
+		maxBlockSize := GetServerDefaults("http2_max_header_block_size").(int)
+		if hc.headLimits.MaxHeaderBytes > 0 {
+			maxBlockSize = 2 * hc.headLimits.MaxHeaderBytes
+		}
+		if len(hc.headerBlock) > maxBlockSize {
+			return &http2Error{ Code: http2ProtocolError, Message: "Header block is larger than the allowed size" }
+		}
+		if frame.hasFlag(http2FlagEndHeaders) {
+			streamID := hc.continuationStream
+			hc.continuationStream = 0
+			return hc.processHeaderBlock(streamID, hc.headerFlags, hc.headerBlock)
+		}
+		return nil
+	case http2FrameData:
+		return hc.processData(frame)
+	case http2FrameRstStream:
+		if frame.StreamID == 0 {
+			return &http2Error{ Code: http2ProtocolError, Message: "RST_STREAM frame must be sent on a stream" }
+		}
+		if len(frame.Payload) != 4 {
+			return &http2Error{ Code: http2FrameSizeError, Message: "RST_STREAM frame must have a payload of 4 bytes" }
+		}
+		hc.mu.Lock()
+		stream, ok := hc.streams[frame.StreamID]
+		if ok {
+			stream.markReset()
+			if !stream.dispatched {
+				hc.removeStream(stream)
+			}
+			hc.cond.Broadcast()
+		}
+		hc.mu.Unlock()
+		return nil
+	case http2FrameGoAway:
+		hc.mu.Lock()
+		hc.goingAway = true
+		hc.mu.Unlock()
+		return nil
+	case http2FramePushPromise:
+		return &http2Error{ Code: http2ProtocolError, Message: "Clients must not send PUSH_PROMISE frames" }
+	case http2FramePriority:
+		if frame.StreamID == 0 {
+			return &http2Error{ Code: http2ProtocolError, Message: "PRIORITY frame must be sent on a stream" }
+		}
+		return nil
+	}
+
+	// Frames of unknown types are ignored, as per RFC 9113.
+	return nil
+}
+
+// Processes a SETTINGS frame received from the client and acknowledges it.
+func (hc *http2Conn) processSettings(frame *http2Frame) error {
+	if frame.StreamID != 0 {
+		return &http2Error{ Code: http2ProtocolError, Message: "SETTINGS frame must not be sent on a stream" }
+	}
+	if frame.hasFlag(http2FlagAck) {
+		if len(frame.Payload) != 0 {
+			return &http2Error{ Code: http2FrameSizeError, Message: "SETTINGS acknowledgement must not have a payload" }
+		}
+		return nil
+	}
+
+	err := hc.applySettings(frame.Payload)
+	if err != nil {
+		return err
+	}
+	return hc.writeFrame(http2FrameSettings, http2FlagAck, 0, nil)
+}
+
+// Applies the settings sent by the client in a SETTINGS frame payload.
+func (hc *http2Conn) applySettings(payload []byte) error {
+	if len(payload) % 6 != 0 {
+		return &http2Error{ Code: http2FrameSizeError, Message: "SETTINGS frame payload must be a multiple of 6 bytes" }
+	}
+
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	for offset := 0; offset < len(payload); offset += 6 {
+		id := binary.BigEndian.Uint16(payload[offset:])
+		value := binary.BigEndian.Uint32(payload[offset + 2:])
+		switch id {
+		case http2SettingEnablePush:
+			if value > 1 {
+				return &http2Error{ Code: http2ProtocolError, Message: "SETTINGS_ENABLE_PUSH must be either 0 or 1" }
+			}
+		case http2SettingInitialWindowSize:
+			if value > http2MaxWindowSize {
+				return &http2Error{ Code: http2FlowControlError, Message: "SETTINGS_INITIAL_WINDOW_SIZE is larger than the maximum window size" }
+			}
+			delta := int64(value) - hc.peerInitialWindowSize
+			hc.peerInitialWindowSize = int64(value)
+			for _, stream := range hc.streams {
+				stream.sendWindow += delta
+			}
+			hc.cond.Broadcast()
+		case http2SettingMaxFrameSize:
+			if value < http2DefaultMaxFrameSize || value > http2MaxAllowedFrameSize {
+				return &http2Error{ Code: http2ProtocolError, Message: "SETTINGS_MAX_FRAME_SIZE is outside the allowed range" }
+			}
+			hc.peerMaxFrameSize = int(value)
+		}
+	}
+	return nil
+}
+
+// Processes a WINDOW_UPDATE frame received from the client, enlarging the flow control window of the connection or of a stream.
+func (hc *http2Conn) processWindowUpdate(frame *http2Frame) error {
+	if len(frame.Payload) != 4 {
+		return &http2Error{ Code: http2FrameSizeError, Message: "WINDOW_UPDATE frame must have a payload of 4 bytes" }
+	}
+
+	increment := int64(binary.BigEndian.Uint32(frame.Payload) & 0x7fffffff)
+	if increment == 0 {
+		return &http2Error{ Code: http2ProtocolError, StreamID: frame.StreamID, Message: "WINDOW_UPDATE frame must have a non-zero increment" }
+	}
+
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	if frame.StreamID == 0 {
+		if hc.sendWindow + increment > http2MaxWindowSize {
+			return &http2Error{ Code: http2FlowControlError, Message: "Connection flow control window is larger than the maximum window size" }
+		}
+		hc.sendWindow += increment
+	} else {
+		stream, ok := hc.streams[frame.StreamID]
+		if !ok {
+			return nil
+		}
+		if stream.sendWindow + increment > http2MaxWindowSize {
+			stream.markReset()
+			hc.cond.Broadcast()
+			return &http2Error{ Code: http2FlowControlError, StreamID: frame.StreamID, Message: "Stream flow control window is larger than the maximum window size" }
+		}
+		stream.sendWindow += increment
+	}
+	hc.cond.Broadcast()
+	return nil
+}
+
+// Processes a HEADERS frame received from the client, which either opens a new stream or carries the trailers of a request.
+func (hc *http2Conn) processHeaders(frame *http2Frame) error {
+	if frame.StreamID == 0 {
+		return &http2Error{ Code: http2ProtocolError, Message: "HEADERS frame must be sent on a stream" }
+	}
+
+	err := frame.removePadding()
+	if err != nil {
+		return err
+	}
+	if frame.hasFlag(http2FlagPriority) {
+		if len(frame.Payload) < 5 {
+			return &http2Error{ Code: http2FrameSizeError, Message: "HEADERS frame is too short for the priority fields" }
+		}
+		frame.Payload = frame.Payload[5:]
+	}
+
+	if !frame.hasFlag(http2FlagEndHeaders) {
+		hc.continuationStream = frame.StreamID
+		hc.headerFlags = frame.Flags
+		hc.headerBlock = append(make([]byte, 0, len(frame.Payload)), frame.Payload...)
+		return nil
+	}
+	return hc.processHeaderBlock(frame.StreamID, frame.Flags, frame.Payload)
+}
+
+// Processes a complete header block received from the client on the given stream.
+func (hc *http2Conn) processHeaderBlock(streamID uint32, flags byte, block []byte) error {
+	// The header block is always decoded, even for streams that are refused, to keep the HPACK state in sync with the client.
+	fields, err := hc.decoder.decode(block)
+	if err != nil {
+		return &http2Error{ Code: http2CompressionError, Message: err.Error() }
+	}
+	endStream := flags & http2FlagEndStream != 0
+
+	hc.mu.Lock()
+	stream, exists := hc.streams[streamID]
+	hc.mu.Unlock()
+	if exists {
+		if stream.remoteClosed || !endStream {
+			return &http2Error{ Code: http2ProtocolError, StreamID: streamID, Message: "Trailers must end the stream" }
+		}
+		for _, field := range fields {
+			if strings.HasPrefix(field.Name, ":") {
+				return &http2Error{ Code: http2ProtocolError, StreamID: streamID, Message: "Trailers must not contain pseudo-header fields" }
+			}
+			if !slices.Contains(ForbiddenTrailers, textproto.CanonicalMIMEHeaderKey(field.Name)) {
+				stream.request.Trailers.Add(field.Name, field.Value)
+			}
+		}
+		return hc.endOfRequest(stream)
+	}
+
+	if streamID % 2 == 0 || streamID <= hc.lastStreamID {
+		return &http2Error{ Code: http2ProtocolError, Message: fmt.Sprintf("Invalid stream ID [%d] for a new stream", streamID) }
+	}
+	hc.mu.Lock()
+	hc.lastStreamID = streamID
+	refused := hc.goingAway || len(hc.streams) >= hc.maxConcurrentStreams
+	stream = hc.newStream(streamID)
+	if !refused {
+		hc.streams[streamID] = stream
+	}
+	hc.mu.Unlock()
+	if refused {
+		return &http2Error{ Code: http2RefusedStreamError, StreamID: streamID, Message: "Stream refused by the server" }
+	}
+
+	request, err := hc.srv.newHTTP2Request(hc, fields)
+	if err != nil {
+		hc.closeStream(stream)
+		return &http2Error{ Code: http2ProtocolError, StreamID: streamID, Message: err.Error() }
+	}
+	stream.request = request
+	stream.remoteClosed = endStream
+
+	clength, ok := request.Headers.Get("Content-Length")
+	if ok {
+		stream.contentLength, err = strconv.ParseInt(strings.TrimSpace(clength), 10, 64)
+		if err != nil || stream.contentLength < 0 {
+			hc.closeStream(stream)
+			return &http2Error{ Code: http2ProtocolError, StreamID: streamID, Message: "Invalid content-length header value" }
+		}
+	}
+
+	// The route is matched before the request body is received, so that the size limits configured for the route can be enforced on the body.
+	limits := hc.srv.Limits
+	stream.route, stream.matchErr = hc.srv.matchRoute(request)
+	if stream.matchErr == nil {
+		limits = limits.merge(stream.route.Limits)
+	}
+	err = request.applyLimits(limits)
+	if err == nil && limits.MaxBodySize > 0 && stream.contentLength > limits.MaxBodySize {
+		err = request.limitError("Body", fmt.Sprintf("Request body is larger than the limit of %d bytes", limits.MaxBodySize), Status413)
+	}
+	if err != nil {
+		hc.reject(stream, err)
+		return nil
+	}
+
+	if endStream {
+		return hc.endOfRequest(stream)
+	}
+	// Routes that stream the request body are executed right away, and read the body from a pipe as it arrives in DATA frames.
+	if stream.matchErr == nil && stream.route.StreamBody {
+		stream.body = &http2RequestBody{ stream: stream }
+		request.streamBody = true
+		request.bodySource = stream.body
+		hc.dispatch(stream)
+	}
+	return nil
+}
+
+// Processes a DATA frame received from the client, which carries a part of the request body.
+func (hc *http2Conn) processData(frame *http2Frame) error {
+	if frame.StreamID == 0 {
+		return &http2Error{ Code: http2ProtocolError, Message: "DATA frame must be sent on a stream" }
+	}
+
+	// The whole frame counts against the flow control windows, including the padding.
+	length := int64(len(frame.Payload))
+	if length > hc.recvWindow {
+		return &http2Error{ Code: http2FlowControlError, Message: "DATA frame is larger than the connection flow control window" }
+	}
+	hc.recvWindow -= length
+	err := frame.removePadding()
+	if err != nil {
+		return err
+	}
+	// The data of every stream is moved out of the connection window as soon as it is received, either into the request body, into the pipe of a route streaming the body, or discarded.
+	// The memory used is bounded by the stream windows for streamed bodies, and by the limit on the bytes buffered by the connection for the other bodies.
+	err = hc.replenishWindow(0, &hc.recvWindow, max(hc.initialWindowSize, http2DefaultWindowSize))
+	if err != nil {
+		return err
+	}
+
+	hc.mu.Lock()
+	stream, ok := hc.streams[frame.StreamID]
+	hc.mu.Unlock()
+	if !ok {
+		if frame.StreamID > hc.lastStreamID {
+			return &http2Error{ Code: http2ProtocolError, Message: "DATA frame received on a stream that has not been opened" }
+		}
+		return nil
+	}
+	if stream.remoteClosed {
+		return &http2Error{ Code: http2StreamClosedError, StreamID: frame.StreamID, Message: "DATA frame received after the end of the stream" }
+	}
+	hc.mu.Lock()
+	exceeded := length > stream.recvWindow
+	if !exceeded {
+		stream.recvWindow -= length
+	}
+	hc.mu.Unlock()
+	if exceeded {
+		return &http2Error{ Code: http2FlowControlError, StreamID: frame.StreamID, Message: "DATA frame is larger than the stream flow control window" }
+	}
+
+	if stream.rejected {
+		if frame.hasFlag(http2FlagEndStream) {
+			stream.remoteClosed = true
+		}
+		return nil
+	}
+	if stream.body != nil {
+		return hc.receiveBody(stream, frame.Payload, frame.hasFlag(http2FlagEndStream))
+	}
+
+	request := stream.request
+	size := int64(len(frame.Payload))
+	hc.mu.Lock()
+	exceeded = hc.bufferedBytes + size > hc.maxBufferedBytes
+	if !exceeded {
+		hc.bufferedBytes += size
+		stream.bufferedBytes += size
+	}
+	hc.mu.Unlock()
+	if exceeded {
+		hc.reject(stream, request.limitError("Body", fmt.Sprintf("Request bodies buffered on the connection are larger than the limit of %d bytes", hc.maxBufferedBytes), Status413))
+		return nil
+	}
+
+	request.BodyBytes = append(request.BodyBytes, frame.Payload...)
+	if request.limits.MaxBodySize > 0 && int64(len(request.BodyBytes)) > request.limits.MaxBodySize {
+		hc.reject(stream, request.limitError("Body", fmt.Sprintf("Request body is larger than the limit of %d bytes", request.limits.MaxBodySize), Status413))
+		return nil
+	}
+
+	if frame.hasFlag(http2FlagEndStream) {
+		return hc.endOfRequest(stream)
+	}
+	return hc.replenishWindow(stream.id, &stream.recvWindow, hc.initialWindowSize)
+}
+
+// Restores the given flow control window for the data received from the client to its full size, once less than half of it is left.
+// The window is restored for the given stream, or for the connection if the stream ID is zero, so that the client is not sent a WINDOW_UPDATE frame for every DATA frame.
+func (hc *http2Conn) replenishWindow(streamID uint32, window *int64, size int64) error {
+	if *window >= size / 2 {
+		return nil
+	}
+	increment := size - *window
+	*window = size
+	return hc.writeWindowUpdate(streamID, uint32(increment))
+}
+
+// Passes the given part of the request body to the handler streaming the body of the given stream, enforcing the body size limit of the request.
+// The rest of the body is discarded once the limit is exceeded, and the handler is returned the limit error once it has read the bytes received before.
+func (hc *http2Conn) receiveBody(stream *http2Stream, data []byte, endStream bool) error {
+	body := stream.body
+	body.received += int64(len(data))
+	limit := stream.request.limits.MaxBodySize
+	hc.mu.Lock()
+	if body.err == nil {
+		if limit > 0 && body.received > limit {
+			body.err = stream.request.limitError("Body", fmt.Sprintf("Request body is larger than the limit of %d bytes", limit), Status413)
+		} else {
+			body.data.Write(data)
+		}
+	}
+	hc.cond.Broadcast()
+	hc.mu.Unlock()
+
+	if endStream {
+		return hc.endOfRequest(stream)
+	}
+	return nil
+}
+
+// Marks the end of the request received on the given stream and dispatches the request to its handler.
+// If the handler is already reading the request body from a pipe, the pipe is told that the whole body has been received instead.
+func (hc *http2Conn) endOfRequest(stream *http2Stream) error {
+	stream.remoteClosed = true
+	if stream.rejected {
+		return nil
+	}
+
+	if stream.body != nil {
+		if stream.contentLength >= 0 && stream.contentLength != stream.body.received {
+			return &http2Error{ Code: http2ProtocolError, StreamID: stream.id, Message: "Request body length does not match the content-length header" }
+		}
+		hc.mu.Lock()
+		if stream.body.err == nil {
+			stream.body.err = io.EOF
+		}
+		hc.cond.Broadcast()
+		hc.mu.Unlock()
+		return nil
+	}
+
+	request := stream.request
+	if stream.contentLength >= 0 && stream.contentLength != int64(len(request.BodyBytes)) {
+		hc.closeStream(stream)
+		return &http2Error{ Code: http2ProtocolError, StreamID: stream.id, Message: "Request body length does not match the content-length header" }
+	}
+
+	request.Locals["ContentLength"] = len(request.BodyBytes)
+	hc.dispatch(stream)
+	return nil
+}
+
+// Executes the handler for the request received on the given stream in a separate goroutine.
+func (hc *http2Conn) dispatch(stream *http2Stream) {
+	stream.dispatched = true
+	hc.handlers.Add(1)
+	go func() {
+		defer hc.handlers.Done()
+		defer hc.closeStream(stream)
+
+		request := stream.request
+		request.Locals["Started"] = time.Now()
+		response := hc.srv.newHTTP2Response(stream, request)
+		hc.srv.processRequest(hc.conn, request, response, stream.route, stream.matchErr)
+		if !response.finished {
+			hc.resetStream(stream.id, http2InternalError)
+		} else if stream.body != nil && !stream.body.complete() {
+			// The client is told to stop sending the part of the request body that was not read by the handler.
+			hc.resetStream(stream.id, http2NoError)
+		}
+	}()
+}
+
+// Answers the request received on the given stream with an error response, without waiting for the rest of the request.
+// The stream is reset once the response is sent, if the client has not finished sending the request.
+func (hc *http2Conn) reject(stream *http2Stream, err error) {
+	hc.srv.Log(err.Error(), ERROR_LEVEL)
+	stream.rejected = true
+	stream.dispatched = true
+	hc.handlers.Add(1)
+	go func() {
+		defer hc.handlers.Done()
+		defer hc.closeStream(stream)
+
+		response := hc.srv.newHTTP2Response(stream, stream.request)
+		parseErr, ok := err.(*RequestParseError)
+		if ok {
+			response.Status(parseErr.GetStatus())
+		} else {
+			response.Status(Status400)
+		}
+		ErrorHandler(stream.request, response)
+		hc.srv.logStatus(stream.request, response)
+		hc.resetStream(stream.id, http2NoError)
+	}()
+}
+
+// Creates a new HTTP request from the header fields received on a HTTP/2 stream.
+// The pseudo-header fields are validated and mapped to the method, path and host of the request, as per RFC 9113.
+func (srv *HttpServer) newHTTP2Request(hc *http2Conn, fields []hpackField) (*HttpRequest, error) {
+	httpRequest := srv.newRequest(hc.conn, strings.NewReader(""))
+	httpRequest.Version = "2.0"
+	// The request body is received in DATA frames by the connection, and is never read from the client connection by the request.
+	httpRequest.conn = nil
+
+	authority := ""
+	scheme := ""
+	// Cookies can be split across many header fields to improve compression, and are joined back into a single header as per RFC 9113.
+	cookies := make([]string, 0)
+	regularFields := false
+	for _, field := range fields {
+		if strings.ToLower(field.Name) != field.Name {
+			return nil, &CustomError{ Message: fmt.Sprintf("Header field name [%s] must be in lowercase", field.Name) }
+		}
+
+		pseudoName, isPseudo := strings.CutPrefix(field.Name, ":")
+		if isPseudo {
+			if regularFields {
+				return nil, &CustomError{ Message: "Pseudo-header fields must appear before regular header fields" }
+			}
+			var target *string
+			switch pseudoName {
+			case "method":
+				target = &httpRequest.Method
+			case "path":
+				target = &httpRequest.ResourcePath
+			case "authority":
+				target = &authority
+			case "scheme":
+				target = &scheme
+			default:
+				return nil, &CustomError{ Message: fmt.Sprintf("Pseudo-header field [%s] is not valid for requests", field.Name) }
+			}
+			if *target != "" {
+				return nil, &CustomError{ Message: fmt.Sprintf("Pseudo-header field [%s] must not be repeated", field.Name) }
+			}
+			*target = field.Value
+			continue
+		}
+
+		regularFields = true
+		if slices.Contains(http2ConnectionHeaders, field.Name) || (field.Name == "te" && !strings.EqualFold(field.Value, "trailers")) {
+			return nil, &CustomError{ Message: fmt.Sprintf("Connection-specific header field [%s] is not allowed in HTTP/2", field.Name) }
+		}
+		httpRequest.headerCount += 1
+		httpRequest.headerBytes += len(field.Name) + len(field.Value) + 4
+		if field.Name == "cookie" {
+			cookies = append(cookies, field.Value)
+		} else {
+			httpRequest.AddHeader(field.Name, field.Value)
+		}
+	}
+
+	if httpRequest.Method == "" || (!strings.EqualFold(httpRequest.Method, "CONNECT") && (httpRequest.ResourcePath == "" || scheme == "")) {
+		return nil, &CustomError{ Message: "Request must contain the :method, :scheme and :path pseudo-header fields" }
+	}
+
+	if len(cookies) > 0 {
+		httpRequest.Headers["Cookie"] = []string{ strings.Join(cookies, "; ") }
+	}
+
+	_, hasHost := httpRequest.Headers.Get("Host")
+	if authority != "" && !hasHost {
+		httpRequest.Headers.Add("Host", authority)
+	}
+	httpRequest.requestLineLength = len(httpRequest.Method) + len(httpRequest.ResourcePath) + len(" HTTP/2.0\r\n") + 1
+
+	err := httpRequest.parseQueryParams()
+	if err != nil {
+		return nil, err
+	}
+
+	httpRequest.resolveOrigin()
+	return httpRequest, nil
+}
+
+// Creates a new HTTP response to be sent on the given HTTP/2 stream.
+func (srv *HttpServer) newHTTP2Response(stream *http2Stream, request *HttpRequest) *HttpResponse {
+	var httpResponse HttpResponse
+	httpResponse.Initialize("2.0", &http2BodyWriter{ stream: stream })
+	httpResponse.Server = srv
+	httpResponse.stream = stream
+	httpResponse.noBody = request != nil && strings.EqualFold(request.Method, "HEAD")
+	return &httpResponse
+}
+
+// Converts the given headers to HPACK header fields, with lowercase names and without the connection-specific headers.
+func http2HeaderFields(headers Headers) []hpackField {
+	fields := make([]hpackField, 0)
+	for key, values := range headers {
+		name := strings.ToLower(key)
+		if slices.Contains(http2ConnectionHeaders, name) {
+			continue
+		}
+		sensitive := name == "authorization" || name == "cookie" || name == "set-cookie"
+		if name == "set-cookie" {
+			for _, value := range values {
+				fields = append(fields, hpackField{ Name: name, Value: value, Sensitive: sensitive })
+			}
+			continue
+		}
+		fields = append(fields, hpackField{ Name: name, Value: strings.Join(values, ","), Sensitive: sensitive })
+	}
+	return fields
+}
+
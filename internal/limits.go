@@ -0,0 +1,85 @@
+package internal
+
+// Structure to hold the size limits enforced on the requests received by the server.
+// A zero value for any of the limits means there is no limit, or when configured for a route, that the server limit applies. Routes can use NO_LIMIT to lift a server limit.
+type RequestLimits struct {
+	// Maximum length (in bytes) of the request line, including the line terminator. Longer request lines are answered with "414 - URI Too Long".
+	MaxRequestLineLength int
+	// Maximum number of bytes allowed for all the header lines of a request, including the line terminators. Larger headers are answered with "431 - Request Header Fields Too Large".
+	MaxHeaderBytes int
+	// Maximum number of header lines allowed in a request. Requests with more headers are answered with "431 - Request Header Fields Too Large".
+	MaxHeaderCount int
+	// Maximum size (in bytes) of the request body. Larger bodies are answered with "413 - Content Too Large".
+	MaxBodySize int64
+}
+
+// Returns the limits to be enforced for a request matching a route with the given limits.
+// The limits configured for the route take precedence over the limits of the current instance, which are used for the limits not configured for the route.
+func (rl RequestLimits) merge(routeLimits *RequestLimits) RequestLimits {
+	if routeLimits == nil {
+		return rl
+	}
+
+	merged := rl
+	merged.MaxRequestLineLength = mergeLimit(rl.MaxRequestLineLength, routeLimits.MaxRequestLineLength)
+	merged.MaxHeaderBytes = mergeLimit(rl.MaxHeaderBytes, routeLimits.MaxHeaderBytes)
+	merged.MaxHeaderCount = mergeLimit(rl.MaxHeaderCount, routeLimits.MaxHeaderCount)
+	merged.MaxBodySize = mergeLimit(rl.MaxBodySize, routeLimits.MaxBodySize)
+	return merged
+}
+
+// Returns the limits to be enforced while reading the request line and headers, before the route of the request is known.
+// The limits of the current instance are relaxed to the loosest of the given limits configured for the routes, so that no route is refused a request it allows.
+func (rl RequestLimits) relax(routeLimits RequestLimits) RequestLimits {
+	relaxed := rl
+	relaxed.MaxRequestLineLength = relaxLimit(rl.MaxRequestLineLength, routeLimits.MaxRequestLineLength)
+	relaxed.MaxHeaderBytes = relaxLimit(rl.MaxHeaderBytes, routeLimits.MaxHeaderBytes)
+	relaxed.MaxHeaderCount = relaxLimit(rl.MaxHeaderCount, routeLimits.MaxHeaderCount)
+	return relaxed
+}
+
+// Returns the loosest of the request line and header limits configured for routes in the current instance and the given route limits.
+// For both, a negative value means no limit and a zero value means the limit is not configured.
+func (rl RequestLimits) loosest(routeLimits RequestLimits) RequestLimits {
+	loosest := rl
+	loosest.MaxRequestLineLength = loosestLimit(rl.MaxRequestLineLength, routeLimits.MaxRequestLineLength)
+	loosest.MaxHeaderBytes = loosestLimit(rl.MaxHeaderBytes, routeLimits.MaxHeaderBytes)
+	loosest.MaxHeaderCount = loosestLimit(rl.MaxHeaderCount, routeLimits.MaxHeaderCount)
+	return loosest
+}
+
+// Returns the limit enforced for a route, given the server limit and the limit configured for the route.
+func mergeLimit[T int | int64](serverLimit T, routeLimit T) T {
+	if routeLimit < 0 {
+		return 0
+	}
+	if routeLimit > 0 {
+		return routeLimit
+	}
+	return serverLimit
+}
+
+// Returns the server limit raised to the given route limit, if the route allows more. A zero value returned means there is no limit.
+func relaxLimit[T int | int64](serverLimit T, routeLimit T) T {
+	if serverLimit <= 0 || routeLimit < 0 {
+		return 0
+	}
+	return max(serverLimit, routeLimit)
+}
+
+// Returns the loosest of the two given route limits.
+func loosestLimit[T int | int64](first T, second T) T {
+	if first < 0 || second < 0 {
+		return NO_LIMIT
+	}
+	return max(first, second)
+}
+
+// Returns the limits enforced while reading the request line and headers of a request, which are the server limits relaxed to the loosest limits configured for the routes of the server and its virtual hosts.
+func (srv *HttpServer) getHeadLimits() RequestLimits {
+	routeLimits := srv.Router.headLimits
+	for _, virtualHost := range srv.virtualHosts {
+		routeLimits = routeLimits.loosest(virtualHost.Router.headLimits)
+	}
+	return srv.Limits.relax(routeLimits)
+}
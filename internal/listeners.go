@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Prefix for addresses referring to a Unix domain socket, like "unix:/run/proteus.sock".
+	UNIX_ADDRESS_PREFIX = "unix:"
+	// Prefix for addresses referring to a TCP socket, like "tcp:localhost:8080".
+	TCP_ADDRESS_PREFIX = "tcp:"
+	// Prefix for addresses referring to a listener socket inherited as a file descriptor, like "fd:3".
+	FD_ADDRESS_PREFIX = "fd:"
+	// The first file descriptor passed by systemd for socket activation.
+	SYSTEMD_LISTEN_FDS_START = 3
+)
+
+// Creates a new listener for the given address. The kind of listener created depends on the prefix of the address.
+//
+// "unix:/run/proteus.sock" creates a Unix domain socket at the given path. A stale socket file left behind by a previous process is removed before the socket is created.
+//
+// "fd:3" creates a listener from the inherited file descriptor 3.
+//
+// "tcp:localhost:8080" or "localhost:8080" creates a TCP listener bound to the given host and port.
+func NewListener(address string) (net.Listener, error) {
+	address = strings.TrimSpace(address)
+	if socketPath, found := strings.CutPrefix(address, UNIX_ADDRESS_PREFIX); found {
+		removeStaleSocket(socketPath)
+		return net.Listen("unix", socketPath)
+	}
+
+	if fdString, found := strings.CutPrefix(address, FD_ADDRESS_PREFIX); found {
+		fd, err := strconv.ParseUint(strings.TrimSpace(fdString), 10, 0)
+		if err != nil {
+			return nil, &CustomError{ Message: fmt.Sprintf("Invalid file descriptor [%s] given for the listener", fdString) }
+		}
+		return ListenerFromFd(uintptr(fd), address)
+	}
+
+	address = strings.TrimPrefix(address, TCP_ADDRESS_PREFIX)
+	return net.Listen("tcp", address)
+}
+
+// Creates a new listener from the listening socket referenced by the given file descriptor, which is typically inherited from a parent process.
+// The name given is used to identify the file descriptor in error messages. The given file descriptor is closed once the listener is created.
+func ListenerFromFd(fd uintptr, name string) (net.Listener, error) {
+	file := os.NewFile(fd, name)
+	if file == nil {
+		return nil, &CustomError{ Message: fmt.Sprintf("File descriptor [%d] is not valid", fd) }
+	}
+	defer file.Close()
+
+	listener, err := net.FileListener(file)
+	if err != nil {
+		return nil, &CustomError{ Message: fmt.Sprintf("File descriptor [%d] could not be used as a listener: %s", fd, err.Error()) }
+	}
+
+	return listener, nil
+}
+
+// Returns the listeners passed to the process by systemd using socket activation.
+// The "LISTEN_PID", "LISTEN_FDS" and "LISTEN_FDNAMES" environment variables are read to find the inherited sockets, and are unset afterwards so that they are not inherited by child processes.
+// An empty list is returned if the process was not started using socket activation.
+func SystemdListeners() ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0)
+	listenPid := strings.TrimSpace(os.Getenv("LISTEN_PID"))
+	listenFds := strings.TrimSpace(os.Getenv("LISTEN_FDS"))
+	fdNames := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	if listenFds == "" {
+		return listeners, nil
+	}
+
+	if listenPid != "" {
+		pid, err := strconv.Atoi(listenPid)
+		if err != nil || pid != os.Getpid() {
+			return listeners, nil
+		}
+	}
+
+	fdCount, err := strconv.Atoi(listenFds)
+	if err != nil || fdCount < 0 {
+		return nil, &CustomError{ Message: fmt.Sprintf("Invalid value [%s] found for LISTEN_FDS", listenFds) }
+	}
+
+	for index := range fdCount {
+		name := fmt.Sprintf("LISTEN_FD_%d", SYSTEMD_LISTEN_FDS_START + index)
+		if index < len(fdNames) && strings.TrimSpace(fdNames[index]) != "" {
+			name = strings.TrimSpace(fdNames[index])
+		}
+
+		listener, err := ListenerFromFd(uintptr(SYSTEMD_LISTEN_FDS_START + index), name)
+		if err != nil {
+			for _, created := range listeners {
+				created.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, nil
+}
+
+// Removes the socket file at the given path if no process is accepting connections on it.
+func removeStaleSocket(socketPath string) {
+	stats, err := os.Stat(socketPath)
+	if err != nil || stats.Mode() & os.ModeSocket == 0 {
+		return
+	}
+
+	conn, err := net.DialTimeout("unix", socketPath, time.Second)
+	if err == nil {
+		conn.Close()
+		return
+	}
+
+	os.Remove(socketPath)
+}
+
+// Represents a connection that supports TCP keep-alive probes.
+type keepAliveConn interface {
+	SetKeepAlive(keepalive bool) error
+	SetKeepAlivePeriod(period time.Duration) error
+}
+
+// Returns the given client connection as a connection that supports TCP keep-alive probes, unwrapping TLS and PROXY protocol connections if needed.
+// The boolean value returned is false if the client connection does not support keep-alive probes, like connections over Unix domain sockets.
+func getKeepAliveConnection(conn net.Conn) (keepAliveConn, bool) {
+	for {
+		kaConn, ok := conn.(keepAliveConn)
+		if ok {
+			return kaConn, true
+		}
+		netConn, ok := conn.(interface{ NetConn() net.Conn })
+		if !ok {
+			return nil, false
+		}
+		conn = netConn.NetConn()
+	}
+}
@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"strconv"
 	"strings"
 )
 
@@ -32,3 +33,52 @@ func (pr Params) Add(key string, paramValues []string) {
 func (pr Params) Length() int {
 	return len(pr)
 }
+
+// Returns the first value in the map for the given key, parsed as a signed integer. The boolean value is false if the key is not present or its value is not a valid integer.
+func (pr Params) GetInt(key string) (int64, bool) {
+	value, ok := pr.getFirst(key)
+	if !ok {
+		return 0, false
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	return parsed, err == nil
+}
+
+// Returns the first value in the map for the given key, parsed as an unsigned integer. The boolean value is false if the key is not present or its value is not a valid unsigned integer.
+func (pr Params) GetUint(key string) (uint64, bool) {
+	value, ok := pr.getFirst(key)
+	if !ok {
+		return 0, false
+	}
+	parsed, err := strconv.ParseUint(value, 10, 64)
+	return parsed, err == nil
+}
+
+// Returns the first value in the map for the given key, parsed as a floating-point number. The boolean value is false if the key is not present or its value is not a valid number.
+func (pr Params) GetFloat(key string) (float64, bool) {
+	value, ok := pr.getFirst(key)
+	if !ok {
+		return 0, false
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	return parsed, err == nil
+}
+
+// Returns the first value in the map for the given key, parsed as a boolean. The boolean value returned second is false if the key is not present or its value is not a valid boolean.
+func (pr Params) GetBool(key string) (bool, bool) {
+	value, ok := pr.getFirst(key)
+	if !ok {
+		return false, false
+	}
+	parsed, err := strconv.ParseBool(value)
+	return parsed, err == nil
+}
+
+// Returns the first value in the map for the given key, if any.
+func (pr Params) getFirst(key string) (string, bool) {
+	values, ok := pr.Get(key)
+	if !ok || len(values) == 0 {
+		return "", false
+	}
+	return values[0], true
+}
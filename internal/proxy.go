@@ -0,0 +1,658 @@
+package internal
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Collection of hop-by-hop headers, which apply to a single connection and are not forwarded by a proxy.
+var hopByHopHeaders = []string{ "Connection", "Keep-Alive", "Proxy-Connection", "Proxy-Authenticate", "Proxy-Authorization", "Te", "Trailer", "Transfer-Encoding", "Upgrade" }
+
+// Structure to represent an upstream server to which a ProxyHandler forwards requests.
+type proxyUpstream struct {
+	// Base URL of the upstream server. Its path is prepended to the path of every request forwarded to the server.
+	target *url.URL
+	// Mutex to synchronize access to the state of the upstream server.
+	mu sync.Mutex
+	// Number of requests currently being forwarded to the upstream server.
+	active int
+	// Number of consecutive requests that could not be forwarded to the upstream server.
+	fails int
+	// Time until which the upstream server is skipped, once it has reached the maximum number of consecutive failures.
+	downUntil time.Time
+	// Flag to determine if the last active health check of the upstream server failed.
+	unhealthy bool
+}
+
+// Returns true if requests can be forwarded to the upstream server as per the passive and active health checks.
+func (pu *proxyUpstream) isAvailable(now time.Time) bool {
+	pu.mu.Lock()
+	defer pu.mu.Unlock()
+	return !pu.unhealthy && !now.Before(pu.downUntil)
+}
+
+// Returns the number of requests currently being forwarded to the upstream server.
+func (pu *proxyUpstream) getActive() int {
+	pu.mu.Lock()
+	defer pu.mu.Unlock()
+	return pu.active
+}
+
+// Marks the start of a request forwarded to the upstream server.
+func (pu *proxyUpstream) begin() {
+	pu.mu.Lock()
+	pu.active += 1
+	pu.mu.Unlock()
+}
+
+// Marks the end of a request forwarded to the upstream server.
+// Once the maximum number of consecutive failures is reached, the server is skipped for the given duration.
+func (pu *proxyUpstream) end(failed bool, maxFails int, failTimeout time.Duration) {
+	pu.mu.Lock()
+	defer pu.mu.Unlock()
+	pu.active -= 1
+	if !failed {
+		pu.fails = 0
+		return
+	}
+
+	pu.fails += 1
+	if maxFails > 0 && pu.fails >= maxFails {
+		pu.fails = 0
+		pu.downUntil = time.Now().Add(failTimeout)
+	}
+}
+
+// Records the result of an active health check of the upstream server.
+func (pu *proxyUpstream) setHealthy(healthy bool) {
+	pu.mu.Lock()
+	pu.unhealthy = !healthy
+	if healthy {
+		pu.fails = 0
+		pu.downUntil = time.Time{}
+	}
+	pu.mu.Unlock()
+}
+
+// Structure to represent a reverse proxy, which forwards the requests it handles to one or more upstream servers and sends back their responses.
+// The Handle() method is used as the route handler of the endpoints to be proxied.
+type ProxyHandler struct {
+	// Method used to choose the upstream server for each request - BALANCE_ROUND_ROBIN (default), BALANCE_LEAST_CONNECTIONS or BALANCE_IP_HASH.
+	Balancing string
+	// Maximum duration to wait for a connection to be established with an upstream server.
+	DialTimeout time.Duration
+	// Maximum duration to wait for the response headers once the request has been sent to an upstream server. The client is sent "504 - Gateway Timeout" once it expires.
+	ResponseTimeout time.Duration
+	// Maximum duration for which an idle connection to an upstream server is kept open to be reused.
+	IdleTimeout time.Duration
+	// Maximum number of idle connections kept open to each upstream server.
+	MaxIdleConnections int
+	// Number of consecutive requests that can fail for an upstream server before it is skipped for the duration given by FailTimeout. A zero value disables the passive health checks.
+	MaxFails int
+	// Duration for which an upstream server is skipped once it has reached the maximum number of consecutive failures.
+	FailTimeout time.Duration
+	// Path requested from each upstream server by the active health checks. A server is considered healthy as long as the path is answered with a 2xx or 3xx status code.
+	HealthCheckPath string
+	// Duration between two active health checks of the upstream servers.
+	HealthCheckInterval time.Duration
+	// Maximum duration to wait for the response to an active health check.
+	HealthCheckTimeout time.Duration
+	// Flag to determine if the "Host" header received from the client is sent to the upstream server, instead of the host of the upstream server.
+	PreserveHost bool
+	// Prefix removed from the request path before it is forwarded to the upstream server.
+	StripPrefix string
+	// TLS configuration used to connect to upstream servers with a "https" URL.
+	TLSConfig *tls.Config
+	// List of upstream servers to which the requests are forwarded.
+	upstreams []*proxyUpstream
+	// Counter used to choose the next upstream server for round-robin balancing.
+	next atomic.Uint64
+	// Transport used to send requests to the upstream servers, created on first use from the configuration of the proxy.
+	transport *http.Transport
+	// Ensures that the transport is created only once.
+	transportOnce sync.Once
+	// Mutex to synchronize starting and stopping the active health checks.
+	mu sync.Mutex
+	// Channel closed to stop the active health checks. It is nil if the health checks are not running.
+	stopChecks chan struct{}
+}
+
+// Creates a new reverse proxy forwarding requests to the given upstream servers, which are given as base URLs like "http://10.0.0.1:8080" or "https://api.internal/v1".
+// The timeouts and health check settings of the proxy can be changed before it handles its first request.
+func NewProxyHandler(Upstreams ...string) (*ProxyHandler, error) {
+	if len(Upstreams) == 0 {
+		return nil, &CustomError{ Message: "At least one upstream server is required for the proxy" }
+	}
+
+	proxy := new(ProxyHandler)
+	for _, upstream := range Upstreams {
+		target, err := url.Parse(strings.TrimSpace(upstream))
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			return nil, &CustomError{ Message: fmt.Sprintf("Upstream server [%s] must be a http or https URL with a host", upstream) }
+		}
+		proxy.upstreams = append(proxy.upstreams, &proxyUpstream{ target: target })
+	}
+
+	proxy.Balancing = BALANCE_ROUND_ROBIN
+	proxy.DialTimeout = time.Duration(GetServerDefaults("proxy_dial_timeout").(int)) * time.Second
+	proxy.ResponseTimeout = time.Duration(GetServerDefaults("proxy_response_timeout").(int)) * time.Second
+	proxy.IdleTimeout = time.Duration(GetServerDefaults("proxy_idle_timeout").(int)) * time.Second
+	proxy.MaxIdleConnections = GetServerDefaults("proxy_max_idle_connections").(int)
+	proxy.MaxFails = GetServerDefaults("proxy_max_fails").(int)
+	proxy.FailTimeout = time.Duration(GetServerDefaults("proxy_fail_timeout").(int)) * time.Second
+	proxy.HealthCheckInterval = time.Duration(GetServerDefaults("proxy_health_check_interval").(int)) * time.Second
+	proxy.HealthCheckTimeout = time.Duration(GetServerDefaults("proxy_health_check_timeout").(int)) * time.Second
+	return proxy, nil
+}
+
+// Returns the transport used to send requests to the upstream servers, creating it on first use.
+func (ph *ProxyHandler) getTransport() *http.Transport {
+	ph.transportOnce.Do(func() {
+		dialer := &net.Dialer{ Timeout: ph.DialTimeout, KeepAlive: 30 * time.Second }
+		ph.transport = &http.Transport{
+			DialContext: dialer.DialContext,
+			TLSClientConfig: ph.TLSConfig,
+			TLSHandshakeTimeout: ph.DialTimeout,
+			ResponseHeaderTimeout: ph.ResponseTimeout,
+			IdleConnTimeout: ph.IdleTimeout,
+			MaxIdleConnsPerHost: ph.MaxIdleConnections,
+			// The body is sent to the client exactly as it was sent by the upstream server, including its content coding.
+			DisableCompression: true,
+		}
+	})
+	return ph.transport
+}
+
+// Forwards the given request to one of the upstream servers and sends back its response. It can be used as the route handler of any endpoint.
+// Requests that could not be sent because no connection could be established are retried on another upstream server, as long as none of the request body has been read.
+// The client is sent "502 - Bad Gateway" if no upstream server is available or the upstream server fails, and "504 - Gateway Timeout" if the upstream server does not respond in time.
+// TRACE and OPTIONS requests with a "Max-Forwards" header of zero are not forwarded, and are answered by the built-in responders instead.
+func (ph *ProxyHandler) Handle(request *HttpRequest, response *HttpResponse) {
+	if request.isLastHop() {
+		if strings.EqualFold(request.Method, "TRACE") {
+			TraceHandler(request, response)
+			return
+		}
+		if strings.EqualFold(request.Method, "OPTIONS") {
+			OptionsHandler(request, response)
+			return
+		}
+	}
+
+	transport := ph.getTransport()
+	clientIP := request.ClientIP()
+	peerIP := getAddressIP(request.PeerAddress)
+	body := &proxyRequestBody{ reader: request.BodyReader() }
+	tried := make(map[*proxyUpstream]bool)
+
+	for {
+		upstream := ph.choose(clientIP, tried)
+		if upstream == nil {
+			ph.fail(request, response, Status502, "No upstream server is available for the request")
+			return
+		}
+		tried[upstream] = true
+
+		upstreamRequest := ph.newUpstreamRequest(request, upstream, body, peerIP)
+		upstream.begin()
+		upstreamResponse, err := transport.RoundTrip(upstreamRequest)
+		if err != nil {
+			upstream.end(true, ph.MaxFails, ph.FailTimeout)
+			var opErr *net.OpError
+			if errors.As(err, &opErr) && opErr.Op == "dial" && !body.consumed && len(tried) < len(ph.upstreams) {
+				request.Server.Log(fmt.Sprintf("Proxy :: Could not connect to upstream server [%s], retrying with another server: %s", upstream.target.Host, err.Error()), WARN_LEVEL)
+				continue
+			}
+
+			status := Status502
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				status = Status504
+			}
+			ph.fail(request, response, status, fmt.Sprintf("Request to upstream server [%s] failed: %s", upstream.target.Host, err.Error()))
+			return
+		}
+
+		err = ph.sendResponse(request, response, upstreamResponse)
+		upstreamResponse.Body.Close()
+		upstream.end(false, ph.MaxFails, ph.FailTimeout)
+		if err != nil {
+			request.Server.Log(fmt.Sprintf("Proxy :: Error occurred while sending the response of upstream server [%s]: %s", upstream.target.Host, err.Error()), ERROR_LEVEL)
+		}
+		return
+	}
+}
+
+// Answers the request with the given error status code, unless the response has already been started.
+func (ph *ProxyHandler) fail(request *HttpRequest, response *HttpResponse, status StatusCode, message string) {
+	request.Server.Log("Proxy :: " + message, ERROR_LEVEL)
+	if response.headersSent || response.hijacked {
+		return
+	}
+	response.Status(status)
+	ErrorHandler(request, response)
+}
+
+// Chooses the upstream server for a request as per the balancing method of the proxy, skipping the servers that are unavailable or have already been tried.
+// It returns nil if no upstream server can be chosen.
+func (ph *ProxyHandler) choose(clientIP string, tried map[*proxyUpstream]bool) *proxyUpstream {
+	now := time.Now()
+	candidates := make([]*proxyUpstream, 0, len(ph.upstreams))
+	for _, upstream := range ph.upstreams {
+		if !tried[upstream] && upstream.isAvailable(now) {
+			candidates = append(candidates, upstream)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(ph.Balancing)) {
+	case BALANCE_IP_HASH:
+		// The hash is applied to the complete list of servers, so that clients are not moved to another server while their server is available.
+		hash := fnv.New32a()
+		hash.Write([]byte(clientIP))
+		start := int(hash.Sum32() % uint32(len(ph.upstreams)))
+		for offset := range ph.upstreams {
+			upstream := ph.upstreams[(start + offset) % len(ph.upstreams)]
+			if !tried[upstream] && upstream.isAvailable(now) {
+				return upstream
+			}
+		}
+		return nil
+	case BALANCE_LEAST_CONNECTIONS:
+		// Servers with the same number of active requests are chosen in round-robin order.
+		start := int(ph.next.Add(1) % uint64(len(candidates)))
+		var chosen *proxyUpstream
+		chosenActive := 0
+		for offset := range candidates {
+			upstream := candidates[(start + offset) % len(candidates)]
+			active := upstream.getActive()
+			if chosen == nil || active < chosenActive {
+				chosen = upstream
+				chosenActive = active
+			}
+		}
+		return chosen
+	default:
+		return candidates[int((ph.next.Add(1) - 1) % uint64(len(candidates)))]
+	}
+}
+
+// Creates the request sent to the given upstream server for the request received from the client.
+// Hop-by-hop headers are removed, and the address of the peer that sent the request is appended to the "X-Forwarded-For" and "Forwarded" headers.
+func (ph *ProxyHandler) newUpstreamRequest(request *HttpRequest, upstream *proxyUpstream, body *proxyRequestBody, peerIP string) *http.Request {
+	target := *upstream.target
+	requestURL, err := url.ParseRequestURI(request.rawTarget)
+	if err != nil || requestURL.Path == "" {
+		requestURL = &url.URL{ Path: request.ResourcePath }
+	}
+	requestPath := requestURL.Path
+	rawPath := requestURL.EscapedPath()
+	prefix := strings.TrimSuffix(CleanRoute(ph.StripPrefix), ROUTE_SEPERATOR)
+	if prefix != "" && (requestPath == prefix || strings.HasPrefix(requestPath, prefix + ROUTE_SEPERATOR)) {
+		requestPath = requestPath[len(prefix):]
+		rawPath = strings.TrimPrefix(rawPath, prefix)
+	}
+	target.Path = joinURLPath(target.Path, requestPath)
+	target.RawPath = joinURLPath(target.EscapedPath(), rawPath)
+	if target.RawPath == target.Path {
+		target.RawPath = ""
+	}
+	target.RawQuery = requestURL.RawQuery
+
+	// The values of a request header are split at every comma when they are received, so they are joined back the same way to rebuild the value sent by the client.
+	// Cookies sent as separate header lines, like the cookie crumbs of HTTP/2 clients, are joined into a single "Cookie" header instead, as per RFC 9113.
+	headers := make(http.Header)
+	for key, values := range request.Headers {
+		if key == "Cookie" {
+			cookies := make([]string, 0, len(values))
+			for _, value := range values {
+				value = strings.TrimSpace(value)
+				if value != "" {
+					cookies = append(cookies, value)
+				}
+			}
+			headers.Set(key, strings.Join(cookies, "; "))
+			continue
+		}
+		headers.Set(key, strings.Join(values, ","))
+	}
+	// The upgrade requested by the client, like a WebSocket handshake, is passed on to the upstream server.
+	upgrade := ""
+	connValue, _ := request.Headers.Get("Connection")
+	if hasHeaderToken(connValue, "upgrade") {
+		upgrade, _ = request.Headers.Get("Upgrade")
+	}
+	removeHopByHopHeaders(headers)
+	headers.Del("Expect")
+	headers.Del("Content-Length")
+	if upgrade != "" && request.conn != nil {
+		headers.Set("Connection", "Upgrade")
+		headers.Set("Upgrade", upgrade)
+	}
+	// The number of times a TRACE or OPTIONS request can still be forwarded is decremented at every hop.
+	if strings.EqualFold(request.Method, "TRACE") || strings.EqualFold(request.Method, "OPTIONS") {
+		maxForwards, err := strconv.Atoi(strings.TrimSpace(headers.Get("Max-Forwards")))
+		if err == nil && maxForwards > 0 {
+			headers.Set("Max-Forwards", strconv.Itoa(maxForwards - 1))
+		}
+	}
+	if _, ok := headers["User-Agent"]; !ok {
+		// An empty value stops the transport from adding its own user agent.
+		headers.Set("User-Agent", "")
+	}
+
+	host, _ := request.Headers.Get("Host")
+	host = strings.TrimSpace(host)
+	scheme := "http"
+	if request.TLS != nil {
+		scheme = "https"
+	}
+	if peerIP != "" {
+		forwardedFor := headers.Get("X-Forwarded-For")
+		if forwardedFor != "" {
+			forwardedFor += ", "
+		}
+		headers.Set("X-Forwarded-For", forwardedFor + peerIP)
+	}
+	// The scheme used by the original client is passed on, while the "Forwarded" element added below describes the connection from the peer.
+	headers.Set("X-Forwarded-Proto", request.Scheme())
+	if host != "" {
+		headers.Set("X-Forwarded-Host", host)
+	}
+	forwarded := make([]string, 0)
+	if peerIP != "" {
+		forwarded = append(forwarded, "for=" + quoteForwardedNode(peerIP))
+	}
+	if host != "" {
+		forwarded = append(forwarded, "host=" + strconv.Quote(host))
+	}
+	forwarded = append(forwarded, "proto=" + scheme)
+	if headers.Get("Forwarded") != "" {
+		headers.Set("Forwarded", headers.Get("Forwarded") + ", " + strings.Join(forwarded, ";"))
+	} else {
+		headers.Set("Forwarded", strings.Join(forwarded, ";"))
+	}
+
+	upstreamRequest := &http.Request{
+		Method: request.Method,
+		URL: &target,
+		Proto: "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header: headers,
+		Host: target.Host,
+	}
+	if ph.PreserveHost && host != "" {
+		upstreamRequest.Host = host
+	}
+
+	clength, hasLength := request.Headers.Get("Content-Length")
+	_, isEncoded := request.Headers.Get("Transfer-Encoding")
+	if request.streamBody {
+		// A body streamed over HTTP/2 without a content-length header has an unknown length, just like a chunked body.
+		if isEncoded || (!hasLength && request.bodySource != nil) {
+			upstreamRequest.ContentLength = -1
+			upstreamRequest.Body = body
+		} else if hasLength {
+			length, _ := strconv.ParseInt(strings.TrimSpace(clength), 10, 64)
+			upstreamRequest.ContentLength = length
+			if length > 0 {
+				upstreamRequest.Body = body
+			}
+		}
+	} else if len(request.BodyBytes) > 0 {
+		upstreamRequest.ContentLength = int64(len(request.BodyBytes))
+		upstreamRequest.Body = body
+	}
+	return upstreamRequest.WithContext(context.Background())
+}
+
+// Sends the response received from the upstream server to the client, streaming its body as it arrives.
+// Switching protocols responses are completed by hijacking the client connection and relaying the bytes in both directions.
+func (ph *ProxyHandler) sendResponse(request *HttpRequest, response *HttpResponse, upstreamResponse *http.Response) error {
+	if upstreamResponse.StatusCode == int(Status101) {
+		return ph.relayUpgrade(response, upstreamResponse)
+	}
+
+	removeHopByHopHeaders(upstreamResponse.Header)
+	for key, values := range upstreamResponse.Header {
+		response.Headers[textproto.CanonicalMIMEHeaderKey(key)] = append([]string{}, values...)
+	}
+	delete(response.Headers, "Content-Length")
+	if upstreamResponse.ContentLength >= 0 {
+		response.Headers["Content-Length"] = []string{ strconv.FormatInt(upstreamResponse.ContentLength, 10) }
+	}
+	response.StatusCode = upstreamResponse.StatusCode
+	response.StatusMessage = strings.TrimSpace(strings.TrimPrefix(upstreamResponse.Status, strconv.Itoa(upstreamResponse.StatusCode)))
+
+	// Responses without a body are sent as they are, without a chunked body.
+	status := upstreamResponse.StatusCode
+	if strings.EqualFold(request.Method, "HEAD") || status == int(Status204) || status == int(Status304) {
+		return response.Write()
+	}
+
+	buffer := make([]byte, 32 * 1024)
+	for {
+		count, readErr := upstreamResponse.Body.Read(buffer)
+		if count > 0 {
+			_, err := response.Stream(buffer[:count])
+			if err == nil {
+				err = response.Flush()
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			// The status has already been sent, so the client can only learn about the failure from the body being cut short.
+			response.abort()
+			return readErr
+		}
+	}
+
+	for key, values := range upstreamResponse.Trailer {
+		for _, value := range values {
+			response.SetTrailer(key, value)
+		}
+	}
+	return response.End()
+}
+
+// Completes a protocol switch accepted by the upstream server, by sending the switching protocols response to the client over the hijacked connection and relaying the bytes in both directions until either side closes its connection.
+func (ph *ProxyHandler) relayUpgrade(response *HttpResponse, upstreamResponse *http.Response) error {
+	upstreamConn, ok := upstreamResponse.Body.(io.ReadWriteCloser)
+	if !ok {
+		return &CustomError{ Message: "Upstream connection cannot be used after switching protocols" }
+	}
+	defer upstreamConn.Close()
+
+	conn, buffered, err := response.Hijack()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var head strings.Builder
+	head.WriteString(fmt.Sprintf("HTTP/1.1 %s%s", upstreamResponse.Status, HEADER_LINE_SEPERATOR))
+	for key, values := range upstreamResponse.Header {
+		for _, value := range values {
+			head.WriteString(fmt.Sprintf("%s: %s%s", key, value, HEADER_LINE_SEPERATOR))
+		}
+	}
+	head.WriteString(HEADER_LINE_SEPERATOR)
+	_, err = io.WriteString(conn, head.String())
+	if err != nil {
+		return err
+	}
+	if len(buffered) > 0 {
+		_, err = upstreamConn.Write(buffered)
+		if err != nil {
+			return err
+		}
+	}
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(upstreamConn, conn)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(conn, upstreamConn)
+		done <- struct{}{}
+	}()
+	<-done
+	return nil
+}
+
+// Starts the active health checks, which request the health check path from every upstream server at the configured interval.
+// Upstream servers failing the check are skipped until they pass a check again. The checks are stopped by calling Close().
+func (ph *ProxyHandler) StartHealthChecks() error {
+	ph.mu.Lock()
+	defer ph.mu.Unlock()
+	if strings.TrimSpace(ph.HealthCheckPath) == "" || ph.HealthCheckInterval <= 0 {
+		return &CustomError{ Message: "A health check path and a positive health check interval are required for the active health checks" }
+	}
+	if ph.stopChecks != nil {
+		return nil
+	}
+
+	ph.stopChecks = make(chan struct{})
+	go ph.runHealthChecks(ph.stopChecks)
+	return nil
+}
+
+// Stops the active health checks and closes the idle connections to the upstream servers.
+func (ph *ProxyHandler) Close() {
+	ph.mu.Lock()
+	if ph.stopChecks != nil {
+		close(ph.stopChecks)
+		ph.stopChecks = nil
+	}
+	ph.mu.Unlock()
+	ph.getTransport().CloseIdleConnections()
+}
+
+// Checks the health of all the upstream servers right away, and then at every health check interval until the given channel is closed.
+func (ph *ProxyHandler) runHealthChecks(stop chan struct{}) {
+	ticker := time.NewTicker(ph.HealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		var wg sync.WaitGroup
+		for _, upstream := range ph.upstreams {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				upstream.setHealthy(ph.checkHealth(upstream))
+			}()
+		}
+		wg.Wait()
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Requests the health check path from the given upstream server and returns true if it is answered with a 2xx or 3xx status code in time.
+func (ph *ProxyHandler) checkHealth(upstream *proxyUpstream) bool {
+	ctx := context.Background()
+	if ph.HealthCheckTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ph.HealthCheckTimeout)
+		defer cancel()
+	}
+
+	target := *upstream.target
+	target.Path = joinURLPath(target.Path, CleanRoute(ph.HealthCheckPath))
+	target.RawPath = ""
+	checkRequest, err := http.NewRequestWithContext(ctx, "GET", target.String(), nil)
+	if err != nil {
+		return false
+	}
+	checkResponse, err := ph.getTransport().RoundTrip(checkRequest)
+	if err != nil {
+		return false
+	}
+	io.Copy(io.Discard, io.LimitReader(checkResponse.Body, 64 * 1024))
+	checkResponse.Body.Close()
+	return checkResponse.StatusCode >= 200 && checkResponse.StatusCode < 400
+}
+
+// Reader for the request body sent to the upstream server, which records if any of the body has been read.
+type proxyRequestBody struct {
+	// Reader for the body of the request received from the client.
+	reader io.Reader
+	// Flag to determine if any of the body has been read, after which the request can no longer be retried.
+	consumed bool
+}
+
+// Reads the next part of the request body.
+func (prb *proxyRequestBody) Read(data []byte) (int, error) {
+	count, err := prb.reader.Read(data)
+	if count > 0 {
+		prb.consumed = true
+	}
+	return count, err
+}
+
+// The body of the request received from the client is not closed by the transport, since it is managed by the server.
+func (prb *proxyRequestBody) Close() error {
+	return nil
+}
+
+// Removes the hop-by-hop headers from the given headers, including the headers listed in the "Connection" header.
+func removeHopByHopHeaders(headers http.Header) {
+	for _, value := range headers.Values("Connection") {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name != "" {
+				headers.Del(name)
+			}
+		}
+	}
+	for _, name := range hopByHopHeaders {
+		headers.Del(name)
+	}
+}
+
+// Joins the path of an upstream server URL with the path of a request, with a single slash between them.
+func joinURLPath(basePath string, requestPath string) string {
+	if basePath == "" || basePath == ROUTE_SEPERATOR {
+		if requestPath == "" {
+			return ROUTE_SEPERATOR
+		}
+		return requestPath
+	}
+	if requestPath == "" || requestPath == ROUTE_SEPERATOR {
+		return basePath
+	}
+	return strings.TrimSuffix(basePath, ROUTE_SEPERATOR) + ROUTE_SEPERATOR + strings.TrimPrefix(requestPath, ROUTE_SEPERATOR)
+}
+
+// Formats the given IP address as a node of the "Forwarded" header, quoting IPv6 addresses as per RFC 7239.
+func quoteForwardedNode(ip string) string {
+	if strings.Contains(ip, ":") {
+		return "\"[" + ip + "]\""
+	}
+	return ip
+}
@@ -0,0 +1,411 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Signature at the start of every PROXY protocol v2 header.
+var proxyProtocolV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// Contains the information sent by a load balancer in the PROXY protocol header at the start of a connection.
+type ProxyHeader struct {
+	// Version of the PROXY protocol used for the header, either 1 (text) or 2 (binary).
+	Version int
+	// Command sent in the header - PROXY_COMMAND_PROXY for relayed connections, or PROXY_COMMAND_LOCAL for connections opened by the load balancer itself, like health checks.
+	Command string
+	// Address of the client that opened the connection to the load balancer. It is nil for connections opened by the load balancer itself, or when the address family is not known.
+	SourceAddress net.Addr
+	// Address on the load balancer to which the client connected. It is nil whenever the source address is nil.
+	DestinationAddress net.Addr
+	// Type-length-value fields sent after the addresses of a v2 header, mapped by their type, like PROXY_TLV_AUTHORITY for the server name sent by the client.
+	TLVs map[byte][]byte
+}
+
+// Returns the value of the type-length-value field of the given type sent in the header. The boolean value returned is false if the field was not sent.
+func (ph *ProxyHeader) TLV(Type byte) ([]byte, bool) {
+	value, ok := ph.TLVs[Type]
+	return value, ok
+}
+
+// Structure to represent a connection whose PROXY protocol header has been read. The source address sent in the header is returned as the remote address of the connection.
+type proxyProtocolConn struct {
+	net.Conn
+	// Reader containing the bytes received after the header, followed by the rest of the connection.
+	reader *bufio.Reader
+	// The header read at the start of the connection.
+	header *ProxyHeader
+}
+
+// Reads data from the connection, starting with the bytes already buffered while reading the header.
+func (ppc *proxyProtocolConn) Read(data []byte) (int, error) {
+	return ppc.reader.Read(data)
+}
+
+// Returns the address of the client sent in the header, or the address of the load balancer if the header did not carry one.
+func (ppc *proxyProtocolConn) RemoteAddr() net.Addr {
+	if ppc.header.SourceAddress != nil {
+		return ppc.header.SourceAddress
+	}
+	return ppc.Conn.RemoteAddr()
+}
+
+// Returns the address to which the client connected on the load balancer, or the local address of the connection if the header did not carry one.
+func (ppc *proxyProtocolConn) LocalAddr() net.Addr {
+	if ppc.header.DestinationAddress != nil {
+		return ppc.header.DestinationAddress
+	}
+	return ppc.Conn.LocalAddr()
+}
+
+// Returns the underlying connection, so that it can be configured like the connection accepted from the listener.
+func (ppc *proxyProtocolConn) NetConn() net.Conn {
+	return ppc.Conn
+}
+
+// Result of accepting a connection and reading its PROXY protocol header.
+type proxyProtocolResult struct {
+	// The connection ready to be handled, if it was accepted.
+	conn net.Conn
+	// The error raised while accepting a connection.
+	err error
+}
+
+// Structure to represent a listener reading the PROXY protocol header sent at the start of the connections accepted from trusted load balancers.
+type proxyProtocolListener struct {
+	net.Listener
+	// Networks from which connections are expected to start with a PROXY protocol header.
+	trusted []*net.IPNet
+	// Maximum duration allowed for reading the header once the connection is accepted.
+	timeout time.Duration
+	// Function used to log the connections rejected because of an invalid header. It is nil if the rejections are not logged.
+	logger func(string, string)
+	// Channel on which the connections whose header has been read are handed over to Accept().
+	results chan proxyProtocolResult
+	// Channel closed once the listener is closed.
+	done chan struct{}
+	// Ensures that the connections are accepted from the underlying listener by a single goroutine.
+	startOnce sync.Once
+	// Ensures that the done channel is closed only once.
+	closeOnce sync.Once
+}
+
+// Creates a new listener that reads the PROXY protocol header (v1 or v2) sent at the start of each connection accepted from the given listener, before the connection is handed over to the server.
+// The header is only read from connections whose source address falls within one of the given networks, written in CIDR notation like "10.0.0.0/8" or as a single IP address. Such connections are closed if they do not start with a valid header.
+// Connections from any other source are handed over unchanged, so that they cannot spoof the client address.
+func NewProxyProtocolListener(listener net.Listener, TrustedSources []string) (net.Listener, error) {
+	if len(TrustedSources) == 0 {
+		return nil, &CustomError{ Message: "At least one trusted source must be given to accept PROXY protocol headers" }
+	}
+
+	trusted, err := parseNetworks(TrustedSources)
+	if err != nil {
+		return nil, err
+	}
+
+	ppl := new(proxyProtocolListener)
+	ppl.Listener = listener
+	ppl.trusted = trusted
+	ppl.timeout = time.Duration(GetServerDefaults("proxy_protocol_timeout").(int)) * time.Second
+	ppl.results = make(chan proxyProtocolResult)
+	ppl.done = make(chan struct{})
+	return ppl, nil
+}
+
+// Waits for the next connection whose PROXY protocol header has been read and returns it.
+// Headers are read in the background, so that a slow client does not hold up the connections accepted after it.
+func (ppl *proxyProtocolListener) Accept() (net.Conn, error) {
+	ppl.startOnce.Do(func() {
+		go ppl.acceptConnections()
+	})
+
+	select {
+	case result := <-ppl.results:
+		return result.conn, result.err
+	case <-ppl.done:
+		return nil, net.ErrClosed
+	}
+}
+
+// Closes the underlying listener, along with the connections whose header is still being read.
+func (ppl *proxyProtocolListener) Close() error {
+	ppl.closeOnce.Do(func() {
+		close(ppl.done)
+	})
+	return ppl.Listener.Close()
+}
+
+// Accepts connections from the underlying listener until it is closed, and reads the header of each connection in a seperate goroutine.
+func (ppl *proxyProtocolListener) acceptConnections() {
+	for {
+		conn, err := ppl.Listener.Accept()
+		if err != nil {
+			select {
+			case ppl.results <- proxyProtocolResult{ err: err }:
+			case <-ppl.done:
+				return
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+
+		go ppl.handshake(conn)
+	}
+}
+
+// Reads the header of the given connection, if it was opened by a trusted source, and hands the connection over to Accept().
+func (ppl *proxyProtocolListener) handshake(conn net.Conn) {
+	if ppl.isTrusted(conn) {
+		conn.SetReadDeadline(time.Now().Add(ppl.timeout))
+		reader := bufio.NewReader(conn)
+		header, err := readProxyHeader(reader)
+		if err != nil {
+			if ppl.logger != nil {
+				ppl.logger(fmt.Sprintf("Connection from [%s] was closed since its PROXY protocol header is not valid: %s", conn.RemoteAddr().String(), err.Error()), ERROR_LEVEL)
+			}
+			conn.Close()
+			return
+		}
+		conn.SetReadDeadline(time.Time{})
+		conn = &proxyProtocolConn{ Conn: conn, reader: reader, header: header }
+	}
+
+	select {
+	case ppl.results <- proxyProtocolResult{ conn: conn }:
+	case <-ppl.done:
+		conn.Close()
+	}
+}
+
+// Checks if the given connection was opened from one of the trusted networks.
+func (ppl *proxyProtocolListener) isTrusted(conn net.Conn) bool {
+	sourceIP := net.ParseIP(getRemoteIP(conn))
+	if sourceIP == nil {
+		return false
+	}
+	for _, network := range ppl.trusted {
+		if network.Contains(sourceIP) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reads a PROXY protocol header of either version from the given reader.
+func readProxyHeader(reader *bufio.Reader) (*ProxyHeader, error) {
+	prefix, err := reader.Peek(len(proxyProtocolV2Signature))
+	if err == nil && bytes.Equal(prefix, proxyProtocolV2Signature) {
+		return readProxyHeaderV2(reader)
+	}
+	if len(prefix) >= 6 && string(prefix[:6]) == "PROXY " {
+		return readProxyHeaderV1(reader)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, &CustomError{ Message: "Connection does not start with a PROXY protocol header" }
+}
+
+// Reads a PROXY protocol v1 header, which is a single line of text like "PROXY TCP4 192.0.2.1 192.0.2.10 56324 443".
+func readProxyHeaderV1(reader *bufio.Reader) (*ProxyHeader, error) {
+	line := make([]byte, 0, PROXY_V1_MAX_LENGTH)
+	for {
+		char, err := reader.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, char)
+		if char == '\n' {
+			break
+		}
+		if len(line) >= PROXY_V1_MAX_LENGTH {
+			return nil, &CustomError{ Message: "PROXY protocol v1 header is longer than 107 bytes" }
+		}
+	}
+
+	text, found := strings.CutSuffix(string(line), HEADER_LINE_SEPERATOR)
+	if !found {
+		return nil, &CustomError{ Message: "PROXY protocol v1 header must end with CRLF" }
+	}
+
+	header := &ProxyHeader{ Version: 1, Command: PROXY_COMMAND_PROXY, TLVs: make(map[byte][]byte) }
+	fields := strings.Split(text, " ")
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return header, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, &CustomError{ Message: fmt.Sprintf("PROXY protocol v1 header [%s] is not valid", text) }
+	}
+
+	sourceIP := net.ParseIP(fields[2])
+	destinationIP := net.ParseIP(fields[3])
+	if sourceIP == nil || destinationIP == nil || (sourceIP.To4() != nil) != (fields[1] == "TCP4") || (destinationIP.To4() != nil) != (fields[1] == "TCP4") {
+		return nil, &CustomError{ Message: fmt.Sprintf("PROXY protocol v1 header [%s] contains addresses not valid for %s", text, fields[1]) }
+	}
+	sourcePort, err := parseProxyPort(fields[4])
+	if err != nil {
+		return nil, err
+	}
+	destinationPort, err := parseProxyPort(fields[5])
+	if err != nil {
+		return nil, err
+	}
+
+	header.SourceAddress = &net.TCPAddr{ IP: sourceIP, Port: sourcePort }
+	header.DestinationAddress = &net.TCPAddr{ IP: destinationIP, Port: destinationPort }
+	return header, nil
+}
+
+// Reads a PROXY protocol v2 header, which is a binary header carrying the addresses followed by type-length-value fields.
+func readProxyHeaderV2(reader *bufio.Reader) (*ProxyHeader, error) {
+	fixedPart := make([]byte, 16)
+	_, err := io.ReadFull(reader, fixedPart)
+	if err != nil {
+		return nil, err
+	}
+	if fixedPart[12] >> 4 != 2 {
+		return nil, &CustomError{ Message: fmt.Sprintf("PROXY protocol version [%d] is not supported", fixedPart[12] >> 4) }
+	}
+
+	payload := make([]byte, binary.BigEndian.Uint16(fixedPart[14:16]))
+	_, err = io.ReadFull(reader, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	header := &ProxyHeader{ Version: 2, TLVs: make(map[byte][]byte) }
+	switch fixedPart[12] & 0x0F {
+	case 0x00:
+		header.Command = PROXY_COMMAND_LOCAL
+	case 0x01:
+		header.Command = PROXY_COMMAND_PROXY
+	default:
+		return nil, &CustomError{ Message: fmt.Sprintf("PROXY protocol v2 command [%d] is not valid", fixedPart[12] & 0x0F) }
+	}
+
+	// Only the address families of stream connections are used; the addresses of any other family are skipped using their length.
+	addressLength := 0
+	family := fixedPart[13] >> 4
+	transport := fixedPart[13] & 0x0F
+	switch family {
+	case 0x01:
+		addressLength = 12
+	case 0x02:
+		addressLength = 36
+	case 0x03:
+		addressLength = 216
+	}
+	if len(payload) < addressLength {
+		return nil, &CustomError{ Message: "PROXY protocol v2 header is shorter than the addresses it declares" }
+	}
+	if header.Command == PROXY_COMMAND_PROXY && transport == 0x01 {
+		switch family {
+		case 0x01:
+			header.SourceAddress = &net.TCPAddr{ IP: net.IP(payload[0:4]), Port: int(binary.BigEndian.Uint16(payload[8:10])) }
+			header.DestinationAddress = &net.TCPAddr{ IP: net.IP(payload[4:8]), Port: int(binary.BigEndian.Uint16(payload[10:12])) }
+		case 0x02:
+			header.SourceAddress = &net.TCPAddr{ IP: net.IP(payload[0:16]), Port: int(binary.BigEndian.Uint16(payload[32:34])) }
+			header.DestinationAddress = &net.TCPAddr{ IP: net.IP(payload[16:32]), Port: int(binary.BigEndian.Uint16(payload[34:36])) }
+		case 0x03:
+			header.SourceAddress = &net.UnixAddr{ Name: string(bytes.TrimRight(payload[0:108], "\x00")), Net: "unix" }
+			header.DestinationAddress = &net.UnixAddr{ Name: string(bytes.TrimRight(payload[108:216], "\x00")), Net: "unix" }
+		}
+	}
+
+	tlvs := payload[addressLength:]
+	for len(tlvs) > 0 {
+		if len(tlvs) < 3 {
+			return nil, &CustomError{ Message: "PROXY protocol v2 header contains a truncated TLV field" }
+		}
+		length := int(binary.BigEndian.Uint16(tlvs[1:3]))
+		if len(tlvs) < 3 + length {
+			return nil, &CustomError{ Message: fmt.Sprintf("PROXY protocol v2 TLV field of type [0x%02x] is longer than the header", tlvs[0]) }
+		}
+		header.TLVs[tlvs[0]] = tlvs[3:3 + length]
+		tlvs = tlvs[3 + length:]
+	}
+
+	// The checksum, if sent, covers the whole header with the checksum field itself set to zero.
+	checksum, ok := header.TLVs[PROXY_TLV_CRC32C]
+	if ok {
+		if len(checksum) != 4 {
+			return nil, &CustomError{ Message: "PROXY protocol v2 CRC32C field must be 4 bytes long" }
+		}
+		expected := binary.BigEndian.Uint32(checksum)
+		original := bytes.Clone(checksum)
+		clear(checksum)
+		table := crc32.MakeTable(crc32.Castagnoli)
+		actual := crc32.Update(crc32.Checksum(fixedPart, table), table, payload)
+		copy(checksum, original)
+		if actual != expected {
+			return nil, &CustomError{ Message: "PROXY protocol v2 header does not match its CRC32C checksum" }
+		}
+	}
+
+	return header, nil
+}
+
+// Parses a port number sent in a PROXY protocol v1 header.
+func parseProxyPort(value string) (int, error) {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 0 || port > 65535 || (len(value) > 1 && value[0] == '0') {
+		return 0, &CustomError{ Message: fmt.Sprintf("PROXY protocol v1 port [%s] is not valid", value) }
+	}
+	return port, nil
+}
+
+// Parses the given list of networks, written in CIDR notation or as single IP addresses.
+func parseNetworks(networks []string) ([]*net.IPNet, error) {
+	parsed := make([]*net.IPNet, 0, len(networks))
+	for _, network := range networks {
+		network = strings.TrimSpace(network)
+		if !strings.Contains(network, "/") {
+			ip := net.ParseIP(network)
+			if ip == nil {
+				return nil, &CustomError{ Message: fmt.Sprintf("Network [%s] is neither an IP address nor in CIDR notation", network) }
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 32
+			}
+			parsed = append(parsed, &net.IPNet{ IP: ip, Mask: net.CIDRMask(bits, bits) })
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(network)
+		if err != nil {
+			return nil, &CustomError{ Message: fmt.Sprintf("Network [%s] is neither an IP address nor in CIDR notation", network) }
+		}
+		parsed = append(parsed, ipNet)
+	}
+	return parsed, nil
+}
+
+// Returns the PROXY protocol header read at the start of the given connection, unwrapping TLS connections if needed. It returns nil if no header was read.
+func getProxyHeader(conn net.Conn) *ProxyHeader {
+	for conn != nil {
+		ppConn, ok := conn.(*proxyProtocolConn)
+		if ok {
+			return ppConn.header
+		}
+		netConn, ok := conn.(interface{ NetConn() net.Conn })
+		if !ok {
+			return nil
+		}
+		conn = netConn.NetConn()
+	}
+	return nil
+}
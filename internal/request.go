@@ -2,8 +2,10 @@ package internal
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
+	"math"
 	"net"
 	"net/textproto"
 	"net/url"
@@ -33,20 +35,67 @@ type HttpRequest struct {
 	Query Params
 	// Collection of all path parameter values stored as key-value pair.
 	Segments Params
-	// The IP address and port number of the client who made the request to the server
+	// The IP address and port number of the client who made the request to the server. For requests forwarded by the trusted proxies of the server, it is the address of the original client, which may not include a port number.
 	ClientAddress string
+	// The IP address and port number of the immediate peer that sent the request to the server, which is a proxy for forwarded requests.
+	PeerAddress string
+	// IP address of the client who made the request, resolved using the forwarding headers sent by the trusted proxies of the server.
+	clientIP string
+	// Port number of the client who made the request, if it was sent by the trusted proxies of the server in the "Forwarded" header.
+	clientPort string
+	// Scheme used by the client to send the request, either "http" or "https".
+	scheme string
+	// Host name requested by the client, without the port number.
+	host string
+	// Port number requested by the client.
+	port int
 	// The server instance processing this request.
 	Server *HttpServer
 	// The actual content contained by the request. The type of the data is determined at run time depending on the data sent as part of the request.
 	Body any
 	// FileSystem instance to access the local file system.
 	fs *FileSystem
+	// Details of the TLS session negotiated with the client. It is nil for requests not received over HTTPS.
+	TLS *TLSInfo
+	// The virtual host of the server selected for the request based on its host name. It is nil if the server has no virtual hosts.
+	virtualHost *VirtualHost
+	// The PROXY protocol header sent by the load balancer at the start of the connection, whose source address is used as the client address. It is nil if no header was sent.
+	ProxyHeader *ProxyHeader
+	// Collection of all the trailer fields received after a chunked request body.
+	Trailers Headers
+	// The client connection from which the request is read. It is nil for requests not read from a network connection.
+	conn net.Conn
+	// Size limits enforced while reading the request.
+	limits RequestLimits
+	// Length of the request line received, including the line terminator.
+	requestLineLength int
+	// Number of bytes received for all the header lines, including the line terminators.
+	headerBytes int
+	// Number of header lines received.
+	headerCount int
+	// Flag to determine if reading the request body has been deferred until the client is sent a "100 Continue" interim response.
+	bodyPending bool
+	// Function that sends the "100 Continue" interim response to the client, before a deferred request body is read.
+	sendContinue func() error
+	// List of functions to be executed once a deferred request body has been read.
+	bodyCallbacks []func()
+	// Error raised while reading a deferred request body, if any.
+	bodyErr error
+	// Flag to determine if reading the request body has been left to the route handler, which reads the body as it arrives using BodyReader().
+	streamBody bool
+	// Reader returned by BodyReader() for a streamed request body. It is nil until the body is requested.
+	bodyReader *requestBodyReader
+	// Source of a streamed request body that is not read from the client connection, like the pipe fed by the DATA frames of a HTTP/2 stream.
+	bodySource io.Reader
+	// Request target as received in the request line, including the query string.
+	rawTarget string
 }
 
 // Initializes the instance of HttpRequest with default values for all its fields.
 func (req *HttpRequest) Initialize(reader io.Reader) {
 	req.BodyBytes = make([]byte, 0)
 	req.Headers = make(Headers)
+	req.Trailers = make(Headers)
 	req.Version = "0.9"
 	req.Locals = make(map[string]any)
 	req.Query = make(Params)
@@ -60,6 +109,16 @@ func (req *HttpRequest) Initialize(reader io.Reader) {
 
 // Reads bytes of data from request byte stream and stores it in individual fields of HttpRequest instance.
 func (req *HttpRequest) Read() error {
+	err := req.readHead()
+	if err != nil {
+		return err
+	}
+
+	return req.readContent()
+}
+
+// Reads the request line and the headers from the request byte stream, and parses the query parameters from the request URL.
+func (req *HttpRequest) readHead() error {
 	err := req.readHeader()
 	if err != nil {
 		return err
@@ -70,15 +129,172 @@ func (req *HttpRequest) Read() error {
 		return err
 	}
 
+	err = req.checkHost()
+	if err != nil {
+		return err
+	}
+
+	req.resolveOrigin()
+	return nil
+}
+
+// Checks that a HTTP/1.1 request contains exactly one "Host" header, as required by RFC 9112. Requests of earlier HTTP versions are not required to contain the header.
+func (req *HttpRequest) checkHost() error {
+	if !strings.EqualFold(req.Version, "1.1") {
+		return nil
+	}
+
+	hostValues, ok := req.Headers["Host"]
+	if !ok || len(hostValues) != 1 {
+		reqError := new(RequestParseError)
+		reqError.Section = "Header"
+		reqError.Value = "Host"
+		reqError.Message = "checkHost: HTTP/1.1 requests must contain exactly one Host header"
+		return reqError
+	}
+	return nil
+}
+
+// Replaces the size limits enforced for the request with the given limits, once the request line and headers have been read.
+// It returns an error if the request line or headers already received exceed the new limits.
+func (req *HttpRequest) applyLimits(limits RequestLimits) error {
+	req.limits = limits
+	if limits.MaxRequestLineLength > 0 && req.requestLineLength > limits.MaxRequestLineLength {
+		return req.limitError("Header", fmt.Sprintf("Request line is longer than the limit of %d bytes", limits.MaxRequestLineLength), Status414)
+	}
+	if limits.MaxHeaderBytes > 0 && req.headerBytes > limits.MaxHeaderBytes {
+		return req.limitError("Header", fmt.Sprintf("Request headers are larger than the limit of %d bytes", limits.MaxHeaderBytes), Status431)
+	}
+	if limits.MaxHeaderCount > 0 && req.headerCount > limits.MaxHeaderCount {
+		return req.limitError("Header", fmt.Sprintf("Request has more than the limit of %d headers", limits.MaxHeaderCount), Status431)
+	}
+	return nil
+}
+
+// Creates a new RequestParseError for a request exceeding one of its size limits, with the given response status code.
+func (req *HttpRequest) limitError(section string, message string, status StatusCode) *RequestParseError {
+	reqError := new(RequestParseError)
+	reqError.Section = section
+	reqError.Value = fmt.Sprintf("%s %s", req.Method, req.ResourcePath)
+	reqError.Message = message
+	reqError.Status = status
+	return reqError
+}
+
+// Validates the expectation sent by the client in the "Expect" header of a HTTP/1.1 request. Expectations sent in requests of older versions are ignored.
+// Only the "100-continue" expectation is supported. If the request has a body, reading the body is deferred until the request is accepted and "LoadBody" is called.
+func (req *HttpRequest) checkExpectation() error {
+	expectation, ok := req.Headers.Get("Expect")
+	if !ok || !strings.EqualFold(req.Version, "1.1") {
+		return nil
+	}
+
+	if !strings.EqualFold(strings.TrimSpace(expectation), "100-continue") {
+		reqError := new(RequestParseError)
+		reqError.Section = "Header"
+		reqError.Value = expectation
+		reqError.Message = "The expectation given in the Expect header is not supported by the server"
+		reqError.Status = Status417
+		return reqError
+	}
+
+	_, isEncoded := req.Headers.Get("Transfer-Encoding")
 	clength, ok := req.Headers.Get("Content-Length")
-	if ok {
-		reqContentLength, err := strconv.Atoi(clength)
+	if !isEncoded && (!ok || strings.TrimSpace(clength) == "0") {
+		return nil
+	}
+
+	// Bodies that are known to be too large are rejected right away, instead of asking the client to send them.
+	if ok && !isEncoded && req.limits.MaxBodySize > 0 {
+		reqContentLength, err := strconv.ParseInt(strings.TrimSpace(clength), 10, 64)
+		if err == nil && reqContentLength > req.limits.MaxBodySize {
+			return req.limitError("Body", fmt.Sprintf("Request body is larger than the limit of %d bytes", req.limits.MaxBodySize), Status413)
+		}
+	}
+
+	req.bodyPending = true
+	return nil
+}
+
+// Reads the request body, if reading it was deferred because the client sent "Expect: 100-continue" and is waiting for the "100 Continue" interim response, or because the route streams the request body.
+// The interim response is sent before the body is read. The server calls this function before executing the route handler, but it can be called earlier by a middleware that needs the body.
+// Calling this function once the body has been read does nothing.
+func (req *HttpRequest) LoadBody() error {
+	if req.bodyReader != nil {
+		if req.bodyErr != nil {
+			return req.bodyErr
+		}
+		return &CustomError{ Message: "Request body cannot be loaded once it is being streamed using BodyReader()" }
+	}
+	if !req.bodyPending && !req.streamBody {
+		return req.bodyErr
+	}
+
+	req.bodyPending = false
+	req.streamBody = false
+	if req.sendContinue != nil {
+		err := req.sendContinue()
 		if err != nil {
-			reqError := new(RequestParseError)
-			reqError.Section = "Header"
-			reqError.Message = err.Error()
-			reqError.Value = fmt.Sprintf("Content Length parsing error for value - %s", strings.TrimSpace(clength))
-			return reqError
+			req.bodyErr = err
+			return err
+		}
+	}
+
+	var err error
+	if req.bodySource != nil {
+		req.BodyBytes, err = io.ReadAll(req.bodySource)
+		if err == nil {
+			req.Locals["ContentLength"] = len(req.BodyBytes)
+		}
+	} else {
+		err = req.readContent()
+	}
+	if req.conn != nil {
+		req.conn.SetReadDeadline(time.Time{})
+	}
+	if err != nil {
+		req.bodyErr = err
+		return err
+	}
+
+	for _, callback := range req.bodyCallbacks {
+		callback()
+	}
+	req.bodyCallbacks = nil
+	return nil
+}
+
+// Executes the given function once the request body has been read. If the body has already been read, the function is executed right away.
+func (req *HttpRequest) whenBodyLoaded(callback func()) {
+	if !req.bodyPending {
+		callback()
+		return
+	}
+	req.bodyCallbacks = append(req.bodyCallbacks, callback)
+}
+
+// Reads the body from the request byte stream as per the framing headers of the request, enforcing the body size limit of the request.
+func (req *HttpRequest) readContent() error {
+	req.setBodyDeadline()
+	var err error
+	transferEncoding, isEncoded := req.Headers.Get("Transfer-Encoding")
+	clength, ok := req.Headers.Get("Content-Length")
+	if isEncoded {
+		err = req.validateTransferEncoding(transferEncoding, ok)
+		if err != nil {
+			return err
+		}
+
+		err = req.readChunkedBody()
+		if err != nil {
+			return err
+		}
+
+		req.Locals["ContentLength"] = len(req.BodyBytes)
+	} else if ok {
+		reqContentLength, err := req.parseContentLength(clength)
+		if err != nil {
+			return err
 		}
 
 		req.Locals["ContentLength"] = reqContentLength
@@ -91,6 +307,134 @@ func (req *HttpRequest) Read() error {
 	return nil
 }
 
+// Parses the value of the "Content-Length" header and validates it against the body size limit of the request.
+func (req *HttpRequest) parseContentLength(clength string) (int, error) {
+	reqContentLength, err := strconv.Atoi(clength)
+	if err != nil {
+		reqError := new(RequestParseError)
+		reqError.Section = "Header"
+		reqError.Message = err.Error()
+		reqError.Value = fmt.Sprintf("Content Length parsing error for value - %s", strings.TrimSpace(clength))
+		return 0, reqError
+	}
+
+	if reqContentLength < 0 {
+		reqError := new(RequestParseError)
+		reqError.Section = "Header"
+		reqError.Message = "Content Length must not be negative"
+		reqError.Value = strings.TrimSpace(clength)
+		return 0, reqError
+	}
+
+	if req.limits.MaxBodySize > 0 && int64(reqContentLength) > req.limits.MaxBodySize {
+		return 0, req.limitError("Body", fmt.Sprintf("Request body is larger than the limit of %d bytes", req.limits.MaxBodySize), Status413)
+	}
+
+	return reqContentLength, nil
+}
+
+// Returns a reader for the request body. If the route streams the request body, the body is read from the connection or the HTTP/2 stream as the returned reader is consumed, otherwise the reader returns the body already read.
+// Streamed bodies are not available in the BodyBytes field, and the parts not read by the route handler are discarded once the response has been sent.
+func (req *HttpRequest) BodyReader() io.Reader {
+	if !req.streamBody {
+		return bytes.NewReader(req.BodyBytes)
+	}
+	if req.bodyReader == nil {
+		req.bodyReader = &requestBodyReader{ request: req }
+	}
+	return req.bodyReader
+}
+
+// Reads and discards the part of a streamed request body not read by the route handler, so that the next request can be read from the connection.
+// If the client is still waiting for "100 Continue", nothing is read since the connection is closed once the response has been sent.
+func (req *HttpRequest) discardBody() {
+	if !req.streamBody || (req.bodyReader == nil && req.bodyPending) {
+		return
+	}
+	io.Copy(io.Discard, req.BodyReader())
+}
+
+// Sets the read deadline of the client connection for reading the request body, as per the read body timeout configured for the server.
+func (req *HttpRequest) setBodyDeadline() {
+	if req.conn == nil || req.Server == nil {
+		return
+	}
+
+	if req.Server.ReadBodyTimeout > 0 {
+		req.conn.SetReadDeadline(time.Now().Add(req.Server.ReadBodyTimeout))
+	} else {
+		req.conn.SetReadDeadline(time.Time{})
+	}
+}
+
+// Returns true if the client wants the connection to be kept open once the request has been answered, as per RFC 9112.
+// HTTP/1.1 connections are persistent unless the "close" connection option is sent, whereas HTTP/1.0 connections are persistent only if the "keep-alive" connection option is sent.
+func (req *HttpRequest) isPersistent() bool {
+	hasClose := false
+	hasKeepAlive := false
+	connValue, ok := req.Headers.Get("Connection")
+	if ok {
+		for _, option := range strings.Split(connValue, ",") {
+			option = strings.TrimSpace(option)
+			if strings.EqualFold(option, "close") {
+				hasClose = true
+			} else if strings.EqualFold(option, "keep-alive") {
+				hasKeepAlive = true
+			}
+		}
+	}
+
+	if hasClose {
+		return false
+	}
+
+	switch GetResponseVersion(req.Version) {
+	case "1.1":
+		return true
+	case "1.0":
+		return hasKeepAlive
+	default:
+		return false
+	}
+}
+
+// Returns the ID of the last Server-Sent Event received by the client, sent in the "Last-Event-ID" header when the client reconnects to an event stream.
+// It returns an empty string if the header was not sent.
+func (req *HttpRequest) LastEventID() string {
+	lastEventID, _ := req.Headers.Get("Last-Event-ID")
+	return strings.TrimSpace(lastEventID)
+}
+
+// Returns the IP address of the client who made the request. For requests forwarded by the trusted proxies of the server, it is the address of the original client taken from the "Forwarded" or "X-Forwarded-For" header.
+func (req *HttpRequest) ClientIP() string {
+	return req.clientIP
+}
+
+// Returns the scheme used by the client to send the request, either "http" or "https". For requests forwarded by the trusted proxies of the server, it is taken from the "Forwarded" or "X-Forwarded-Proto" header.
+func (req *HttpRequest) Scheme() string {
+	return req.scheme
+}
+
+// Returns the host name requested by the client, without the port number. For requests forwarded by the trusted proxies of the server, it is taken from the "Forwarded" or "X-Forwarded-Host" header.
+func (req *HttpRequest) Host() string {
+	return req.host
+}
+
+// Returns the port number requested by the client, which is the default port number of the scheme if the requested host does not include one.
+func (req *HttpRequest) Port() int {
+	return req.port
+}
+
+// Checks if the request must not be forwarded any further, which is the case when its "Max-Forwards" header is zero.
+func (req *HttpRequest) isLastHop() bool {
+	maxForwards, ok := req.Headers.Get("Max-Forwards")
+	if !ok {
+		return false
+	}
+	hopsLeft, err := strconv.Atoi(strings.TrimSpace(maxForwards))
+	return err == nil && hopsLeft == 0
+}
+
 // Gets the time elapsed since request processing started (in milliseconds).
 // If start time is not available, it returns zero.
 func (req *HttpRequest) ProcessingTime() int64 {
@@ -108,7 +452,28 @@ func (req *HttpRequest) readHeader() error {
 	HeaderProcessingCompleted := false
 
 	for {
-		message, err := req.reader.ReadString('\n')
+		var message string
+		var tooLong bool
+		var err error
+		if !RequestLineProcessed {
+			message, tooLong, err = req.readLimitedLine(req.limits.MaxRequestLineLength)
+			if tooLong {
+				// The version cannot be read from a request line that is too long, so the error response is sent as a HTTP/1.1 response.
+				req.Version = "1.1"
+				return req.limitError("Header", fmt.Sprintf("Request line is longer than the limit of %d bytes", req.limits.MaxRequestLineLength), Status414)
+			}
+			req.requestLineLength = len(message)
+		} else {
+			maxLength := 0
+			if req.limits.MaxHeaderBytes > 0 {
+				maxLength = max(req.limits.MaxHeaderBytes - req.headerBytes, 1)
+			}
+			message, tooLong, err = req.readLimitedLine(maxLength)
+			if tooLong {
+				return req.limitError("Header", fmt.Sprintf("Request headers are larger than the limit of %d bytes", req.limits.MaxHeaderBytes), Status431)
+			}
+			req.headerBytes += len(message)
+		}
 		if err != nil {
 			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
 				return &ReadTimeoutError{}
@@ -160,6 +525,17 @@ func (req *HttpRequest) readHeader() error {
 				return reqError
 			}
 			req.Version = strings.TrimSpace(tempVersion)
+			// HTTP/2 requests are sent as binary frames, so a request line claiming HTTP/2 or later is not valid.
+			versionNo, err := strconv.ParseFloat(req.Version, 64)
+			if err == nil && versionNo >= 2 {
+				req.Version = "1.1"
+				reqError := new(RequestParseError)
+				reqError.Section = "Header"
+				reqError.Value = tempVersion
+				reqError.Message = "HTTP/2 requests must be sent using the HTTP/2 connection preface"
+				reqError.Status = Status505
+				return reqError
+			}
 			RequestLineProcessed = true
 		} else {
 			HeaderKey, HeaderValue, found := strings.Cut(message, HEADER_KEY_VALUE_SEPERATOR)
@@ -171,6 +547,11 @@ func (req *HttpRequest) readHeader() error {
 				return reqError
 			}
 
+			req.headerCount += 1
+			if req.limits.MaxHeaderCount > 0 && req.headerCount > req.limits.MaxHeaderCount {
+				return req.limitError("Header", fmt.Sprintf("Request has more than the limit of %d headers", req.limits.MaxHeaderCount), Status431)
+			}
+
 			HeaderKey = strings.TrimSpace(HeaderKey)
 			HeaderValue = strings.TrimSpace(HeaderValue)
 			req.AddHeader(HeaderKey, HeaderValue)
@@ -184,20 +565,235 @@ func (req *HttpRequest) readHeader() error {
 func (req *HttpRequest) readBody() error {
 	reqContentLength := req.Locals["ContentLength"].(int)
 	if reqContentLength > 0 {
-		req.BodyBytes = make([]byte, reqContentLength)
-		for index := range reqContentLength {
-			bodyByte, err := req.reader.ReadByte()
-			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
-				return &ReadTimeoutError{}
-			} else if err != nil {
-				reqError := new(RequestParseError)
-				reqError.Section = "Body"
-				reqError.Value = "Request Body"
-				reqError.Message = err.Error()
-				return reqError
-			}
-			req.BodyBytes[index] = bodyByte
+		// The body is read in pieces, so that the memory used grows with the bytes received rather than the length declared by the client.
+		body := new(bytes.Buffer)
+		err := req.copyBody(body, int64(reqContentLength))
+		if err != nil {
+			return err
 		}
+		req.BodyBytes = body.Bytes()
+	}
+
+	return nil
+}
+
+// Copies the given number of bytes from the request byte stream to the given writer, through a buffer of fixed size.
+func (req *HttpRequest) copyBody(dst io.Writer, size int64) error {
+	_, err := io.CopyN(dst, req.reader, size)
+	if err == nil {
+		return nil
+	}
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return &ReadTimeoutError{}
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	reqError := new(RequestParseError)
+	reqError.Section = "Body"
+	reqError.Value = "Request Body"
+	reqError.Message = err.Error()
+	return reqError
+}
+
+// Validates the transfer codings applied to the request body as per RFC 9112.
+// Only the "chunked" transfer coding is supported, and it must be the final coding applied to the body.
+func (req *HttpRequest) validateTransferEncoding(transferEncoding string, hasContentLength bool) error {
+	if hasContentLength {
+		reqError := new(RequestParseError)
+		reqError.Section = "Header"
+		reqError.Value = transferEncoding
+		reqError.Message = "Request must not contain both Transfer-Encoding and Content-Length headers"
+		return reqError
+	}
+
+	if !strings.EqualFold(req.Version, "1.1") {
+		reqError := new(RequestParseError)
+		reqError.Section = "Header"
+		reqError.Value = transferEncoding
+		reqError.Message = fmt.Sprintf("Transfer-Encoding header is not supported for HTTP/%s requests", req.Version)
+		return reqError
+	}
+
+	codings := strings.Split(transferEncoding, ",")
+	for index, coding := range codings {
+		coding = strings.TrimSpace(coding)
+		coding, _, _ = strings.Cut(coding, ";")
+		coding = strings.TrimSpace(coding)
+		isLast := index == len(codings) - 1
+		if isLast && !strings.EqualFold(coding, "chunked") {
+			reqError := new(RequestParseError)
+			reqError.Section = "Header"
+			reqError.Value = transferEncoding
+			reqError.Message = "The final transfer coding applied to the request body must be chunked"
+			return reqError
+		}
+
+		if !isLast {
+			reqError := new(RequestParseError)
+			reqError.Section = "Header"
+			reqError.Value = transferEncoding
+			reqError.Message = fmt.Sprintf("Transfer coding [%s] is not supported by the server", coding)
+			reqError.Status = Status501
+			return reqError
+		}
+	}
+
+	return nil
+}
+
+// Reads a single line terminated by LF from the request byte stream, including the line terminator.
+// If the line is longer than the given maximum length (in bytes), reading stops and the boolean value returned is true. A zero value means there is no limit.
+func (req *HttpRequest) readLimitedLine(maxLength int) (string, bool, error) {
+	line := make([]byte, 0)
+	for {
+		fragment, err := req.reader.ReadSlice('\n')
+		line = append(line, fragment...)
+		if maxLength > 0 && len(line) > maxLength {
+			return "", true, nil
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		return string(line), false, err
+	}
+}
+
+// Reads a single line terminated by CRLF from the request byte stream and returns the line without the line terminator.
+// The section value is used to classify the error returned, if any. Lines longer than the given maximum length are rejected with the given status code.
+func (req *HttpRequest) readLine(section string, maxLength int, status StatusCode) (string, error) {
+	line, tooLong, err := req.readLimitedLine(maxLength)
+	if tooLong {
+		return "", req.limitError(section, fmt.Sprintf("Line is longer than the limit of %d bytes", maxLength), status)
+	}
+	if err != nil {
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return "", &ReadTimeoutError{}
+		}
+		reqError := new(RequestParseError)
+		reqError.Section = section
+		reqError.Value = strings.TrimSpace(line)
+		reqError.Message = err.Error()
+		return "", reqError
+	}
+
+	line = strings.TrimSuffix(line, "\n")
+	line = strings.TrimSuffix(line, "\r")
+	return line, nil
+}
+
+// Reads a request body sent using the chunked transfer coding and stores the decoded body in the HttpRequest instance.
+// Chunk extensions are ignored and the trailer fields received after the last chunk are stored in the "Trailers" field of the request.
+func (req *HttpRequest) readChunkedBody() error {
+	body := new(bytes.Buffer)
+	for {
+		chunkSize, err := req.readChunkSize(int64(body.Len()))
+		if err != nil {
+			return err
+		}
+		if chunkSize == 0 {
+			break
+		}
+		err = req.copyBody(body, chunkSize)
+		if err != nil {
+			return err
+		}
+		err = req.readChunkEnd()
+		if err != nil {
+			return err
+		}
+	}
+
+	err := req.readTrailers()
+	if err != nil {
+		return err
+	}
+
+	req.BodyBytes = body.Bytes()
+	return nil
+}
+
+// Reads the size line of the next chunk of a chunked request body, given the number of body bytes already read. A zero size means the last chunk has been read.
+// The chunk size is rejected if it is larger than the bytes left for the body, as per the body size limit of the request, so that it is never used to allocate memory upfront.
+func (req *HttpRequest) readChunkSize(bodyLength int64) (int64, error) {
+	maxChunkLineLength := GetServerDefaults("max_chunk_line_length").(int)
+	chunkLine, err := req.readLine("Body", maxChunkLineLength, Status400)
+	if err != nil {
+		return 0, err
+	}
+
+	chunkSizeString, _, _ := strings.Cut(chunkLine, ";")
+	chunkSizeString = strings.TrimSpace(chunkSizeString)
+	chunkSize, err := strconv.ParseInt(chunkSizeString, 16, 64)
+	if err != nil || chunkSize < 0 || chunkSize > math.MaxInt64 - bodyLength {
+		reqError := new(RequestParseError)
+		reqError.Section = "Body"
+		reqError.Value = chunkLine
+		reqError.Message = "Invalid chunk size found in the chunked request body"
+		return 0, reqError
+	}
+
+	if req.limits.MaxBodySize > 0 && chunkSize > req.limits.MaxBodySize - bodyLength {
+		return 0, req.limitError("Body", fmt.Sprintf("Request body is larger than the limit of %d bytes", req.limits.MaxBodySize), Status413)
+	}
+	return chunkSize, nil
+}
+
+// Reads the line terminator following the data of a chunk in a chunked request body.
+func (req *HttpRequest) readChunkEnd() error {
+	maxChunkLineLength := GetServerDefaults("max_chunk_line_length").(int)
+	chunkEnd, err := req.readLine("Body", maxChunkLineLength, Status400)
+	if err != nil {
+		return err
+	}
+	if chunkEnd != "" {
+		reqError := new(RequestParseError)
+		reqError.Section = "Body"
+		reqError.Value = chunkEnd
+		reqError.Message = "Chunk data must be followed by CRLF in the chunked request body"
+		return reqError
+	}
+	return nil
+}
+
+// Reads the trailer fields sent after the last chunk of a chunked request body.
+func (req *HttpRequest) readTrailers() error {
+	trailerBytes := 0
+	trailerCount := 0
+	for {
+		maxLength := 0
+		if req.limits.MaxHeaderBytes > 0 {
+			maxLength = max(req.limits.MaxHeaderBytes - trailerBytes, 1)
+		}
+		trailerLine, err := req.readLine("Trailer", maxLength, Status431)
+		if err != nil {
+			return err
+		}
+		trailerBytes += len(trailerLine) + len(HEADER_LINE_SEPERATOR)
+
+		if trailerLine == "" {
+			break
+		}
+
+		trailerCount += 1
+		if req.limits.MaxHeaderCount > 0 && trailerCount > req.limits.MaxHeaderCount {
+			return req.limitError("Trailer", fmt.Sprintf("Request has more than the limit of %d trailer fields", req.limits.MaxHeaderCount), Status431)
+		}
+
+		TrailerKey, TrailerValue, found := strings.Cut(trailerLine, HEADER_KEY_VALUE_SEPERATOR)
+		if !found {
+			reqError := new(RequestParseError)
+			reqError.Section = "Trailer"
+			reqError.Value = strings.TrimSpace(trailerLine)
+			reqError.Message = "Invalid trailer field found after the chunked request body"
+			return reqError
+		}
+
+		TrailerKey = strings.TrimSpace(TrailerKey)
+		if slices.Contains(ForbiddenTrailers, textproto.CanonicalMIMEHeaderKey(TrailerKey)) {
+			continue
+		}
+		req.Trailers.Add(TrailerKey, strings.TrimSpace(TrailerValue))
 	}
 
 	return nil
@@ -206,6 +802,7 @@ func (req *HttpRequest) readBody() error {
 // Parses all the query paramaters from the request URL and stores in the HttpRequest instance.
 // Once the parsing is done, it removes the query parameters string from the Resource Path field.
 func (req *HttpRequest) parseQueryParams() error {
+	req.rawTarget = req.ResourcePath
 	CleanedPath := CleanRoute(req.ResourcePath)
 	parsedUrl, err := url.Parse(CleanedPath)
 	if err != nil {
@@ -275,3 +872,139 @@ func (req *HttpRequest) AddHeader(HeaderKey string, HeaderValue string) {
 		req.Headers.Add(HeaderKey, HeaderValue)
 	}
 }
+
+// Reader for a request body streamed to the route handler as it arrives from the client, as per the framing headers and the body size limit of the request.
+type requestBodyReader struct {
+	// The request whose body is being read.
+	request *HttpRequest
+	// Flag to determine if the framing headers have been validated and the body has started to be read.
+	started bool
+	// Flag to determine if the body is sent using the chunked transfer coding.
+	chunked bool
+	// Number of bytes of the body yet to be read for bodies with a "Content-Length" header, or of the current chunk for chunked bodies.
+	remaining int64
+	// Flag to determine if the data of a chunk has been read and its line terminator is yet to be read, for chunked bodies.
+	inChunk bool
+	// Number of body bytes read so far.
+	length int64
+	// Error returned by all further reads once the body has been read completely or could not be read.
+	err error
+}
+
+// Reads the next part of the request body from the connection. The "100 Continue" interim response is sent before the first read, if the client is waiting for it.
+func (rbr *requestBodyReader) Read(data []byte) (int, error) {
+	if rbr.err != nil {
+		return 0, rbr.err
+	}
+
+	req := rbr.request
+	if !rbr.started {
+		rbr.err = rbr.start()
+		if rbr.err != nil {
+			req.bodyErr = rbr.err
+			return 0, rbr.err
+		}
+	}
+
+	count, err := rbr.read(data)
+	if err != nil {
+		rbr.err = err
+		if req.conn != nil {
+			req.conn.SetReadDeadline(time.Time{})
+		}
+		if err == io.EOF {
+			req.Locals["ContentLength"] = int(rbr.length)
+		} else {
+			req.bodyErr = err
+		}
+	}
+	return count, err
+}
+
+// Sends the "100 Continue" interim response if the client is waiting for it, and validates the framing headers of the request body.
+func (rbr *requestBodyReader) start() error {
+	req := rbr.request
+	rbr.started = true
+	if req.bodyPending {
+		req.bodyPending = false
+		if req.sendContinue != nil {
+			err := req.sendContinue()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	// The framing of a body received from another source has already been validated by the source.
+	if req.bodySource != nil {
+		return nil
+	}
+
+	req.setBodyDeadline()
+	transferEncoding, isEncoded := req.Headers.Get("Transfer-Encoding")
+	clength, ok := req.Headers.Get("Content-Length")
+	if isEncoded {
+		rbr.chunked = true
+		return req.validateTransferEncoding(transferEncoding, ok)
+	}
+	if ok {
+		reqContentLength, err := req.parseContentLength(clength)
+		if err != nil {
+			return err
+		}
+		rbr.remaining = int64(reqContentLength)
+	}
+	return nil
+}
+
+// Reads the next part of the request body as per its framing.
+func (rbr *requestBodyReader) read(data []byte) (int, error) {
+	req := rbr.request
+	if req.bodySource != nil {
+		count, err := req.bodySource.Read(data)
+		rbr.length += int64(count)
+		return count, err
+	}
+	if rbr.chunked && rbr.remaining <= 0 {
+		if rbr.inChunk {
+			err := req.readChunkEnd()
+			if err != nil {
+				return 0, err
+			}
+			rbr.inChunk = false
+		}
+		chunkSize, err := req.readChunkSize(rbr.length)
+		if err != nil {
+			return 0, err
+		}
+		if chunkSize == 0 {
+			err = req.readTrailers()
+			if err != nil {
+				return 0, err
+			}
+			return 0, io.EOF
+		}
+		rbr.remaining = chunkSize
+		rbr.inChunk = true
+	}
+
+	if rbr.remaining <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(data)) > rbr.remaining {
+		data = data[:rbr.remaining]
+	}
+	count, err := req.reader.Read(data)
+	rbr.remaining -= int64(count)
+	rbr.length += int64(count)
+	if err != nil {
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return count, &ReadTimeoutError{}
+		}
+		reqError := new(RequestParseError)
+		reqError.Section = "Body"
+		reqError.Value = "Request Body"
+		reqError.Message = err.Error()
+		return count, reqError
+	}
+	return count, nil
+}
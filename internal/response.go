@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 	"io"
+	"net"
 )
 
 // Structure to represent a HTTP response sent back by the server to the client.
@@ -31,6 +32,32 @@ type HttpResponse struct {
 	Locals map[string]any
 	// FileSystem instance to access the local file system.
 	fs *FileSystem
+	// Collection of all the trailer fields to be sent after a streamed response body. Trailers are sent only when the response body is streamed using the chunked transfer coding, or over HTTP/2.
+	Trailers Headers
+	// Flag to determine if the status line and headers have been written to the response byte stream.
+	headersSent bool
+	// Flag to determine if the response body is being streamed using the chunked transfer coding.
+	chunked bool
+	// Flag to determine if the end of the response body is marked by closing the connection.
+	closeDelimited bool
+	// Flag to determine if the streamed response has been completed.
+	finished bool
+	// Number of body bytes streamed so far.
+	bodyWritten int64
+	// The client connection to which the response is written. It is nil for responses not written to a network connection.
+	conn net.Conn
+	// The HTTP/2 stream on which the response is sent. It is nil for responses sent over HTTP/1.x connections.
+	stream *http2Stream
+	// Flag to determine if the connection has been switched to another protocol, after which it is no longer used for HTTP.
+	upgraded bool
+	// The Server-Sent Events stream carried by the response body, if any. Events must be sent using the stream, rather than writing to the response directly.
+	eventStream *EventStream
+	// The request being answered by the response. It is nil for responses not created for a request received from a network connection.
+	request *HttpRequest
+	// Flag to determine if the connection has been taken over by the route handler, after which the server no longer writes to it or manages it.
+	hijacked bool
+	// Flag to determine if the response body is left out, as for the responses to HEAD requests. The headers are sent as they would be for the body.
+	noBody bool
 }
 
 // // Initializes the instance of HttpResponse with default values for all its fields.
@@ -42,6 +69,7 @@ func (res *HttpResponse) Initialize(version string, writer io.Writer) {
 		res.Version = version
 	}
 	res.Headers = make(Headers)
+	res.Trailers = make(Headers)
 	res.Locals = make(map[string]any)
 	res.addGeneralHeaders()
 	res.addResponseHeaders()
@@ -73,6 +101,14 @@ func (res *HttpResponse) addResponseHeaders() {
 
 // Writes bytes of data to response byte stream from the HttpResponse instance.
 func (res *HttpResponse) Write() error {
+	if res.hijacked {
+		resErr := new(ResponseError)
+		resErr.Section = "RespWrite"
+		resErr.Value = ""
+		resErr.Message = "Response cannot be written once the connection has been hijacked"
+		return resErr
+	}
+
 	if res.writer == nil {
 		resErr := new(ResponseError)
 		resErr.Section = "RespWrite"
@@ -81,6 +117,26 @@ func (res *HttpResponse) Write() error {
 		return resErr
 	}
 
+	if res.headersSent {
+		resErr := new(ResponseError)
+		resErr.Section = "RespWrite"
+		resErr.Value = ""
+		resErr.Message = "Response headers have already been sent for the streamed response"
+		return resErr
+	}
+
+	res.headersSent = true
+	res.finished = true
+	// The length of the body is always sent, so that the client can find the end of the response on a persistent connection.
+	_, hasLength := res.Headers.Get("Content-Length")
+	if !hasLength && res.StatusCode >= 200 && res.StatusCode != int(Status204) && res.StatusCode != int(Status304) {
+		res.Headers.Add("Content-Length", strconv.Itoa(len(res.BodyBytes)))
+	}
+
+	if res.stream != nil {
+		return res.writeHTTP2()
+	}
+
 	var err error
 	if !strings.EqualFold(res.Version, "0.9") {
 		err = res.writeStatusLine()
@@ -96,9 +152,11 @@ func (res *HttpResponse) Write() error {
 		}
 	}
 
-	err = res.writeBody()
-	if err != nil {
-		return err
+	if !res.noBody {
+		err = res.writeBody()
+		if err != nil {
+			return err
+		}
 	}
 
 	err = res.writer.Flush()
@@ -113,6 +171,32 @@ func (res *HttpResponse) Write() error {
 	return nil
 }
 
+// Writes the status, headers and body of the response as frames on the HTTP/2 stream of the response.
+func (res *HttpResponse) writeHTTP2() error {
+	if res.StatusCode == 0 {
+		resErr := new(ResponseError)
+		resErr.Section = "StatusLine"
+		resErr.Value = ""
+		resErr.Message = "Status code for the response cannot be zero"
+		return resErr
+	}
+
+	endStream := len(res.BodyBytes) == 0 || res.noBody
+	err := res.stream.writeHeaders(res.StatusCode, res.Headers, endStream)
+	if err == nil && !endStream {
+		err = res.stream.writeData(res.BodyBytes, true)
+	}
+	if err != nil {
+		resErr := new(ResponseError)
+		resErr.Section = "RespWrite"
+		resErr.Value = ""
+		resErr.Message = fmt.Sprintf("Error while writing response to the HTTP/2 stream :: %s", err.Error())
+		return resErr
+	}
+
+	return nil
+}
+
 // Writes the HTTP response status line to the response byte stream.
 func (res *HttpResponse) writeStatusLine() error {
 	if res.StatusCode == 0 {
@@ -146,14 +230,20 @@ func (res *HttpResponse) writeStatusLine() error {
 // Writes the HTTP response headers to the response byte stream.
 func (res *HttpResponse) writeHeaders() error {
 	for key, values := range res.Headers {
-		value := strings.Join(values, ",")
-		_, err := res.writer.WriteString(fmt.Sprintf("%s: %s%s", key, value, HEADER_LINE_SEPERATOR))
-		if err != nil {
-			resErr := new(ResponseError)
-			resErr.Section = "Header"
-			resErr.Value = fmt.Sprintf("%s: %s", key, value)
-			resErr.Message = fmt.Sprintf("Error while writing response header :: %s", err.Error())
-			return resErr
+		// Cookies cannot be combined into a single header line, since the attributes of a cookie can contain commas.
+		lines := []string{ strings.Join(values, ",") }
+		if key == "Set-Cookie" {
+			lines = values
+		}
+		for _, value := range lines {
+			_, err := res.writer.WriteString(fmt.Sprintf("%s: %s%s", key, value, HEADER_LINE_SEPERATOR))
+			if err != nil {
+				resErr := new(ResponseError)
+				resErr.Section = "Header"
+				resErr.Value = fmt.Sprintf("%s: %s", key, value)
+				resErr.Message = fmt.Sprintf("Error while writing response header :: %s", err.Error())
+				return resErr
+			}
 		}
 	}
 
@@ -210,6 +300,377 @@ func (res *HttpResponse) writeBody() error {
 	return nil
 }
 
+// Writes the status line and headers of a streamed response to the response byte stream. The response body can be written afterwards using Stream().
+// If the "Content-Length" header is not set, the body of a HTTP/1.1 response is sent using the chunked transfer coding, and the end of the body of a HTTP/1.0 response is marked by closing the connection.
+// If the status of the response has not been set, "200 - OK" is sent. The headers are buffered until the first call to Flush() or End(), except for HTTP/2 responses whose headers are sent right away.
+func (res *HttpResponse) SendHeaders() error {
+	if res.hijacked {
+		resErr := new(ResponseError)
+		resErr.Section = "RespWrite"
+		resErr.Value = ""
+		resErr.Message = "Response cannot be written once the connection has been hijacked"
+		return resErr
+	}
+
+	if res.writer == nil {
+		resErr := new(ResponseError)
+		resErr.Section = "RespWrite"
+		resErr.Value = ""
+		resErr.Message = "Writer object not initialized"
+		return resErr
+	}
+
+	if res.headersSent {
+		resErr := new(ResponseError)
+		resErr.Section = "Header"
+		resErr.Value = ""
+		resErr.Message = "Response headers have already been sent"
+		return resErr
+	}
+
+	res.headersSent = true
+	if strings.EqualFold(res.Version, "0.9") {
+		res.closeDelimited = true
+		return nil
+	}
+
+	if res.StatusCode == 0 {
+		res.Status(Status200)
+	}
+
+	// HTTP/2 frames mark the end of the response body, so the body is never chunked or delimited by closing the connection.
+	if res.stream != nil {
+		err := res.stream.writeHeaders(res.StatusCode, res.Headers, false)
+		if err != nil {
+			resErr := new(ResponseError)
+			resErr.Section = "Header"
+			resErr.Value = ""
+			resErr.Message = fmt.Sprintf("Error while writing response headers to the HTTP/2 stream :: %s", err.Error())
+			return resErr
+		}
+		return nil
+	}
+
+	_, hasLength := res.Headers.Get("Content-Length")
+	if !hasLength && !res.noBody && res.StatusCode >= 200 && res.StatusCode != int(Status204) && res.StatusCode != int(Status304) {
+		if strings.EqualFold(res.Version, "1.1") {
+			res.chunked = true
+			res.Headers.Add("Transfer-Encoding", "chunked")
+			if res.Trailers.Length() > 0 {
+				trailerNames := make([]string, 0)
+				for key := range res.Trailers {
+					trailerNames = append(trailerNames, key)
+				}
+				slices.Sort(trailerNames)
+				res.Headers.Add("Trailer", strings.Join(trailerNames, ","))
+			}
+		} else {
+			res.closeDelimited = true
+			delete(res.Headers, "Keep-Alive")
+			delete(res.Headers, "Connection")
+			res.Headers.Add("Connection", "close")
+		}
+	}
+
+	err := res.writeStatusLine()
+	if err != nil {
+		return err
+	}
+
+	return res.writeHeaders()
+}
+
+// Writes the given bytes as the next part of a streamed response body. The status line and headers are sent first, if they have not been sent already.
+// The data written is buffered until the buffer is full or until Flush() is called.
+func (res *HttpResponse) Stream(data []byte) (int, error) {
+	if !res.headersSent {
+		err := res.SendHeaders()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if res.finished {
+		resErr := new(ResponseError)
+		resErr.Section = "Body"
+		resErr.Value = ""
+		resErr.Message = "Streamed response has already been completed"
+		return 0, resErr
+	}
+
+	if len(data) == 0 {
+		return 0, nil
+	}
+
+	// The body of a response to a HEAD request is discarded, so that the handler of the GET endpoint can be used unchanged.
+	if res.noBody {
+		return len(data), nil
+	}
+
+	clength, hasLength := res.Headers.Get("Content-Length")
+	if hasLength {
+		contentLength, err := strconv.ParseInt(strings.TrimSpace(clength), 10, 64)
+		if err == nil && res.bodyWritten + int64(len(data)) > contentLength {
+			resErr := new(ResponseError)
+			resErr.Section = "Body"
+			resErr.Value = clength
+			resErr.Message = "Streamed response body is longer than the Content-Length header value"
+			return 0, resErr
+		}
+	}
+
+	if res.chunked {
+		_, err := res.writer.WriteString(fmt.Sprintf("%x%s", len(data), HEADER_LINE_SEPERATOR))
+		if err != nil {
+			resErr := new(ResponseError)
+			resErr.Section = "Body"
+			resErr.Value = "Chunk Size"
+			resErr.Message = fmt.Sprintf("Error while writing response body :: %s", err.Error())
+			return 0, resErr
+		}
+	}
+
+	written, err := res.writer.Write(data)
+	res.bodyWritten += int64(written)
+	if err != nil {
+		resErr := new(ResponseError)
+		resErr.Section = "Body"
+		resErr.Value = "Streamed Body"
+		resErr.Message = fmt.Sprintf("Error while writing response body :: %s", err.Error())
+		return written, resErr
+	}
+
+	if res.chunked {
+		_, err := res.writer.WriteString(HEADER_LINE_SEPERATOR)
+		if err != nil {
+			resErr := new(ResponseError)
+			resErr.Section = "Body"
+			resErr.Value = "Chunk End"
+			resErr.Message = fmt.Sprintf("Error while writing response body :: %s", err.Error())
+			return written, resErr
+		}
+	}
+
+	return written, nil
+}
+
+// Sends all the buffered response data to the client. The status line and headers are sent first, if they have not been sent already.
+func (res *HttpResponse) Flush() error {
+	if !res.headersSent {
+		err := res.SendHeaders()
+		if err != nil {
+			return err
+		}
+	}
+
+	err := res.writer.Flush()
+	if err != nil {
+		resErr := new(ResponseError)
+		resErr.Section = "RespWrite"
+		resErr.Value = ""
+		resErr.Message = "Writer object could not be flushed"
+		return resErr
+	}
+
+	return nil
+}
+
+// Adds a trailer field to be sent after the streamed response body.
+// Trailers added before the headers are sent are announced to the client using the "Trailer" header.
+func (res *HttpResponse) SetTrailer(TrailerKey string, TrailerValue string) {
+	res.Trailers.Add(TrailerKey, TrailerValue)
+}
+
+// Completes a streamed response by writing the last chunk and the trailer fields (for chunked responses) and flushes all the buffered data to the client.
+// The server calls this function automatically once the route handler returns, if a streamed response has not been completed.
+func (res *HttpResponse) End() error {
+	// The event stream is closed first, so that no heartbeat is written once the response is completed.
+	if res.eventStream != nil {
+		res.eventStream.Close()
+	}
+
+	if !res.headersSent {
+		err := res.SendHeaders()
+		if err != nil {
+			return err
+		}
+	}
+
+	if res.finished {
+		return nil
+	}
+
+	res.finished = true
+	// A body shorter than its declared length cannot be completed, and the client would otherwise wait for the missing bytes or read the next response as part of the body.
+	if res.isBodyShort() {
+		res.Flush()
+		res.abort()
+		resErr := new(ResponseError)
+		resErr.Section = "Body"
+		resErr.Value = fmt.Sprintf("%d bytes", res.bodyWritten)
+		resErr.Message = "Streamed response body is shorter than the Content-Length header value, the response has been aborted"
+		return resErr
+	}
+
+	if res.stream != nil {
+		err := res.Flush()
+		if err != nil {
+			return err
+		}
+
+		err = res.stream.finish(res.Trailers)
+		if err != nil {
+			resErr := new(ResponseError)
+			resErr.Section = "Trailer"
+			resErr.Value = ""
+			resErr.Message = fmt.Sprintf("Error while completing the response on the HTTP/2 stream :: %s", err.Error())
+			return resErr
+		}
+		return nil
+	}
+
+	if res.chunked {
+		_, err := res.writer.WriteString("0" + HEADER_LINE_SEPERATOR)
+		if err != nil {
+			resErr := new(ResponseError)
+			resErr.Section = "Body"
+			resErr.Value = "Last Chunk"
+			resErr.Message = fmt.Sprintf("Error while writing response body :: %s", err.Error())
+			return resErr
+		}
+
+		for key, values := range res.Trailers {
+			if slices.Contains(ForbiddenTrailers, key) {
+				continue
+			}
+			value := strings.Join(values, ",")
+			_, err := res.writer.WriteString(fmt.Sprintf("%s: %s%s", key, value, HEADER_LINE_SEPERATOR))
+			if err != nil {
+				resErr := new(ResponseError)
+				resErr.Section = "Trailer"
+				resErr.Value = fmt.Sprintf("%s: %s", key, value)
+				resErr.Message = fmt.Sprintf("Error while writing response trailer :: %s", err.Error())
+				return resErr
+			}
+		}
+
+		_, err = res.writer.WriteString(HEADER_LINE_SEPERATOR)
+		if err != nil {
+			resErr := new(ResponseError)
+			resErr.Section = "Trailer"
+			resErr.Value = HEADER_LINE_SEPERATOR
+			resErr.Message = fmt.Sprintf("Error while writing response trailer :: %s", err.Error())
+			return resErr
+		}
+	}
+
+	return res.Flush()
+}
+
+// Returns true if fewer body bytes have been streamed than the value of the "Content-Length" header sent with a response that carries a body.
+func (res *HttpResponse) isBodyShort() bool {
+	if res.noBody || res.closeDelimited || res.StatusCode < 200 || res.StatusCode == int(Status204) || res.StatusCode == int(Status304) {
+		return false
+	}
+	clength, hasLength := res.Headers.Get("Content-Length")
+	if !hasLength {
+		return false
+	}
+	contentLength, err := strconv.ParseInt(strings.TrimSpace(clength), 10, 64)
+	return err == nil && res.bodyWritten < contentLength
+}
+
+// Returns an io.Writer that streams all the bytes written to it as the response body, using Stream().
+func (res *HttpResponse) BodyWriter() io.Writer {
+	return &responseBodyWriter{ response: res }
+}
+
+// Abandons a streamed response whose body cannot be completed, so that the client does not mistake the partial body for the complete one.
+// The HTTP/2 stream is reset, while HTTP/1.x connections are closed.
+func (res *HttpResponse) abort() {
+	res.finished = true
+	if res.eventStream != nil {
+		res.eventStream.Close()
+	}
+	if res.stream != nil {
+		res.stream.conn.resetStream(res.stream.id, http2InternalError)
+		return
+	}
+	res.closeDelimited = true
+	if res.conn != nil {
+		res.conn.Close()
+	}
+}
+
+// Returns true if the connection must be closed once the response has been sent, either because the end of the response body is marked by closing the connection, because the connection has been upgraded to another protocol or because the "Connection: close" header is set.
+func (res *HttpResponse) closeConnection() bool {
+	if res.closeDelimited || res.upgraded {
+		return true
+	}
+
+	connValue, ok := res.Headers.Get("Connection")
+	if ok {
+		for _, option := range strings.Split(connValue, ",") {
+			if strings.EqualFold(strings.TrimSpace(option), "close") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Takes over the client connection from the server, so that the route handler can speak another protocol on it, like a tunnel for a CONNECT request.
+// It returns the connection along with the bytes sent by the client that were already read from the connection but not consumed by the request, which must be processed before reading from the connection.
+// Once hijacked, the server neither writes the response nor reads further requests from the connection, and the route handler is responsible for closing it.
+// The connection is no longer counted as active by the server, and a graceful shutdown neither closes it nor waits for it. Connections can only be hijacked before the response headers are sent, and HTTP/2 streams cannot be hijacked.
+func (res *HttpResponse) Hijack() (net.Conn, []byte, error) {
+	resErr := new(ResponseError)
+	resErr.Section = "Hijack"
+	resErr.Value = ""
+	if res.hijacked {
+		resErr.Message = "Connection has already been hijacked"
+		return nil, nil, resErr
+	}
+	if res.stream != nil {
+		resErr.Message = "Connection cannot be hijacked for a HTTP/2 stream"
+		return nil, nil, resErr
+	}
+	if res.conn == nil || res.request == nil || res.Server == nil {
+		resErr.Message = "Response is not being written to a network connection"
+		return nil, nil, resErr
+	}
+	if res.headersSent || res.upgraded {
+		resErr.Message = "Connection cannot be hijacked once the response headers have been sent"
+		return nil, nil, resErr
+	}
+
+	var buffered []byte
+	if res.request.reader != nil && res.request.reader.Buffered() > 0 {
+		peeked, _ := res.request.reader.Peek(res.request.reader.Buffered())
+		buffered = make([]byte, len(peeked))
+		copy(buffered, peeked)
+		res.request.reader.Discard(len(peeked))
+	}
+
+	res.hijacked = true
+	res.conn.SetDeadline(time.Time{})
+	res.Server.cw.Remove(res.conn)
+	res.Server.wg.Done()
+	return res.conn, buffered, nil
+}
+
+// Adapter to use a streamed response body as an io.Writer.
+type responseBodyWriter struct {
+	// The response whose body is being streamed.
+	response *HttpResponse
+}
+
+// Streams the given bytes as the next part of the response body.
+func (rbw *responseBodyWriter) Write(data []byte) (int, error) {
+	return rbw.response.Stream(data)
+}
+
 // Adds a new key-value pair to the request headers collection.
 func (res *HttpResponse) AddHeader(HeaderKey string, HeaderValue string) {
 	if slices.Contains(DateHeaders, textproto.CanonicalMIMEHeaderKey(HeaderKey)) {
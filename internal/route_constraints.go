@@ -0,0 +1,245 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Regular expressions matching the values allowed for each of the typed constraints of path parameters, like ":id<int>".
+var typedConstraints = map[string]string {
+	"int": `[-+]?[0-9]+`,
+	"uint": `[0-9]+`,
+	"float": `[-+]?([0-9]+(\.[0-9]*)?|\.[0-9]+)([eE][-+]?[0-9]+)?`,
+	"bool": `true|false`,
+	"uuid": `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+}
+
+// Structure to represent a parameter segment of a route path, like ":id", ":id<int>", ":id(\d+)" or ":slug<[a-z-]+>".
+type paramSegment struct {
+	// Name of the path parameter, under which the matched value is stored in the segments of the request.
+	Name string
+	// Type of the parameter, like "int" or "uuid", for typed constraints. It is empty for regular expression constraints and unconstrained parameters.
+	Type string
+	// Regular expression the whole value of the parameter must match. It is nil for unconstrained parameters.
+	regex *regexp.Regexp
+	// Compiled program of the regular expression, used to find overlapping constraints. It is nil for unconstrained parameters.
+	prog *syntax.Prog
+}
+
+// Parses a parameter segment of a route path, which starts with ":" and is optionally followed by a constraint in angle brackets or parentheses.
+// Angle brackets contain either one of the types "int", "uint", "float", "bool" and "uuid", or a regular expression, while parentheses always contain a regular expression.
+func parseParamSegment(part string) (*paramSegment, error) {
+	declaration, _ := strings.CutPrefix(part, ":")
+	name := declaration
+	pattern := ""
+	segment := new(paramSegment)
+	if index := strings.IndexAny(declaration, "<("); index >= 0 {
+		name = declaration[:index]
+		closing := ">"
+		if declaration[index] == '(' {
+			closing = ")"
+		}
+		if !strings.HasSuffix(declaration, closing) || len(declaration) - index < 3 {
+			return nil, &RoutingError{ RoutePath: part, Message: "parseParamSegment: The constraint of the path parameter must be a non-empty value enclosed in \"<>\" or \"()\"" }
+		}
+		pattern = declaration[index + 1:len(declaration) - 1]
+		if typePattern, ok := typedConstraints[pattern]; ok && closing == ">" {
+			segment.Type = pattern
+			pattern = typePattern
+		}
+	}
+	if name == "" {
+		return nil, &RoutingError{ RoutePath: part, Message: "parseParamSegment: The path parameter must be named" }
+	}
+	segment.Name = name
+	if pattern == "" {
+		return segment, nil
+	}
+
+	anchored := "^(?:" + pattern + ")$"
+	regex, err := regexp.Compile(anchored)
+	if err != nil {
+		return nil, &RoutingError{ RoutePath: part, Message: fmt.Sprintf("parseParamSegment: The constraint of the path parameter is not a valid regular expression: %s", err.Error()) }
+	}
+	parsed, err := syntax.Parse(anchored, syntax.Perl)
+	if err != nil {
+		return nil, &RoutingError{ RoutePath: part, Message: fmt.Sprintf("parseParamSegment: The constraint of the path parameter is not a valid regular expression: %s", err.Error()) }
+	}
+	prog, err := syntax.Compile(parsed.Simplify())
+	if err != nil {
+		return nil, &RoutingError{ RoutePath: part, Message: fmt.Sprintf("parseParamSegment: The constraint of the path parameter is not a valid regular expression: %s", err.Error()) }
+	}
+	segment.regex = regex
+	segment.prog = prog
+	return segment, nil
+}
+
+// Checks if the given value of a request route segment satisfies the constraint of the parameter segment.
+func (ps *paramSegment) matches(value string) bool {
+	if ps.regex == nil {
+		return true
+	}
+	if !ps.regex.MatchString(value) {
+		return false
+	}
+
+	// Numeric values must also fit in the type they are parsed into by the typed accessors.
+	var err error
+	switch ps.Type {
+	case "int":
+		_, err = strconv.ParseInt(value, 10, 64)
+	case "uint":
+		_, err = strconv.ParseUint(value, 10, 64)
+	case "float":
+		_, err = strconv.ParseFloat(value, 64)
+	}
+	return err == nil
+}
+
+// Checks if the parameter segment and the given parameter segment are ambiguous, when declared at the same level of the route tree.
+// Two unconstrained parameters are always ambiguous, while a constrained parameter is never ambiguous with an unconstrained one, since it takes precedence over it.
+// Two constrained parameters are ambiguous if there is a value satisfying both their constraints.
+func (ps *paramSegment) overlaps(other *paramSegment) bool {
+	if ps.prog == nil || other.prog == nil {
+		return ps.prog == nil && other.prog == nil
+	}
+	return regexOverlaps(ps.prog, other.prog)
+}
+
+// Checks if there is a non-empty string matched by both the given compiled regular expressions, by exploring the product of the two programs.
+// Empty-width assertions other than the beginning and the end of the text are assumed to hold, so that overlapping expressions are never missed.
+func regexOverlaps(first *syntax.Prog, second *syntax.Prog) bool {
+	type statePair struct {
+		first uint32
+		second uint32
+	}
+
+	firstStart, _ := regexClosure(first, uint32(first.Start), true)
+	secondStart, _ := regexClosure(second, uint32(second.Start), true)
+	queue := make([]statePair, 0)
+	visited := make(map[statePair]bool)
+	for _, firstPc := range firstStart {
+		for _, secondPc := range secondStart {
+			queue = append(queue, statePair{ firstPc, secondPc })
+		}
+	}
+
+	for len(queue) > 0 {
+		pair := queue[0]
+		queue = queue[1:]
+		if visited[pair] {
+			continue
+		}
+		visited[pair] = true
+
+		firstInst := &first.Inst[pair.first]
+		secondInst := &second.Inst[pair.second]
+		if !rangesIntersect(getRuneRanges(firstInst), getRuneRanges(secondInst)) {
+			continue
+		}
+
+		firstNext, firstMatch := regexClosure(first, firstInst.Out, false)
+		secondNext, secondMatch := regexClosure(second, secondInst.Out, false)
+		if firstMatch && secondMatch {
+			return true
+		}
+		for _, firstPc := range firstNext {
+			for _, secondPc := range secondNext {
+				queue = append(queue, statePair{ firstPc, secondPc })
+			}
+		}
+	}
+	return false
+}
+
+// Follows the instructions of the program that do not consume any input, starting from the given instruction.
+// It returns the instructions consuming a rune that can be reached, and whether the end of the text can be matched at this position.
+func regexClosure(prog *syntax.Prog, pc uint32, atStart bool) ([]uint32, bool) {
+	consuming := make([]uint32, 0)
+	visited := make(map[uint32]bool)
+	var follow func(uint32, bool) bool
+	follow = func(pc uint32, atEnd bool) bool {
+		if visited[pc << 1 | boolToBit(atEnd)] {
+			return false
+		}
+		visited[pc << 1 | boolToBit(atEnd)] = true
+
+		inst := &prog.Inst[pc]
+		switch inst.Op {
+		case syntax.InstAlt, syntax.InstAltMatch:
+			firstMatch := follow(inst.Out, atEnd)
+			secondMatch := follow(inst.Arg, atEnd)
+			return firstMatch || secondMatch
+		case syntax.InstCapture, syntax.InstNop:
+			return follow(inst.Out, atEnd)
+		case syntax.InstEmptyWidth:
+			emptyOp := syntax.EmptyOp(inst.Arg)
+			if emptyOp & (syntax.EmptyBeginText | syntax.EmptyBeginLine) != 0 && !atStart {
+				return false
+			}
+			// An assertion for the end of the text can only be satisfied if no more input follows.
+			if emptyOp & (syntax.EmptyEndText | syntax.EmptyEndLine) != 0 {
+				return follow(inst.Out, true)
+			}
+			return follow(inst.Out, atEnd)
+		case syntax.InstMatch:
+			return true
+		case syntax.InstFail:
+			return false
+		default:
+			if !atEnd {
+				consuming = append(consuming, pc)
+			}
+			return false
+		}
+	}
+	isMatch := follow(pc, false)
+	return consuming, isMatch
+}
+
+// Returns the ranges of runes consumed by the given instruction, as pairs of the lowest and highest rune of each range.
+func getRuneRanges(inst *syntax.Inst) []rune {
+	switch inst.Op {
+	case syntax.InstRune1:
+		return []rune{ inst.Rune[0], inst.Rune[0] }
+	case syntax.InstRuneAny:
+		return []rune{ 0, unicode.MaxRune }
+	case syntax.InstRuneAnyNotNL:
+		return []rune{ 0, '\n' - 1, '\n' + 1, unicode.MaxRune }
+	}
+
+	if len(inst.Rune) == 1 {
+		ranges := []rune{ inst.Rune[0], inst.Rune[0] }
+		if syntax.Flags(inst.Arg) & syntax.FoldCase != 0 {
+			for folded := unicode.SimpleFold(inst.Rune[0]); folded != inst.Rune[0]; folded = unicode.SimpleFold(folded) {
+				ranges = append(ranges, folded, folded)
+			}
+		}
+		return ranges
+	}
+	return inst.Rune
+}
+
+// Checks if any of the first rune ranges intersects with any of the second rune ranges.
+func rangesIntersect(first []rune, second []rune) bool {
+	for i := 0; i + 1 < len(first); i += 2 {
+		for j := 0; j + 1 < len(second); j += 2 {
+			if first[i] <= second[j + 1] && second[j] <= first[i + 1] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Converts the given boolean value to a single bit.
+func boolToBit(value bool) uint32 {
+	if value {
+		return 1
+	}
+	return 0
+}
@@ -1,6 +1,8 @@
 package internal
 
 import (
+	"fmt"
+	"slices"
 	"strings"
 )
 
@@ -36,6 +38,52 @@ var StaticFileHandler = func (request *HttpRequest, response *HttpResponse) {
 	}
 }
 
+// Handler to answer an OPTIONS request automatically with the list of HTTP methods allowed for the requested resource.
+var OptionsHandler = func (request *HttpRequest, response *HttpResponse) {
+	allowedMethods, ok := request.Locals["AllowedMethods"].([]string)
+	if !ok {
+		allowedMethods = getServerMethods(request)
+	}
+	response.Status(Status204)
+	response.Headers.Add("Allow", strings.Join(allowedMethods, ", "))
+	err := response.Write()
+	if err != nil {
+		request.Server.Log(err.Error(), ERROR_LEVEL)
+	}
+}
+
+// Handler to answer a TRACE request by echoing the received request line and headers back to the client as a "message/http" response.
+// The values of the headers listed in TraceRedactedHeaders are redacted, so that credentials are never reflected back.
+var TraceHandler = func (request *HttpRequest, response *HttpResponse) {
+	var message strings.Builder
+	target := request.rawTarget
+	if target == "" {
+		target = request.ResourcePath
+	}
+	message.WriteString(fmt.Sprintf("%s %s HTTP/%s%s", request.Method, target, request.Version, HEADER_LINE_SEPERATOR))
+	headerNames := make([]string, 0)
+	for key := range request.Headers {
+		headerNames = append(headerNames, key)
+	}
+	slices.Sort(headerNames)
+	for _, key := range headerNames {
+		value, _ := request.Headers.Get(key)
+		if slices.Contains(TraceRedactedHeaders, key) {
+			value = "[redacted]"
+		}
+		message.WriteString(fmt.Sprintf("%s: %s%s", key, value, HEADER_LINE_SEPERATOR))
+	}
+	message.WriteString(HEADER_LINE_SEPERATOR)
+
+	response.Status(Status200)
+	response.Headers.Add("Content-Type", "message/http")
+	response.BodyBytes = []byte(message.String())
+	err := response.Write()
+	if err != nil {
+		request.Server.Log(err.Error(), ERROR_LEVEL)
+	}
+}
+
 // Default error handler logic to be implemented for sending an error response back to client.
 var ErrorHandler = func (request *HttpRequest, response *HttpResponse) {
 	if response.StatusCode < int(Status400) {
@@ -43,8 +91,13 @@ var ErrorHandler = func (request *HttpRequest, response *HttpResponse) {
 		return
 	}
 
-	if response.StatusCode == int(Status405) {
-		response.Headers.Add("Allow", GetAllowedMethods(response.Version))
+	_, hasAllow := response.Headers.Get("Allow")
+	if response.StatusCode == int(Status405) && !hasAllow {
+		if request.Server != nil {
+			response.Headers.Add("Allow", request.Server.getAllowedMethods(response.Version))
+		} else {
+			response.Headers.Add("Allow", GetAllowedMethods(response.Version))
+		}
 	}
 
 	statusCode := StatusCode(response.StatusCode)
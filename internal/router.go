@@ -1,6 +1,8 @@
 package internal
 
 import (
+	"fmt"
+	"slices"
 	"strings"
 	"path/filepath"
 )
@@ -13,6 +15,10 @@ type Route struct {
 	Method string
 	// List of all route level middlewares configured.
 	Middlewares []Middleware
+	// Size limits enforced on the requests matching the route, overriding the limits configured for the server. It is nil if the server limits apply.
+	Limits *RequestLimits
+	// Flag to determine if the request body is streamed to the route handler as it arrives, instead of being read before the handler is executed.
+	StreamBody bool
 }
 
 // Structure to hold all the routes and the associated routing logic.
@@ -23,6 +29,12 @@ type Router struct {
 	staticRoutes map[string]string
 	// To access the underlying filesystem and its files/folders.
 	fs *FileSystem
+	// Handler function invoked for requests that do not match any endpoint, instead of the default error handler.
+	notFoundHandler RouteHandler
+	// Handler function invoked for requests whose route path has endpoints declared only for other HTTP methods, instead of the default error handler.
+	methodNotAllowedHandler RouteHandler
+	// Loosest of the request line and header limits configured for the routes of the router, which are read before the route of a request is known.
+	headLimits RequestLimits
 }
 
 // Adds a new static route and target folder to the static routes collection.
@@ -89,18 +101,177 @@ func (rtr *Router) Options(RoutePath string, handlerFunc RouteHandler, middlewar
 	return rtr.addRoute("OPTIONS", RoutePath, handlerFunc, middlewareList)
 }
 
+// Creates a new PATCH endpoint at the given route path and sets the handler function to be invoked when the route is requested by the user.
+func (rtr *Router) Patch(RoutePath string, handlerFunc RouteHandler, middlewareList ...Middleware) error {
+	RoutePath = CleanRoute(RoutePath)
+	return rtr.addRoute("PATCH", RoutePath, handlerFunc, middlewareList)
+}
+
+// Creates a new endpoint at the given route path for every HTTP method and sets the handler function to be invoked when the route is requested by the user.
+// Endpoints declared for a specific method at the same route path take precedence. Extension methods must still be allowed for the server using AllowMethods().
+func (rtr *Router) Any(RoutePath string, handlerFunc RouteHandler, middlewareList ...Middleware) error {
+	RoutePath = CleanRoute(RoutePath)
+	return rtr.addRoute(ANY_METHOD, RoutePath, handlerFunc, middlewareList)
+}
+
+// Creates a new endpoint for the given HTTP method at the given route path and sets the handler function to be invoked when the route is requested by the user.
+// The method can be any valid method token, including extension methods like "PROPFIND", which must also be allowed for the server using AllowMethods(). The method "*" declares the endpoint for every method, like Any().
+func (rtr *Router) Handle(Method string, RoutePath string, handlerFunc RouteHandler, middlewareList ...Middleware) error {
+	RoutePath = CleanRoute(RoutePath)
+	Method = strings.TrimSpace(Method)
+	if !isToken(Method) {
+		reError := new(RoutingError)
+		reError.RoutePath = RoutePath
+		reError.Message = fmt.Sprintf("Handle: [%s] is not a valid HTTP method", Method)
+		return reError
+	}
+	return rtr.addRoute(Method, RoutePath, handlerFunc, middlewareList)
+}
+
 // Creates a new CONNECT endpoint at the given route path and sets the handler function to be invoked when the route is requested by the user.
 func (rtr *Router) Connect(RoutePath string, handlerFunc RouteHandler, middlewareList ...Middleware) error {
 	RoutePath = CleanRoute(RoutePath)
 	return rtr.addRoute("CONNECT", RoutePath, handlerFunc, middlewareList)
 }
 
+// Overrides the size limits enforced on requests for the endpoint with the given HTTP method and route path, as it was declared.
+// Limits left as zero in the given value are taken from the server, and limits set to NO_LIMIT lift the server limit for the endpoint.
+// Since the request line and headers are read before the route is known, they are read under the loosest limits configured for any route and checked against the limits of the endpoint once it has been matched.
+func (rtr *Router) Limit(Method string, RoutePath string, limits RequestLimits) error {
+	RoutePath = CleanRoute(RoutePath)
+	Method = strings.ToUpper(strings.TrimSpace(Method))
+	for _, route := range rtr.routeTree.GetRoutes(RoutePath) {
+		if strings.EqualFold(route.Method, Method) {
+			routeLimits := limits
+			route.Limits = &routeLimits
+			rtr.headLimits = rtr.headLimits.loosest(limits)
+			return nil
+		}
+	}
+
+	reError := new(RoutingError)
+	reError.RoutePath = RoutePath
+	reError.Message = fmt.Sprintf("Limit: No %s endpoint has been declared for the route path", Method)
+	return reError
+}
+
+// Streams the request body to the handler of the endpoint with the given HTTP method and route path, as it was declared, instead of reading the whole body before the handler is executed.
+// The handler reads the body as it arrives using the BodyReader() method of the request. Over HTTP/2, the client is allowed to send more of the body only as the handler reads it.
+func (rtr *Router) StreamBody(Method string, RoutePath string) error {
+	RoutePath = CleanRoute(RoutePath)
+	Method = strings.ToUpper(strings.TrimSpace(Method))
+	for _, route := range rtr.routeTree.GetRoutes(RoutePath) {
+		if strings.EqualFold(route.Method, Method) {
+			route.StreamBody = true
+			return nil
+		}
+	}
+
+	reError := new(RoutingError)
+	reError.RoutePath = RoutePath
+	reError.Message = fmt.Sprintf("StreamBody: No %s endpoint has been declared for the route path", Method)
+	return reError
+}
+
+// Sets the handler function to be invoked for requests that do not match any endpoint declared on the router.
+// The response status is set to "404 - Not Found" before the handler is invoked, and the server level middlewares are executed before the handler.
+func (rtr *Router) NotFound(handlerFunc RouteHandler) {
+	rtr.notFoundHandler = handlerFunc
+}
+
+// Sets the handler function to be invoked for requests whose route path has endpoints declared only for other HTTP methods.
+// The response status is set to "405 - Method Not Allowed" and the "Allow" header lists the methods declared for the route path before the handler is invoked.
+func (rtr *Router) MethodNotAllowed(handlerFunc RouteHandler) {
+	rtr.methodNotAllowedHandler = handlerFunc
+}
+
+// Sends the error response for a request that did not match any endpoint of the router, using the handler set for the error by the application, if any.
+// Requests whose route path matched endpoints declared for other HTTP methods are answered with "405 - Method Not Allowed", and all other requests with "404 - Not Found", unless the error carries its own status code.
+func (rtr *Router) sendMatchError(request *HttpRequest, response *HttpResponse, matchErr error) {
+	handlerFunc := rtr.notFoundHandler
+	routingError, ok := matchErr.(*RoutingError)
+	if ok && routingError.Status != 0 {
+		response.Status(routingError.Status)
+		handlerFunc = nil
+	} else if ok && len(routingError.AllowedMethods) > 0 {
+		response.Status(Status405)
+		response.Headers.Add("Allow", strings.Join(routingError.AllowedMethods, ", "))
+		handlerFunc = rtr.methodNotAllowedHandler
+	} else {
+		response.Status(Status404)
+	}
+
+	if handlerFunc == nil {
+		handlerFunc = ErrorHandler
+	}
+	handlerFunc(request, response)
+}
+
+// Gets the list of HTTP methods that can be used for a route path with the given endpoints. HEAD can be used wherever GET can, and OPTIONS can always be used.
+// If an endpoint has been declared for every method, all the methods allowed for the server are returned.
+func (rtr *Router) getAllowedMethods(routes []*Route, request *HttpRequest) []string {
+	methods := make([]string, 0)
+	for _, route := range routes {
+		if route.Method == ANY_METHOD {
+			return getServerMethods(request)
+		}
+		if !slices.Contains(methods, route.Method) {
+			methods = append(methods, route.Method)
+		}
+	}
+
+	if slices.Contains(methods, "GET") && !slices.Contains(methods, "HEAD") {
+		methods = append(methods, "HEAD")
+	}
+	if !slices.Contains(methods, "OPTIONS") {
+		methods = append(methods, "OPTIONS")
+	}
+	slices.Sort(methods)
+	return methods
+}
+
+// Creates the route answering an OPTIONS request automatically with the given list of allowed methods.
+func newOptionsRoute(request *HttpRequest, allowedMethods []string) *Route {
+	request.Locals["AllowedMethods"] = allowedMethods
+	optionsRoute := new(Route)
+	optionsRoute.Method = "OPTIONS"
+	optionsRoute.RouteHandler = OptionsHandler
+	optionsRoute.Middlewares = make([]Middleware, 0)
+	return optionsRoute
+}
+
+// Checks if the given request is a TRACE request to be answered by the built-in responder of the server, when no endpoint has been declared for it.
+func isTraceEnabled(request *HttpRequest) bool {
+	return strings.EqualFold(request.Method, "TRACE") && request.Server != nil && request.Server.EnableTrace
+}
+
+// Creates the route answering a TRACE request using the built-in responder.
+func newTraceRoute() *Route {
+	traceRoute := new(Route)
+	traceRoute.Method = "TRACE"
+	traceRoute.RouteHandler = TraceHandler
+	traceRoute.Middlewares = make([]Middleware, 0)
+	return traceRoute
+}
+
+// Gets the list of HTTP methods allowed for the server processing the given request, for the HTTP version of the request.
+func getServerMethods(request *HttpRequest) []string {
+	allowedMethods := GetAllowedMethods(request.Version)
+	if request.Server != nil {
+		allowedMethods = request.Server.getAllowedMethods(request.Version)
+	}
+	if allowedMethods == "" {
+		return make([]string, 0)
+	}
+	return strings.Split(allowedMethods, ", ")
+}
+
 // Adds a new dynamic route and its associated handler function to the collection of routes defined in the router instance.
+// Path parameters can be constrained by a type or a regular expression, and the route path must not match the same request paths as another route path already declared. A catch-all segment, like "*filepath", must be named and can only be the last segment of the route path.
 func (rtr *Router) addRoute(Method string, RoutePath string, handlerFunc RouteHandler, middlewareList []Middleware) error {
 	RoutePath = CleanRoute(RoutePath)
 	Method = strings.TrimSpace(Method)
 	Method = strings.ToUpper(Method)
-
 	routeObj := Route{
 		RouteHandler: handlerFunc,
 		Method: Method,
@@ -108,13 +279,22 @@ func (rtr *Router) addRoute(Method string, RoutePath string, handlerFunc RouteHa
 	}
 
 	routeObj.Middlewares = append(routeObj.Middlewares, middlewareList...)
-	rtr.routeTree.Insert(RoutePath, &routeObj)
-	return nil
+	return rtr.routeTree.Insert(RoutePath, &routeObj)
 }
 
 // Function that matches a given route with the route tree and fetches the matched route, uses this route to get the corresponding handler.
 func (rtr *Router) Match(request *HttpRequest) (*Route, error) {
 	routePath := CleanRoute(request.ResourcePath)
+	// The request "OPTIONS *" is about the server as a whole, rather than any of its resources.
+	if strings.EqualFold(request.Method, "OPTIONS") && request.rawTarget == "*" {
+		return newOptionsRoute(request, getServerMethods(request)), nil
+	}
+
+	// A TRACE request that must not be forwarded any further is answered by the built-in responder, even if it matches an endpoint like a proxy.
+	if isTraceEnabled(request) && request.isLastHop() {
+		return newTraceRoute(), nil
+	}
+
 	if strings.EqualFold(request.Method, "GET") || strings.EqualFold(request.Method, "HEAD") {
 		for routeKey, TargetPath := range rtr.staticRoutes {
 			if strings.HasPrefix(routePath, routeKey) {
@@ -134,7 +314,10 @@ func (rtr *Router) Match(request *HttpRequest) (*Route, error) {
 	}
 
 	routeInfo := rtr.routeTree.Match(routePath)
-	if routeInfo.MatchedRoutes == nil {
+	if len(routeInfo.MatchedRoutes) == 0 && isTraceEnabled(request) {
+		return newTraceRoute(), nil
+	}
+	if len(routeInfo.MatchedRoutes) == 0 {
 		reError := new(RoutingError)
 		reError.RoutePath = routePath
 		reError.Message = "matchRoute: A match was not found in the router's prefix tree"
@@ -147,18 +330,40 @@ func (rtr *Router) Match(request *HttpRequest) (*Route, error) {
 		}
 	}
 
-	var finalRoute *Route = nil
+	// Endpoints declared for the request method take precedence over the endpoints declared for every method.
+	// HEAD requests are served by the GET endpoint when no HEAD endpoint has been declared, and OPTIONS requests are answered automatically when no OPTIONS endpoint has been declared.
+	var finalRoute, getRoute, anyRoute *Route
 	for _, route := range routeInfo.MatchedRoutes {
 		if strings.EqualFold(route.Method, request.Method) {
 			finalRoute = route
 			break
 		}
+		if route.Method == "GET" {
+			getRoute = route
+		}
+		if route.Method == ANY_METHOD && anyRoute == nil {
+			anyRoute = route
+		}
+	}
+
+	if finalRoute == nil && strings.EqualFold(request.Method, "HEAD") {
+		finalRoute = getRoute
+	}
+	if finalRoute == nil {
+		finalRoute = anyRoute
+	}
+	if finalRoute == nil && strings.EqualFold(request.Method, "OPTIONS") {
+		finalRoute = newOptionsRoute(request, rtr.getAllowedMethods(routeInfo.MatchedRoutes, request))
+	}
+	if finalRoute == nil && isTraceEnabled(request) {
+		finalRoute = newTraceRoute()
 	}
 
 	if finalRoute == nil {
 		reError := new(RoutingError)
 		reError.RoutePath = routePath
 		reError.Message = "matchRoute: A match was not for the HTTP method and route combination"
+		reError.AllowedMethods = rtr.getAllowedMethods(routeInfo.MatchedRoutes, request)
 		return nil, reError
 	}
 
@@ -1,8 +1,10 @@
 package internal
 
 import (
+	"fmt"
 	"strings"
 	"path"
+	"slices"
 )
 
 // Contains the match information when a given route is matched with the prefix tree.
@@ -34,10 +36,20 @@ func newMatchInfo() *MatchInfo {
 
 // Structure to represent each individual node of the prefix tree (trie tree).
 type PrefixTreeNode struct {
-	// Child elements to the current node stored as a map.
+	// Child elements to the current node stored as a map, keyed by the route part as it was inserted. It holds the children of every kind of segment, and is not used to match request routes.
 	Children map[string]*PrefixTreeNode
 	// Route instance mapped to the current node. Default value is nil.
 	Routes []*Route
+	// Parameter segment represented by the current node, along with its constraint. It is nil for literal and catch-all segments.
+	param *paramSegment
+	// Route part of the parent node leading to the current node.
+	key string
+	// Children of the current node for literal segments, which are matched only by the identical request route part.
+	literalChildren map[string]*PrefixTreeNode
+	// Children of the current node for parameter segments, in the order they are tried when matching a route.
+	paramChildren []*PrefixTreeNode
+	// Child of the current node for a catch-all segment. It is nil if the node has no catch-all child.
+	catchAllChild *PrefixTreeNode
 }
 
 // Adds a route instance to the routes list of the prefix tree node.
@@ -48,11 +60,96 @@ func (ptn *PrefixTreeNode) AddToRoutes(route *Route) {
 	ptn.Routes = append(ptn.Routes, route)
 }
 
+// Adds the given node as a child of the prefix tree node for the given route part, and to the collection of children of its kind.
+func (ptn *PrefixTreeNode) addChild(part string, childNode *PrefixTreeNode) {
+	childNode.key = part
+	ptn.Children[part] = childNode
+	if childNode.param != nil {
+		ptn.paramChildren = append(ptn.paramChildren, childNode)
+		slices.SortFunc(ptn.paramChildren, func(first *PrefixTreeNode, second *PrefixTreeNode) int {
+			return compareSegments(first.key, second.key)
+		})
+	} else if isCatchAllSegment(part) {
+		ptn.catchAllChild = childNode
+	} else {
+		ptn.literalChildren[part] = childNode
+	}
+}
+
+// Walks the subtree of the prefix tree node to find a node with routes mapped to it, matching the given route parts.
+// At each level, the literal child is tried first, followed by the parameter children in the order of their precedence and finally the catch-all child. When a branch does not lead to a node with routes, the matcher backtracks and tries the next candidate.
+// Since every node is reached with the same remaining route parts, each node is visited at most once, and the result is the same for every call.
+func (ptn *PrefixTreeNode) match(parts []string, state *matchState) *PrefixTreeNode {
+	if len(parts) == 0 {
+		if len(ptn.Routes) > 0 {
+			return ptn
+		}
+		return nil
+	}
+
+	part := parts[0]
+	if childNode, exists := ptn.literalChildren[part]; exists {
+		state.push(part, "", "", false)
+		if matched := childNode.match(parts[1:], state); matched != nil {
+			return matched
+		}
+		state.pop(false)
+	}
+
+	for _, childNode := range ptn.paramChildren {
+		if !childNode.param.matches(part) {
+			continue
+		}
+		state.push(childNode.key, childNode.param.Name, part, true)
+		if matched := childNode.match(parts[1:], state); matched != nil {
+			return matched
+		}
+		state.pop(true)
+	}
+
+	if ptn.catchAllChild != nil {
+		state.push(ptn.catchAllChild.key, strings.TrimPrefix(ptn.catchAllChild.key, "*"), strings.Join(parts, ROUTE_SEPERATOR), true)
+		return ptn.catchAllChild
+	}
+	return nil
+}
+
+// Checks if a route already inserted in the subtree of the prefix tree node matches the same request routes as the given route parts.
+// Such a route has the same number of segments, and differs from the given route parts only in the names of catch-all segments, or in parameter segments whose constraints overlap. Routes differing in a literal segment are never ambiguous, since literal segments take precedence over parameter segments.
+func (ptn *PrefixTreeNode) findAmbiguousRoute(parts []string, segments []*paramSegment, matchedParts []string, differs bool) (string, bool) {
+	if len(parts) == 0 {
+		return CleanRoute(path.Join(matchedParts...)), differs && len(ptn.Routes) > 0
+	}
+
+	if childNode, exists := ptn.Children[parts[0]]; exists {
+		if route, found := childNode.findAmbiguousRoute(parts[1:], segments[1:], append(matchedParts, parts[0]), differs); found {
+			return route, true
+		}
+	}
+	if isCatchAllSegment(parts[0]) && ptn.catchAllChild != nil && ptn.catchAllChild.key != parts[0] {
+		return CleanRoute(path.Join(append(matchedParts, ptn.catchAllChild.key)...)), true
+	}
+	if segments[0] == nil {
+		return "", false
+	}
+	for _, childNode := range ptn.paramChildren {
+		if childNode.key == parts[0] || !segments[0].overlaps(childNode.param) {
+			continue
+		}
+		if route, found := childNode.findAmbiguousRoute(parts[1:], segments[1:], append(matchedParts, childNode.key), true); found {
+			return route, true
+		}
+	}
+	return "", false
+}
+
 // Creates and returns pointer to a new node in the prefix tree.
 func NewPrefixTreeNode() *PrefixTreeNode {
 	newNode := new(PrefixTreeNode)
 	newNode.Routes = nil
 	newNode.Children = make(map[string]*PrefixTreeNode)
+	newNode.literalChildren = make(map[string]*PrefixTreeNode)
+	newNode.paramChildren = make([]*PrefixTreeNode, 0)
 	return newNode
 }
 
@@ -70,23 +167,62 @@ func EmptyPrefixTree() *PrefixTree {
 }
 
 // Inserts the given route path to the prefix tree.
-func (pt *PrefixTree) Insert(RoutePath string, MappedRoute *Route) {
+// Parameter segments, like ":id", can be constrained by a type or a regular expression, like ":id<int>" or ":id(\\d+)". A catch-all segment, like "*filepath", must be named and can only be the last segment of the route path.
+// It returns an error if a segment is not valid, or if a route already inserted matches the same request routes, in which case the prefix tree is left unchanged.
+func (pt *PrefixTree) Insert(RoutePath string, MappedRoute *Route) error {
 	RouteParts := NormalizeRoute(RoutePath)
-	if len(RouteParts) == 0 {
-		pt.Root.AddToRoutes(MappedRoute)
-		return
+	segments := make([]*paramSegment, len(RouteParts))
+	for index, part := range RouteParts {
+		if isCatchAllSegment(part) && (part == "*" || index < len(RouteParts) - 1) {
+			reError := new(RoutingError)
+			reError.RoutePath = RoutePath
+			reError.Message = fmt.Sprintf("Insert: The catch-all segment [%s] must be named and must be the last segment of the route path", part)
+			return reError
+		}
+		if strings.HasPrefix(part, ":") {
+			segment, err := parseParamSegment(part)
+			if err != nil {
+				return err
+			}
+			segments[index] = segment
+		}
+	}
+
+	ambiguousRoute, isAmbiguous := pt.Root.findAmbiguousRoute(RouteParts, segments, make([]string, 0), false)
+	if isAmbiguous {
+		reError := new(RoutingError)
+		reError.RoutePath = RoutePath
+		reError.Message = fmt.Sprintf("Insert: The route path is ambiguous with the route path [%s] already declared", ambiguousRoute)
+		return reError
 	}
 	Current := pt.Root
-	for _, part := range RouteParts {
+	for index, part := range RouteParts {
 		if _, exists := Current.Children[part]; !exists {
-			Current.Children[part] = NewPrefixTreeNode()
+			childNode := NewPrefixTreeNode()
+			childNode.param = segments[index]
+			Current.addChild(part, childNode)
 		}
 		Current = Current.Children[part]
 	}
 	Current.AddToRoutes(MappedRoute)
+	return nil
+}
+
+// Returns the routes mapped to the given route path exactly as it was inserted, without matching its parameter and catch-all segments against the ones in the prefix tree.
+func (pt *PrefixTree) GetRoutes(RoutePath string) []*Route {
+	Current := pt.Root
+	for _, part := range NormalizeRoute(RoutePath) {
+		Next, exists := Current.Children[part]
+		if !exists {
+			return nil
+		}
+		Current = Next
+	}
+	return Current.Routes
 }
 
 // Get all the routes available in the prefix tree.
+// The children of each node are listed in the order of their matching precedence, with the literal segments first, followed by the parameter segments and the catch-all segments.
 func (pt *PrefixTree) GetAllRoutes() []string {
 	routes := make([]string, 0)
 	var traverse func(*PrefixTreeNode, string)
@@ -94,8 +230,13 @@ func (pt *PrefixTree) GetAllRoutes() []string {
 		if CurrentNode.Routes != nil {
 			routes = append(routes, RoutePath)
 		}
-		for part, NextNode := range CurrentNode.Children {
-			traverse(NextNode, path.Join(RoutePath, part))
+		parts := make([]string, 0, len(CurrentNode.Children))
+		for part := range CurrentNode.Children {
+			parts = append(parts, part)
+		}
+		slices.SortFunc(parts, compareSegments)
+		for _, part := range parts {
+			traverse(CurrentNode.Children[part], path.Join(RoutePath, part))
 		}
 	}
 	traverse(pt.Root, "/")
@@ -106,6 +247,7 @@ func (pt *PrefixTree) GetAllRoutes() []string {
 }
 
 // Find a match for the given route in the prefix tree.
+// At each level, a literal segment is preferred over a parameter segment, and a parameter segment over a catch-all segment, which captures the rest of the route, including the slashes. When the rest of the route does not match below a segment, the next segment in the order of precedence is tried.
 func (pt *PrefixTree) Match(RoutePath string) *MatchInfo {
 	MatchedRouteInfo := newMatchInfo()
 	ipRouteParts := NormalizeRoute(RoutePath)
@@ -114,37 +256,86 @@ func (pt *PrefixTree) Match(RoutePath string) *MatchInfo {
 		MatchedRouteInfo.AddToRoutes(pt.Root.Routes)
 		return MatchedRouteInfo
 	}
-	opRouteParts := make([]string, 0)
-	Current := pt.Root
-	for _, part := range ipRouteParts {
-		Next, exists := Current.Children[part]
-		if !exists {
-			hasBeenFound := false
-			for key, nextNode := range Current.Children {
-				paramName, isFound := strings.CutPrefix(key, ":")
-				if isFound {
-					MatchedRouteInfo.Segments.Add(paramName, []string { part })
-					opRouteParts = append(opRouteParts, key)
-					Current = nextNode
-					hasBeenFound = true
-					break
-				}
-			}
-			if !hasBeenFound {
-				MatchedRouteInfo.MatchedRoutes = nil
-				MatchedRouteInfo.MatchedPath = ""
-				return MatchedRouteInfo
-			}
-		} else {
-			opRouteParts = append(opRouteParts, part)
-			Current = Next
-		}
+
+	state := newMatchState(len(ipRouteParts))
+	matchedNode := pt.Root.match(ipRouteParts, state)
+	if matchedNode == nil {
+		MatchedRouteInfo.MatchedRoutes = nil
+		MatchedRouteInfo.MatchedPath = ""
+		return MatchedRouteInfo
 	}
-	MatchedRouteInfo.AddToRoutes(Current.Routes)
-	MatchedRouteInfo.MatchedPath = CleanRoute(path.Join(opRouteParts...))
+
+	for index, paramName := range state.paramNames {
+		MatchedRouteInfo.Segments.Add(paramName, []string { state.paramValues[index] })
+	}
+	MatchedRouteInfo.AddToRoutes(matchedNode.Routes)
+	MatchedRouteInfo.MatchedPath = CleanRoute(path.Join(state.routeParts...))
 	return MatchedRouteInfo
 }
 
+// Structure to hold the route parts and path parameters of the branch being explored while matching a route in the prefix tree.
+type matchState struct {
+	// Route parts of the prefix tree along the branch being explored.
+	routeParts []string
+	// Names of the path parameters captured along the branch being explored.
+	paramNames []string
+	// Values of the path parameters captured along the branch being explored.
+	paramValues []string
+}
+
+// Creates a new match state with enough capacity for a route with the given number of parts.
+func newMatchState(partCount int) *matchState {
+	state := new(matchState)
+	state.routeParts = make([]string, 0, partCount)
+	state.paramNames = make([]string, 0, partCount)
+	state.paramValues = make([]string, 0, partCount)
+	return state
+}
+
+// Adds a route part to the branch being explored, along with the path parameter it captures, if any.
+func (ms *matchState) push(routePart string, paramName string, paramValue string, isParam bool) {
+	ms.routeParts = append(ms.routeParts, routePart)
+	if isParam {
+		ms.paramNames = append(ms.paramNames, paramName)
+		ms.paramValues = append(ms.paramValues, paramValue)
+	}
+}
+
+// Removes the last route part from the branch being explored, along with the path parameter it captured, if any.
+func (ms *matchState) pop(isParam bool) {
+	ms.routeParts = ms.routeParts[:len(ms.routeParts) - 1]
+	if isParam {
+		ms.paramNames = ms.paramNames[:len(ms.paramNames) - 1]
+		ms.paramValues = ms.paramValues[:len(ms.paramValues) - 1]
+	}
+}
+
+// Checks if the given route part is a catch-all segment, like "*filepath", which captures the rest of the route.
+func isCatchAllSegment(part string) bool {
+	return strings.HasPrefix(part, "*")
+}
+
+// Compares two route parts by their matching precedence, placing the literal segments first, followed by the parameter segments with a constraint, the parameter segments without a constraint and the catch-all segments.
+// Route parts of the same kind are compared in lexical order.
+func compareSegments(first string, second string) int {
+	rank := func(part string) int {
+		if isCatchAllSegment(part) {
+			return 3
+		}
+		if strings.HasPrefix(part, ":") && strings.ContainsAny(part, "<(") {
+			return 1
+		}
+		if strings.HasPrefix(part, ":") {
+			return 2
+		}
+		return 0
+	}
+	if rank(first) != rank(second) {
+		return rank(first) - rank(second)
+	}
+	return strings.Compare(first, second)
+}
+
 // Normalizes the given route path into a slice of route parts present in the path.
 // This function also removes any leading or trailing space and '/' before getting the route parts.
 func NormalizeRoute(RoutePath string) []string {
@@ -1,6 +1,10 @@
 package internal
 
 import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -10,6 +14,7 @@ import (
 	"os/signal"
 	"runtime"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -19,29 +24,6 @@ import (
 // Number of CPUs that can be used for facilitating concurrency.
 var numCPU = runtime.NumCPU()
 
-// Structure to track all the active connections maintained by the server.
-type ConnectionWatcher struct {
-	// Mutex to synchronize read-write activities for tracking the number of active connections.
-	mu sync.RWMutex
-	// Contains the number of active connections with the server.
-	connCount int
-}
-
-// Increases the connection count by the specified delta.
-func (cw *ConnectionWatcher) UpdateCount(delta int) {
-	cw.mu.Lock()
-	cw.connCount += delta
-	cw.mu.Unlock()
-}
-
-// Returns the connection count value for the ConnectionWatcher instance..
-func (cw *ConnectionWatcher) GetCount() int {
-	cw.mu.RLock()
-	count := cw.connCount
-	cw.mu.RUnlock()
-	return count
-}
-
 // Structure to create an instance of a web server.
 type HttpServer struct {
 	// Hostname of the web server instance.
@@ -50,8 +32,10 @@ type HttpServer struct {
 	PortNumber int
 	// // key-value pairs to hold variables available for all requests and responses processed by the server instance.
 	Locals map[string]any
-	// Listener created and bound to the host address and port number.
-	listener net.Listener
+	// List of all listeners from which the server accepts incoming connections.
+	listeners []net.Listener
+	// Listeners created by Start() and StartTLS() for the configured address, which are handed over to the new process when the server is upgraded.
+	boundListeners []boundListener
 	// Router instance that contains all the routes and their associated handlers.
 	Router *Router
 	// Logger to capture request processing logs.
@@ -66,18 +50,110 @@ type HttpServer struct {
 	cw *ConnectionWatcher
 	// Flag to determine if the listener is closed.
 	listClosed bool
-	// Mutex to manage read-write activities on the listClosed flag.
+	// Mutex to manage read-write activities on the listClosed flag and the list of listeners.
 	limu sync.RWMutex
+	// Ensures that the shutdown channel is closed only once.
+	shutdownOnce sync.Once
+	// Ensures that the certificate reload goroutine is started only once.
+	watchOnce sync.Once
 	// Server level middlewares to be executed for all incoming requests regardless of the matching route.
 	middlewares []Middleware
+	// TLS configuration to be used by the server for HTTPS connections. If nil, a default configuration is created when the server starts listening for HTTPS requests.
+	TLSConfig *tls.Config
+	// Collection of certificates presented to HTTPS clients, selected by the server name sent by the client.
+	certificates *CertificateStore
+	// Maximum duration allowed for reading the request line and headers of a request. A zero value means there is no timeout.
+	ReadHeaderTimeout time.Duration
+	// Maximum duration allowed for reading the body of a request, once the headers have been read. A zero value means there is no timeout.
+	ReadBodyTimeout time.Duration
+	// Maximum duration allowed for writing the response, starting from the time the request has been read. A zero value means there is no timeout.
+	WriteTimeout time.Duration
+	// Maximum duration for which a persistent connection is kept open waiting for the next request. A zero value means there is no timeout.
+	IdleTimeout time.Duration
+	// Maximum number of requests that can be processed on a single persistent connection. A zero value means there is no limit.
+	MaxRequestsPerConnection int
+	// Flag to determine if the idle timeout is derived from the number of active connections using the server's keep-alive heuristic, instead of using the IdleTimeout value.
+	AdaptiveKeepAlive bool
+	// Maximum number of connections handled by the server at the same time. A zero value means there is no limit.
+	MaxConnections int
+	// Maximum number of connections handled by the server at the same time for a single client IP address. A zero value means there is no limit.
+	MaxConnectionsPerIP int
+	// Determines what happens to new connections once the maximum number of connections is reached. Allowed options - OVERLOAD_REJECT (default), OVERLOAD_QUEUE.
+	OverloadPolicy string
+	// Maximum number of connections waiting in the queue when the overload policy is OVERLOAD_QUEUE. Connections accepted once the queue is full are rejected.
+	MaxQueuedConnections int
+	// Maximum duration for which a connection waits in the queue before it is rejected. A zero value means the connection waits until it is admitted or the server shuts down.
+	QueueTimeout time.Duration
+	// Duration sent to rejected clients in the "Retry-After" header, after which they can try to connect again.
+	RetryAfter time.Duration
+	// Size limits enforced on all the requests received by the server. The limits can be overridden for individual routes using the "Limit" method of the router.
+	Limits RequestLimits
+	// Flag to determine if clients can use HTTP/2, negotiated using ALPN for HTTPS connections and using prior knowledge or "Upgrade: h2c" for cleartext connections.
+	EnableHTTP2 bool
+	// Flag to determine if messages sent over WebSocket connections are compressed using the "permessage-deflate" extension, when the client supports it.
+	WebSocketCompression bool
+	// Flag to determine if TRACE requests are answered by the built-in responder, which echoes the received request back to the client with its credentials redacted.
+	// It is disabled by default, since echoing requests back can expose headers to cross-site tracing attacks.
+	EnableTrace bool
+	// Flag to determine if connections from the sources listed in ProxyProtocolSources start with a PROXY protocol header (v1 or v2), whose source address is used as the client address.
+	ProxyProtocol bool
+	// List of networks, in CIDR notation like "10.0.0.0/8" or as single IP addresses, of the load balancers allowed to send the PROXY protocol header.
+	ProxyProtocolSources []string
+	// Networks of the proxies trusted to send the "Forwarded" and "X-Forwarded-*" headers, set using SetTrustedProxies().
+	trustedProxies []*net.IPNet
+	// Virtual hosts declared on the server using AddVirtualHost(), keyed by their host name or wildcard pattern.
+	virtualHosts map[string]*VirtualHost
+	// List of extension methods, like "PROPFIND" or "MKCOL", allowed for the server in addition to the methods supported for each HTTP version.
+	extensionMethods []string
 }
 
-// Function that closes the server listener and marks the listClosed flag as closed.
-func (srv *HttpServer) close() {
+// Function that closes all the server listeners and marks the listClosed flag as closed.
+// It returns the first error raised while closing the listeners, if any.
+func (srv *HttpServer) close() error {
+	upgrader.unregister(srv)
 	srv.limu.Lock()
+	defer srv.limu.Unlock()
 	srv.listClosed = true
-	srv.limu.Unlock()
-	srv.listener.Close()
+	var closeErr error
+	for _, listener := range srv.listeners {
+		err := listener.Close()
+		if err != nil && closeErr == nil {
+			closeErr = err
+		}
+	}
+	srv.listeners = nil
+	return closeErr
+}
+
+// Adds the given listener to the list of server listeners.
+// It returns false if the server has already been closed, in which case the listener is not added.
+// Once added, the listener is counted in the server's wait group before the server can be closed, so that a shutdown always waits for serve() to return.
+func (srv *HttpServer) addListener(listener net.Listener) bool {
+	srv.limu.Lock()
+	defer srv.limu.Unlock()
+	if srv.listClosed {
+		return false
+	}
+	srv.listeners = append(srv.listeners, listener)
+	srv.wg.Add(1)
+	return true
+}
+
+// Closes the shutdown channel to notify all the goroutines that the server is shutting down.
+func (srv *HttpServer) signalShutdown() {
+	srv.shutdownOnce.Do(func() {
+		close(srv.shutdown)
+	})
+}
+
+// Returns true if the server has been signalled to shutdown and false, otherwise.
+func (srv *HttpServer) isShuttingDown() bool {
+	select {
+	case <-srv.shutdown:
+		return true
+	default:
+		return false
+	}
 }
 
 // Returns true if the server listener is already closed and false, otherwise.
@@ -93,7 +169,7 @@ func (srv *HttpServer) isClosed() bool {
 func (srv *HttpServer) processMiddlewares(request *HttpRequest, response *HttpResponse, middlewareList []Middleware) bool {
 	mwsInstance := CreateMiddlewares(middlewareList...)
 	for _, middleware := range mwsInstance.Stack {
-		if !mwsInstance.ProcessNext {
+		if !mwsInstance.ProcessNext || response.hijacked {
 			return true
 		}
 		middleware(request, response, mwsInstance.Stop)
@@ -101,163 +177,482 @@ func (srv *HttpServer) processMiddlewares(request *HttpRequest, response *HttpRe
 	return false
 }
 
-// Accepts incoming connections and creates seperate goroutines for each new client.
-func (srv *HttpServer) acceptConnections() {
-	defer srv.wg.Done()
-
+// Accepts incoming connections from the given listener and creates seperate goroutines for each new client.
+// It returns ServerClosedError once the server is shutdown or closed, and any other error that prevents the listener from accepting new connections.
+func (srv *HttpServer) acceptConnections(listener net.Listener) error {
+	var retryDelay time.Duration
 	for {
 		select {
 		case <-srv.shutdown:
 			srv.Log("Server Shutdown initiated :: No new connections will be accepted from now.", WARN_LEVEL)
-			return
+			return &ServerClosedError{}
 		default:
-			clientConnection, err := srv.listener.Accept()
+			clientConnection, err := listener.Accept()
 			if err != nil {
-				if !srv.isClosed() {
-					srv.Log(fmt.Sprintf("Error occurred while accepting a new client: %s", err.Error()), ERROR_LEVEL)
+				if srv.isClosed() {
+					return &ServerClosedError{}
+				}
+				if errors.Is(err, net.ErrClosed) {
+					return err
 				}
+
+				srv.Log(fmt.Sprintf("Error occurred while accepting a new client: %s", err.Error()), ERROR_LEVEL)
+				if retryDelay == 0 {
+					retryDelay = 5 * time.Millisecond
+				} else {
+					retryDelay = min(2 * retryDelay, time.Second)
+				}
+				time.Sleep(retryDelay)
 				continue
 			}
 
+			retryDelay = 0
 			srv.Log(fmt.Sprintf("A new client - %s has connected to the server", TextColor.Green(clientConnection.RemoteAddr().String())), INFO_LEVEL)
 			srv.wg.Add(1)
-			go srv.handleClient(clientConnection)
-			srv.cw.UpdateCount(1)
+			srv.admitConnection(clientConnection)
 		}
 	}
 }
 
+// Admits the given connection if the connection limits of the server have not been reached, and starts handling its requests.
+// Otherwise, the connection is either queued or rejected depending on the overload policy of the server.
+func (srv *HttpServer) admitConnection(conn net.Conn) {
+	srv.cw.SetLimits(srv.MaxConnections, srv.MaxConnectionsPerIP)
+	added, ipLimitReached := srv.cw.TryAdd(conn)
+	if added {
+		go srv.handleClient(conn)
+		return
+	}
+
+	if ipLimitReached {
+		srv.Log(fmt.Sprintf("Client [%s] has reached the limit of %d connections per IP address", conn.RemoteAddr().String(), srv.MaxConnectionsPerIP), WARN_LEVEL)
+		go srv.rejectConnection(conn)
+		return
+	}
+
+	if strings.EqualFold(srv.OverloadPolicy, OVERLOAD_QUEUE) {
+		admitted, ok := srv.cw.Enqueue(conn, srv.MaxQueuedConnections)
+		if ok {
+			go srv.waitInQueue(conn, admitted)
+			return
+		}
+	}
+
+	srv.Log(fmt.Sprintf("Client [%s] was rejected since the server has reached the limit of %d connections", conn.RemoteAddr().String(), srv.MaxConnections), WARN_LEVEL)
+	go srv.rejectConnection(conn)
+}
+
+// Waits until the given queued connection is admitted and then handles its requests.
+// The connection is rejected if it is not admitted before the queue timeout expires, and closed if the server shuts down in the meantime.
+func (srv *HttpServer) waitInQueue(conn net.Conn, admitted chan struct{}) {
+	var timeout <-chan time.Time
+	if srv.QueueTimeout > 0 {
+		timer := time.NewTimer(srv.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-admitted:
+		srv.handleClient(conn)
+		return
+	case <-timeout:
+		if srv.cw.Dequeue(conn) {
+			srv.Log(fmt.Sprintf("Client [%s] was rejected since it was not admitted within the queue timeout", conn.RemoteAddr().String()), WARN_LEVEL)
+			srv.rejectConnection(conn)
+			return
+		}
+	case <-srv.shutdown:
+		if srv.cw.Dequeue(conn) {
+			conn.Close()
+			srv.wg.Done()
+			return
+		}
+	}
+
+	// The connection was admitted while the timeout or the shutdown was being processed.
+	srv.handleClient(conn)
+}
+
+// Rejects the given connection with a "503 - Service Unavailable" response, which includes a "Retry-After" header, and closes the connection.
+func (srv *HttpServer) rejectConnection(conn net.Conn) {
+	defer srv.wg.Done()
+	defer conn.Close()
+	srv.cw.Reject()
+
+	conn.SetDeadline(time.Now().Add(time.Duration(GetServerDefaults("reject_timeout").(int)) * time.Second))
+	var httpResponse HttpResponse
+	httpResponse.Initialize("1.1", conn)
+	httpResponse.Server = srv
+	httpResponse.conn = conn
+	httpResponse.Status(Status503)
+	httpResponse.Headers.Add("Connection", "close")
+	retryAfter := int(math.Ceil(srv.RetryAfter.Seconds()))
+	if retryAfter > 0 {
+		httpResponse.Headers.Add("Retry-After", strconv.Itoa(retryAfter))
+	}
+	err := httpResponse.SendError(Status503.GetErrorContent())
+	if err != nil {
+		srv.Log(fmt.Sprintf("Error occurred while rejecting client [%s]: %s", conn.RemoteAddr().String(), err.Error()), ERROR_LEVEL)
+		return
+	}
+
+	// The request sent by the client is discarded before the connection is closed, so that the client receives the response instead of a connection reset.
+	closeWriter, ok := conn.(interface{ CloseWrite() error })
+	if ok && closeWriter.CloseWrite() == nil {
+		io.Copy(io.Discard, io.LimitReader(conn, 64 * 1024))
+	}
+}
+
+// Returns a snapshot of the connections currently handled by the server, along with the number of connections accepted and rejected since the server started.
+func (srv *HttpServer) Stats() ServerStats {
+	return srv.cw.GetStats()
+}
+
 // Handles incoming HTTP requests sent from each individual client trying to connect to the web server instance.
 func (srv *HttpServer) handleClient(ClientConnection net.Conn) {
-	defer srv.wg.Done()
-	defer srv.cw.UpdateCount(-1)
-	defer ClientConnection.Close()
+	// Once the connection is hijacked by a route handler, it has already been removed from the active connections and the wait group, and is closed by the handler.
+	hijacked := false
+	defer func() {
+		if !hijacked {
+			ClientConnection.Close()
+			srv.cw.Remove(ClientConnection)
+			srv.wg.Done()
+		}
+	}()
 
-	handleRequest := func() (int, error) {
-		httpRequest := srv.NewRequest(ClientConnection)
-		err := httpRequest.Read()
+	tlsConn, isTLS := ClientConnection.(*tls.Conn)
+	if isTLS {
+		handshakeTimeout := GetServerDefaults("tls_handshake_timeout").(int)
+		tlsConn.SetDeadline(time.Now().Add(time.Duration(handshakeTimeout) * time.Second))
+		err := tlsConn.Handshake()
+		if err != nil {
+			srv.Log(fmt.Sprintf("TLS handshake with client [%s] failed: %s", ClientConnection.RemoteAddr().String(), err.Error()), ERROR_LEVEL)
+			return
+		}
+		tlsConn.SetDeadline(time.Time{})
+		if srv.EnableHTTP2 && tlsConn.ConnectionState().NegotiatedProtocol == HTTP2_ALPN_PROTOCOL {
+			srv.cw.SetIdle(ClientConnection, false)
+			srv.serveHTTP2(ClientConnection, bufio.NewReader(ClientConnection), nil, nil, nil, nil)
+			return
+		}
+	}
+
+	// Processes a single request read from the connection and returns the duration for which the connection can be kept idle waiting for the next request.
+	// The boolean value returned is true if the connection must be closed once the request has been processed.
+	handleRequest := func(reader *bufio.Reader, requestCount int) (time.Duration, bool, error) {
+		httpRequest := srv.newRequest(ClientConnection, reader)
+		// The read deadline for the first request on the connection is already set while waiting for the request to arrive.
+		if srv.ReadHeaderTimeout > 0 && requestCount > 1 {
+			ClientConnection.SetReadDeadline(time.Now().Add(srv.ReadHeaderTimeout))
+		}
+		// The route is matched before the request body is read, so that the size limits configured for the route can be enforced on the body.
+		var matchedRoute *Route
+		var matchErr error
+		err := httpRequest.readHead()
+		if err == nil {
+			limits := srv.Limits
+			matchedRoute, matchErr = srv.matchRoute(httpRequest)
+			if matchErr == nil {
+				limits = limits.merge(matchedRoute.Limits)
+			}
+			err = httpRequest.applyLimits(limits)
+		}
+		if err == nil {
+			err = httpRequest.checkExpectation()
+		}
+		// Routes that stream the request body leave reading the body to the route handler.
+		if err == nil && matchErr == nil && matchedRoute.StreamBody {
+			httpRequest.streamBody = true
+		} else if err == nil && !httpRequest.bodyPending {
+			err = httpRequest.readContent()
+		}
+		ClientConnection.SetReadDeadline(time.Time{})
+		if srv.WriteTimeout > 0 {
+			ClientConnection.SetWriteDeadline(time.Now().Add(srv.WriteTimeout))
+		}
 		if err != nil {
-			_, ok := err.(*ReadTimeoutError)
-			if err != io.EOF && !ok {
+			_, isTimeout := err.(*ReadTimeoutError)
+			if err != io.EOF && !isTimeout {
 				srv.Log(err.Error(), ERROR_LEVEL)
 			}
 
-			return 0, err
+			srv.respondToReadError(ClientConnection, httpRequest, err)
+			return 0, true, err
 		}
 
-		httpRequest.Locals["Started"] = time.Now()
-		httpResponse := srv.NewResponse(ClientConnection, httpRequest)
-		var timeout int
-		var max int
-		connValue, ok := httpRequest.Headers.Get("Connection")
-		if ok && strings.EqualFold(connValue, "keep-alive") && strings.EqualFold(httpResponse.Version, "1.1") {
-			currCount := srv.cw.GetCount()
-			timeout, max = srv.getKeepAliveHeuristic(currCount)
-			srv.Log(fmt.Sprintf("The timeout value returned by heuristic is %d seconds for active connection count %d", timeout, currCount), INFO_LEVEL)
-			tcpConn, ok := ClientConnection.(*net.TCPConn)
+		// A cleartext HTTP/1.1 request can ask to switch to HTTP/2, in which case the request is answered on the first stream of the HTTP/2 connection.
+		// Requests whose body has not been read yet are answered over HTTP/1.1, since the body must be received before the protocol is switched.
+		if srv.EnableHTTP2 && !isTLS && !httpRequest.bodyPending && !httpRequest.streamBody {
+			settings, ok := httpRequest.getHTTP2Upgrade()
 			if ok {
-				tcpConn.SetKeepAlive(true)
-				tcpConn.SetKeepAlivePeriod(time.Duration(timeout) * time.Second)
-				tcpConn.SetReadDeadline(time.Now().Add(time.Duration(timeout) * time.Second))
+				_, err = io.WriteString(ClientConnection, "HTTP/1.1 101 Switching Protocols" + HEADER_LINE_SEPERATOR + "Connection: Upgrade" + HEADER_LINE_SEPERATOR + "Upgrade: " + HTTP2_CLEARTEXT_PROTOCOL + HEADER_LINE_SEPERATOR + HEADER_LINE_SEPERATOR)
+				if err != nil {
+					return 0, true, err
+				}
+				srv.serveHTTP2(ClientConnection, reader, httpRequest, matchedRoute, matchErr, settings)
+				return 0, true, nil
 			}
-
-			httpResponse.Headers.Add("Connection", "keep-alive")
-			httpResponse.Headers.Add("Keep-Alive", fmt.Sprintf("timeout=%d, max=%d", timeout, max))
 		}
 
-		if !IsMethodAllowed(httpResponse.Version, strings.ToUpper(strings.TrimSpace(httpRequest.Method))) {
-			httpResponse.Status(Status405)
-			ErrorHandler(httpRequest, httpResponse)
+		httpRequest.Locals["Started"] = time.Now()
+		httpResponse := srv.NewResponse(ClientConnection, httpRequest)
+		var idleTimeout time.Duration
+		// The connection is kept open only if the client wants a persistent connection, the request limit of the connection has not been reached and the server is not shutting down.
+		keepAlive := httpRequest.isPersistent() && (srv.MaxRequestsPerConnection <= 0 || requestCount < srv.MaxRequestsPerConnection) && !srv.isShuttingDown()
+		if !keepAlive {
+			httpResponse.Headers["Connection"] = []string{ "close" }
 		} else {
-			// First stage of execution will implement all server level middlewares configured.
-			if len(srv.middlewares) > 0 {
-				responseSent := srv.processMiddlewares(httpRequest, httpResponse, srv.middlewares)
-				if responseSent {
-					srv.logStatus(httpRequest, httpResponse)
-					return timeout, nil
-				}
+			idleTimeout = srv.IdleTimeout
+			if srv.AdaptiveKeepAlive {
+				currCount := srv.cw.GetCount()
+				idleTimeout = srv.getKeepAliveHeuristic(currCount)
+				srv.Log(fmt.Sprintf("The timeout value returned by heuristic is %s for active connection count %d", idleTimeout, currCount), INFO_LEVEL)
 			}
 
-			// Next match the request route with the route tree to find the corresponding route handler.
-			matchedRoute, err := srv.Router.Match(httpRequest)
-			if err != nil {
-				srv.Log(err.Error(), ERROR_LEVEL)
-				httpResponse.Status(Status404)
-				ErrorHandler(httpRequest, httpResponse)
-			} else {
-				// After match is fetched, process the route level middlewares.
-				if len(matchedRoute.Middlewares) > 0 {
-					responseSent := srv.processMiddlewares(httpRequest, httpResponse, matchedRoute.Middlewares)
-					if responseSent {
-						srv.logStatus(httpRequest, httpResponse)
-						return timeout, nil
-					}
+			kaConn, ok := getKeepAliveConnection(ClientConnection)
+			if ok && idleTimeout > 0 {
+				kaConn.SetKeepAlive(true)
+				kaConn.SetKeepAlivePeriod(idleTimeout)
+			}
+
+			// HTTP/1.1 connections are persistent by default, whereas HTTP/1.0 clients must be told that the connection is kept open.
+			if strings.EqualFold(httpResponse.Version, "1.0") {
+				httpResponse.Headers.Add("Connection", "keep-alive")
+			}
+			keepAliveValue := make([]string, 0)
+			if idleTimeout > 0 {
+				keepAliveValue = append(keepAliveValue, fmt.Sprintf("timeout=%d", int(math.Ceil(idleTimeout.Seconds()))))
+			}
+			if srv.MaxRequestsPerConnection > 0 {
+				keepAliveValue = append(keepAliveValue, fmt.Sprintf("max=%d", srv.MaxRequestsPerConnection - requestCount))
+			}
+			if len(keepAliveValue) > 0 {
+				httpResponse.Headers.Add("Keep-Alive", strings.Join(keepAliveValue, ", "))
+			}
+		}
+
+		// The client waits for "100 Continue" before sending the body, which is sent only once the server and route middlewares have accepted the request.
+		// If the request is answered before its body is read, the connection is closed since the client may or may not send the body.
+		if httpRequest.bodyPending {
+			connHeader, hasConnHeader := httpResponse.Headers["Connection"]
+			httpResponse.Headers["Connection"] = []string{ "close" }
+			httpRequest.sendContinue = func() error {
+				if httpResponse.headersSent {
+					resError := new(ResponseError)
+					resError.Section = "StatusLine"
+					resError.Value = "100 Continue"
+					resError.Message = "The interim response cannot be sent once the final response has been sent"
+					return resError
 				}
 
-				// Finally execute the handler for the matched route.
-				matchedRoute.RouteHandler(httpRequest, httpResponse)
+				if hasConnHeader {
+					httpResponse.Headers["Connection"] = connHeader
+				} else {
+					delete(httpResponse.Headers, "Connection")
+				}
+				_, err := io.WriteString(ClientConnection, "HTTP/1.1 100 Continue" + HEADER_LINE_SEPERATOR + HEADER_LINE_SEPERATOR)
+				return err
 			}
 		}
 
-		srv.logStatus(httpRequest, httpResponse)
-		return timeout, nil
+		err = srv.processRequest(ClientConnection, httpRequest, httpResponse, matchedRoute, matchErr)
+		if !httpResponse.hijacked && err == nil {
+			httpRequest.discardBody()
+		}
+		if httpResponse.hijacked {
+			hijacked = true
+			srv.Log(fmt.Sprintf("Client connection [%s] has been hijacked by the handler for [%s %s]", ClientConnection.RemoteAddr().String(), httpRequest.Method, httpRequest.ResourcePath), INFO_LEVEL)
+			return 0, true, nil
+		}
+		if err != nil {
+			return 0, true, err
+		}
+		return idleTimeout, !keepAlive || httpResponse.closeConnection() || httpRequest.bodyErr != nil, nil
 	}
 
-	timer := time.NewTimer(0)
-	defer timer.Stop()
+	reader := bufio.NewReader(ClientConnection)
+	requestCount := 0
+	var idleTimeout time.Duration
 
 	for {
-		timeout, err := handleRequest();
-		_, ok := err.(*ReadTimeoutError)
-		if err != io.EOF && !ok {
-			if !timer.Stop() {
-				<-timer.C
+		// The connection is marked idle while waiting for the next request, so that a graceful shutdown can close it.
+		srv.cw.SetIdle(ClientConnection, true)
+		if srv.isShuttingDown() {
+			srv.Log("Server shutdown initiated :: Closing client connection - " + ClientConnection.RemoteAddr().String(), WARN_LEVEL)
+			return
+		}
+
+		// The first request must start arriving within the read header timeout, while the subsequent requests are governed by the idle timeout.
+		waitTimeout := idleTimeout
+		if requestCount == 0 {
+			waitTimeout = srv.ReadHeaderTimeout
+		}
+		if waitTimeout > 0 {
+			ClientConnection.SetReadDeadline(time.Now().Add(waitTimeout))
+		} else {
+			ClientConnection.SetReadDeadline(time.Time{})
+		}
+
+		_, err := reader.Peek(1)
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				srv.Log(fmt.Sprintf("Client connection [%s] has timed out.", ClientConnection.RemoteAddr().String()), INFO_LEVEL)
 			}
-			timer.Reset(time.Duration(timeout) * time.Second)
-			srv.Log(fmt.Sprintf("Timeout for client connection [%s] has been changed to %d seconds.", ClientConnection.RemoteAddr().String(), timeout), WARN_LEVEL)
+			return
 		}
 
-		select {
-		case <-srv.shutdown:
-			srv.Log("Server shutdown initiated :: Closing client connection - " + ClientConnection.RemoteAddr().String(), WARN_LEVEL)
+		// Pipelined requests are already buffered in the reader, and are processed one after the other so that the responses are sent in the order the requests were received.
+		srv.cw.SetIdle(ClientConnection, false)
+		if requestCount == 0 && srv.EnableHTTP2 && !isTLS && srv.hasHTTP2Preface(reader) {
+			srv.serveHTTP2(ClientConnection, reader, nil, nil, nil, nil)
 			return
-		case <-timer.C:
-			srv.Log(fmt.Sprintf("Client connection [%s] has timed out.", ClientConnection.RemoteAddr().String()), INFO_LEVEL)
+		}
+		requestCount += 1
+		timeout, closeConn, err := handleRequest(reader, requestCount)
+		if err != nil || closeConn {
 			return
-		default:
 		}
+		idleTimeout = timeout
+	}
+}
+
+// Executes the server middlewares, the route middlewares and the handler of the route matched for the given request, and completes the response.
+// It returns an error if the deferred body of the request could not be read, in which case an error response has already been sent to the client.
+func (srv *HttpServer) processRequest(conn net.Conn, httpRequest *HttpRequest, httpResponse *HttpResponse, matchedRoute *Route, matchErr error) error {
+	if !srv.isMethodAllowed(httpResponse.Version, httpRequest.Method) {
+		methodErr := new(RoutingError)
+		methodErr.RoutePath = httpRequest.ResourcePath
+		methodErr.Message = fmt.Sprintf("processRequest: The HTTP method [%s] is not allowed for the server", httpRequest.Method)
+		methodErr.AllowedMethods = getServerMethods(httpRequest)
+		srv.getRouter(httpRequest).sendMatchError(httpRequest, httpResponse, methodErr)
+	} else {
+		// First stage of execution will implement all server level middlewares configured, followed by the middlewares of the virtual host serving the request.
+		middlewares := srv.middlewares
+		if httpRequest.virtualHost != nil {
+			middlewares = slices.Concat(srv.middlewares, httpRequest.virtualHost.middlewares)
+		}
+		if len(middlewares) > 0 {
+			responseSent := srv.processMiddlewares(httpRequest, httpResponse, middlewares)
+			if httpResponse.hijacked {
+				return nil
+			}
+			if responseSent {
+				srv.completeResponse(httpResponse)
+				srv.logStatus(httpRequest, httpResponse)
+				return nil
+			}
+		}
+
+		// Next use the route matched with the route tree to find the corresponding route handler.
+		if matchErr != nil {
+			srv.Log(matchErr.Error(), ERROR_LEVEL)
+			srv.getRouter(httpRequest).sendMatchError(httpRequest, httpResponse, matchErr)
+		} else {
+			// After match is fetched, process the route level middlewares.
+			if len(matchedRoute.Middlewares) > 0 {
+				responseSent := srv.processMiddlewares(httpRequest, httpResponse, matchedRoute.Middlewares)
+				if httpResponse.hijacked {
+					return nil
+				}
+				if responseSent {
+					srv.completeResponse(httpResponse)
+					srv.logStatus(httpRequest, httpResponse)
+					return nil
+				}
+			}
+
+			// Finally read the request body, if it was deferred, and execute the handler for the matched route. Streamed request bodies are read by the handler instead.
+			var err error
+			if !httpRequest.streamBody {
+				err = httpRequest.LoadBody()
+			}
+			if err != nil {
+				if err != io.EOF {
+					srv.Log(err.Error(), ERROR_LEVEL)
+				}
+				srv.respondToReadError(conn, httpRequest, err)
+				return err
+			}
+			matchedRoute.RouteHandler(httpRequest, httpResponse)
+			if httpResponse.hijacked {
+				return nil
+			}
+		}
+	}
+
+	srv.completeResponse(httpResponse)
+	srv.logStatus(httpRequest, httpResponse)
+	return nil
+}
+
+// Answers a request that could not be read completely with an error response, after which the connection is closed since the message framing can no longer be trusted.
+// Malformed requests are answered with the status code of the parsing error, while requests that were not received completely in time are answered with "408 - Request Timeout".
+func (srv *HttpServer) respondToReadError(conn net.Conn, request *HttpRequest, err error) {
+	parseErr, ok := err.(*RequestParseError)
+	_, isTimeout := err.(*ReadTimeoutError)
+	if !ok && !isTimeout {
+		return
+	}
+
+	httpResponse := srv.NewResponse(conn, request)
+	httpResponse.Headers.Add("Connection", "close")
+	if ok {
+		httpResponse.Status(parseErr.GetStatus())
+	} else {
+		httpResponse.Status(Status408)
 	}
+	ErrorHandler(request, httpResponse)
+	srv.logStatus(request, httpResponse)
 }
 
-// Server's Keep-Alive heuristic which returns the timeout value and the maximum number of requests that can be processed by a single connection.
-func (srv *HttpServer) getKeepAliveHeuristic(connCount int) (int, int) {
+// Completes the given response if its body was streamed by the route handler and was not completed by it.
+func (srv *HttpServer) completeResponse(response *HttpResponse) {
+	if response.headersSent && !response.finished {
+		err := response.End()
+		if err != nil {
+			srv.Log(err.Error(), ERROR_LEVEL)
+		}
+	}
+}
+
+// Server's Keep-Alive heuristic which returns the duration for which an idle connection is kept open, based on the number of active connections.
+// It is used for the idle timeout instead of the configured value when adaptive keep-alive is enabled for the server.
+func (srv *HttpServer) getKeepAliveHeuristic(connCount int) time.Duration {
 	usableCPU := numCPU - 1
 	scalingFactor := 2.0
 	timeout := 15 / (1 + math.Exp(scalingFactor * float64(connCount - usableCPU)))
-	return int(math.Ceil(timeout)), 100
+	return time.Duration(math.Ceil(timeout)) * time.Second
 }
 
 // Terminate all the active connections with the server before shutting down the server instance.
-func (srv *HttpServer) terminate() {
+// Idle connections are closed right away while connections processing a request are allowed to complete, until the given context expires.
+func (srv *HttpServer) terminate(ctx context.Context) error {
 	srv.Log("Server shutdown signal received...", INFO_LEVEL)
+	srv.Log("Server Shutdown :: All existing connections are being terminated.", WARN_LEVEL)
+	srv.signalShutdown()
+	closeErr := srv.close()
+
 	terminateDone := make(chan struct{})
 	go func () {
-		srv.Log("Server Shutdown :: All existing connections are being terminated.", WARN_LEVEL)
-		close(srv.shutdown)
-		srv.close()
 		srv.wg.Wait()
 		close(terminateDone)
 	}()
 
-	srvShutTimeout := GetServerDefaults("shutdown_timeout").(int)
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
 
-	select {
-	case <-terminateDone:
-		srv.Log("Server Shutdown :: All active connections have been terminated successfully.", INFO_LEVEL)
-		return
-	case <-time.After(time.Duration(srvShutTimeout) * time.Second):
-		srv.Log("Server Shutdown Timeout :: Not all active connection(s) were terminated successfully.", ERROR_LEVEL)
-		return
+	for {
+		srv.cw.CloseIdle()
+		select {
+		case <-terminateDone:
+			srv.Log("Server Shutdown :: All active connections have been terminated successfully.", INFO_LEVEL)
+			return closeErr
+		case <-ctx.Done():
+			srv.Log("Server Shutdown Timeout :: Not all active connection(s) were terminated successfully.", ERROR_LEVEL)
+			return ctx.Err()
+		case <-ticker.C:
+		}
 	}
 }
 
@@ -312,10 +707,25 @@ func (srv *HttpServer) logStatus(request *HttpRequest, response *HttpResponse) {
 
 // Creates and returns pointer to a new instance of HTTP request.
 func (srv *HttpServer) NewRequest(Connection net.Conn) *HttpRequest {
+	return srv.newRequest(Connection, Connection)
+}
+
+// Creates and returns pointer to a new instance of HTTP request which reads the request message from the given reader.
+// The reader is shared by all the requests received on the same connection so that bytes buffered for the next request are not lost.
+func (srv *HttpServer) newRequest(Connection net.Conn, reader io.Reader) *HttpRequest {
 	var httpRequest HttpRequest
-	httpRequest.Initialize(Connection)
+	httpRequest.Initialize(reader)
 	httpRequest.ClientAddress = Connection.RemoteAddr().String()
+	httpRequest.PeerAddress = httpRequest.ClientAddress
+	httpRequest.clientIP = getRemoteIP(Connection)
 	httpRequest.Server = srv
+	httpRequest.conn = Connection
+	httpRequest.limits = srv.getHeadLimits()
+	httpRequest.ProxyHeader = getProxyHeader(Connection)
+	tlsConn, ok := Connection.(*tls.Conn)
+	if ok {
+		httpRequest.TLS = newTLSInfo(tlsConn.ConnectionState())
+	}
 	return &httpRequest
 }
 
@@ -324,6 +734,9 @@ func (srv *HttpServer) NewResponse(Connection net.Conn, request *HttpRequest) *H
 	var httpResponse HttpResponse
 	httpResponse.Initialize(GetResponseVersion(request.Version), Connection)
 	httpResponse.Server = srv
+	httpResponse.conn = Connection
+	httpResponse.request = request
+	httpResponse.noBody = strings.EqualFold(request.Method, "HEAD")
 	return &httpResponse
 }
 
@@ -342,36 +755,359 @@ func (srv *HttpServer) SetLogger(logFormat string) {
 	}
 }
 
+// Allows the given extension methods, like "PROPFIND" or "MKCOL" for WebDAV, for the requests received by the server over HTTP/1.0 and later versions.
+// Requests using methods that are neither supported for their HTTP version nor allowed for the server are answered with "405 - Method Not Allowed".
+func (srv *HttpServer) AllowMethods(Methods ...string) error {
+	for _, method := range Methods {
+		method = strings.ToUpper(strings.TrimSpace(method))
+		if !isToken(method) || method == ANY_METHOD {
+			reqError := new(CustomError)
+			reqError.Message = fmt.Sprintf("AllowMethods: [%s] is not a valid HTTP method", method)
+			return reqError
+		}
+		if !slices.Contains(srv.extensionMethods, method) {
+			srv.extensionMethods = append(srv.extensionMethods, method)
+		}
+	}
+	return nil
+}
+
+// Sets the proxies trusted to send the "Forwarded" and "X-Forwarded-*" headers, given as networks in CIDR notation like "10.0.0.0/8" or as single IP addresses.
+// The client IP address, scheme, host and port of the requests sent by these proxies are taken from the headers, and the resolved client address is used in the request logs.
+// Headers sent by any other client are ignored, so that they cannot spoof their address.
+func (srv *HttpServer) SetTrustedProxies(Proxies ...string) error {
+	trustedProxies, err := parseNetworks(Proxies)
+	if err != nil {
+		return err
+	}
+	srv.trustedProxies = trustedProxies
+	return nil
+}
+
+// Checks if the given IP address belongs to one of the trusted proxies of the server.
+func (srv *HttpServer) isTrustedProxy(ip string) bool {
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil {
+		return false
+	}
+	for _, network := range srv.trustedProxies {
+		if network.Contains(parsedIP) {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks if the given HTTP method is supported for the given version or allowed as an extension method for the server.
+func (srv *HttpServer) isMethodAllowed(version string, method string) bool {
+	method = strings.ToUpper(strings.TrimSpace(method))
+	if IsMethodAllowed(version, method) {
+		return true
+	}
+	return !strings.EqualFold(strings.TrimSpace(version), "0.9") && slices.Contains(srv.extensionMethods, method)
+}
+
+// Gets the list of HTTP methods supported for the given version, along with the extension methods allowed for the server.
+func (srv *HttpServer) getAllowedMethods(version string) string {
+	allowedMethods := GetAllowedMethods(version)
+	if len(srv.extensionMethods) == 0 || strings.EqualFold(strings.TrimSpace(version), "0.9") {
+		return allowedMethods
+	}
+	return strings.Join(append([]string{ allowedMethods }, srv.extensionMethods...), ", ")
+}
+
 // Adds a server level middleware to the server instance.
 func (srv *HttpServer) Use(middleware Middleware) {
 	srv.middlewares = append(srv.middlewares, middleware)
 }
 
 // Setup the web server instance to listen for incoming HTTP requests at the given hostname and port number.
-func (srv * HttpServer) Listen() {
-	serverAddress := fmt.Sprintf("%s:%d", srv.HostAddress, srv.PortNumber)
-	server, err := net.Listen("tcp", serverAddress)
+// This function blocks until the process receives an interrupt or termination signal, after which the server is gracefully shutdown. On receiving a SIGHUP or SIGUSR2 signal, the server is upgraded using Upgrade() instead.
+// To control the lifecycle of the server from code, use Start() and Shutdown() instead.
+func (srv * HttpServer) Listen() error {
+	err := srv.Start()
 	if err != nil {
 		srv.Log(fmt.Sprintf("Error occurred while setting up listener socket: %s", err.Error()), ERROR_LEVEL)
-		return
+		return err
 	}
 
-	srv.listener = server
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	srv.Log("To terminate the server, press Ctrl + C", WARN_LEVEL)
+	return srv.ShutdownOnSignal()
+}
 
-	if srv.shutdown == nil {
-		srv.shutdown = make(chan struct{})
+// Setup the web server instance to listen for incoming HTTPS requests at the given hostname and port number.
+// The certificate and key files given are added to the certificates already configured for the server instance using AddCertificate(). Both the file paths can be empty if the certificates have already been configured or if the TLSConfig field of the server has been set.
+// Certificate files are checked for changes periodically and reloaded without restarting the server.
+// This function blocks until the process receives an interrupt or termination signal, after which the server is gracefully shutdown.
+func (srv *HttpServer) ListenTLS(certFile string, keyFile string) error {
+	err := srv.StartTLS(certFile, keyFile)
+	if err != nil {
+		srv.Log(fmt.Sprintf("Error occurred while setting up listener socket: %s", err.Error()), ERROR_LEVEL)
+		return err
 	}
 
-	srv.cw = new(ConnectionWatcher)
-	srv.Log(fmt.Sprintf("Web server is listening at http://%s", serverAddress), WARN_LEVEL)
 	srv.Log("To terminate the server, press Ctrl + C", WARN_LEVEL)
-	srv.wg.Add(1)
-	go srv.acceptConnections()
-	<-sigChan
-	srv.terminate()
-	close(sigChan)
+	return srv.ShutdownOnSignal()
+}
+
+// Binds the web server instance to the configured hostname and port number and starts accepting HTTP requests in the background.
+// The hostname can also refer to a Unix domain socket, like "unix:/run/proteus.sock", or an inherited file descriptor, like "fd:3", in which case the port number is ignored.
+// If the process was started by an upgrade, the listener handed over by the previous process for the same address is used instead of creating a new one.
+// It returns an error if the listener socket could not be created. This function does not block.
+func (srv *HttpServer) Start() error {
+	listener, err := srv.listen()
+	if err != nil {
+		return err
+	}
+
+	listener, err = srv.wrapListener(listener)
+	if err != nil {
+		return err
+	}
+
+	srv.startServing(listener, "http")
+	return nil
+}
+
+// Binds the web server instance to the configured hostname and port number and starts accepting HTTPS requests in the background.
+// It returns an error if the TLS configuration is invalid or if the listener socket could not be created. This function does not block.
+func (srv *HttpServer) StartTLS(certFile string, keyFile string) error {
+	tlsConfig, err := srv.getTLSConfig(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+
+	listener, err := srv.listen()
+	if err != nil {
+		return err
+	}
+
+	listener, err = srv.wrapListener(listener)
+	if err != nil {
+		return err
+	}
+
+	srv.watchCertificates()
+	srv.startServing(tls.NewListener(listener, tlsConfig), "https")
+	return nil
+}
+
+// Accepts incoming HTTP connections from the given listener.
+// This function blocks until the server is shutdown or closed, after which it returns ServerClosedError. Any other error that prevents the listener from accepting connections is returned as-is.
+// It can be called more than once with different listeners for the server to accept connections from all of them.
+func (srv *HttpServer) Serve(listener net.Listener) error {
+	listener, err := srv.wrapListener(listener)
+	if err != nil {
+		return err
+	}
+
+	if !srv.addListener(listener) {
+		listener.Close()
+		return &ServerClosedError{}
+	}
+	return srv.serve(listener, "http")
+}
+
+// Accepts incoming HTTPS connections from the given listener.
+// The certificate and key files are handled the same way as ListenTLS(). This function blocks until the server is shutdown or closed, after which it returns ServerClosedError.
+func (srv *HttpServer) ServeTLS(listener net.Listener, certFile string, keyFile string) error {
+	tlsConfig, err := srv.getTLSConfig(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+
+	listener, err = srv.wrapListener(listener)
+	if err != nil {
+		return err
+	}
+
+	tlsListener := tls.NewListener(listener, tlsConfig)
+	if !srv.addListener(tlsListener) {
+		tlsListener.Close()
+		return &ServerClosedError{}
+	}
+	srv.watchCertificates()
+	return srv.serve(tlsListener, "https")
+}
+
+// Gracefully shuts down the server instance. The listeners are closed first so that no new connections are accepted, idle connections are closed and connections processing a request are allowed to complete.
+// If the given context expires before all the connections are closed, the context's error is returned.
+func (srv *HttpServer) Shutdown(ctx context.Context) error {
+	return srv.terminate(ctx)
+}
+
+// Immediately closes all the listeners and active connections of the server instance, without waiting for requests being processed to complete.
+// To shutdown the server gracefully, use Shutdown() instead.
+func (srv *HttpServer) Close() error {
+	srv.signalShutdown()
+	err := srv.close()
+	srv.cw.CloseAll()
+	return err
+}
+
+// Blocks until one of the given signals is received by the process and then gracefully shuts down the server instance.
+// If no signals are given, the server is shutdown on receiving an interrupt (SIGINT) or termination (SIGTERM) signal, and upgraded using Upgrade() on receiving a hangup (SIGHUP) or user-defined (SIGUSR2) signal on platforms that support upgrades.
+// If an upgrade fails, the error is logged and the server continues waiting for a signal. The time given for the active connections to complete is determined by the "shutdown_timeout" server default.
+// It returns right away if the server is shutdown by other means while waiting for a signal.
+func (srv *HttpServer) ShutdownOnSignal(signals ...os.Signal) error {
+	if len(signals) == 0 {
+		signals = append([]os.Signal{ os.Interrupt, syscall.SIGTERM }, upgradeSignals...)
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, signals...)
+	defer signal.Stop(sigChan)
+
+	for {
+		select {
+		case sig := <-sigChan:
+			if slices.Contains(upgradeSignals, sig) {
+				srv.Log(fmt.Sprintf("Server upgrade signal [%s] received...", sig.String()), INFO_LEVEL)
+				err := upgrader.run()
+				if err != nil {
+					srv.Log(err.Error(), ERROR_LEVEL)
+					continue
+				}
+				srv.Log("Server upgrade :: The listeners have been taken over by the new process.", INFO_LEVEL)
+			}
+		case <-srv.shutdown:
+			return nil
+		}
+		break
+	}
+
+	srvShutTimeout := GetServerDefaults("shutdown_timeout").(int)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(srvShutTimeout) * time.Second)
+	defer cancel()
+	return srv.Shutdown(ctx)
+}
+
+// Returns the address, made of the hostname and port number, to which the server instance is bound.
+// If the hostname refers to a Unix domain socket or an inherited file descriptor, the hostname is returned as-is.
+func (srv *HttpServer) address() string {
+	if strings.HasPrefix(srv.HostAddress, UNIX_ADDRESS_PREFIX) || strings.HasPrefix(srv.HostAddress, FD_ADDRESS_PREFIX) {
+		return srv.HostAddress
+	}
+	return net.JoinHostPort(srv.HostAddress, strconv.Itoa(srv.PortNumber))
+}
+
+// Adds the certificate and key pair available in the given files to the list of certificates presented by the server to HTTPS clients.
+// When multiple certificates are added, the certificate is selected using the server name sent by the client. The first certificate added is used if no other certificate matches.
+func (srv *HttpServer) AddCertificate(certFile string, keyFile string) error {
+	if srv.certificates == nil {
+		srv.certificates = new(CertificateStore)
+	}
+	return srv.certificates.AddCertificate(certFile, keyFile)
+}
+
+// Returns the TLS configuration to be used by the server for accepting HTTPS connections.
+func (srv *HttpServer) getTLSConfig(certFile string, keyFile string) (*tls.Config, error) {
+	if strings.TrimSpace(certFile) != "" || strings.TrimSpace(keyFile) != "" {
+		err := srv.AddCertificate(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var tlsConfig *tls.Config
+	if srv.TLSConfig != nil {
+		tlsConfig = srv.TLSConfig.Clone()
+	} else {
+		tlsConfig = &tls.Config{ MinVersion: tls.VersionTLS12 }
+	}
+
+	if srv.certificates != nil && srv.certificates.Length() > 0 && tlsConfig.GetCertificate == nil {
+		tlsConfig.GetCertificate = srv.certificates.GetCertificate
+	}
+
+	if len(tlsConfig.Certificates) == 0 && tlsConfig.GetCertificate == nil && tlsConfig.GetConfigForClient == nil {
+		return nil, &CustomError{ Message: "No certificates have been configured for the server to accept HTTPS connections" }
+	}
+
+	// HTTP/2 is preferred over HTTP/1.1 when the client supports both.
+	if srv.EnableHTTP2 && !slices.Contains(tlsConfig.NextProtos, HTTP2_ALPN_PROTOCOL) {
+		tlsConfig.NextProtos = append([]string{ HTTP2_ALPN_PROTOCOL }, tlsConfig.NextProtos...)
+	}
+	if !slices.Contains(tlsConfig.NextProtos, "http/1.1") {
+		tlsConfig.NextProtos = append(tlsConfig.NextProtos, "http/1.1")
+	}
+
+	return tlsConfig, nil
+}
+
+// Starts a background goroutine that periodically reloads the server certificates whose files have changed, until the server is shutdown.
+// The goroutine is started only once, regardless of the number of HTTPS listeners served by the server instance.
+func (srv *HttpServer) watchCertificates() {
+	if srv.certificates == nil || srv.certificates.Length() == 0 {
+		return
+	}
+
+	srv.watchOnce.Do(func() {
+		srv.wg.Add(1)
+		go func() {
+			defer srv.wg.Done()
+
+			reloadInterval := GetServerDefaults("certificate_reload_interval").(int)
+			ticker := time.NewTicker(time.Duration(reloadInterval) * time.Second)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-srv.shutdown:
+					return
+				case <-ticker.C:
+					err := srv.certificates.Reload()
+					if err != nil {
+						srv.Log(fmt.Sprintf("Error occurred while reloading server certificates: %s", err.Error()), ERROR_LEVEL)
+					}
+				}
+			}
+		}()
+	})
+}
+
+// Starts accepting connections from the given listener in the background. Errors raised by the listener are logged to the server log stream.
+func (srv *HttpServer) startServing(listener net.Listener, scheme string) {
+	if !srv.addListener(listener) {
+		listener.Close()
+		return
+	}
+
+	go func() {
+		err := srv.serve(listener, scheme)
+		var closedErr *ServerClosedError
+		if err != nil && !errors.As(err, &closedErr) {
+			srv.Log(fmt.Sprintf("Error occurred while accepting connections: %s", err.Error()), ERROR_LEVEL)
+		}
+	}()
+}
+
+// Wraps the given listener to read the PROXY protocol header sent at the start of each connection, if the server accepts PROXY protocol headers.
+// The given listener is closed if the trusted sources configured for the server are not valid.
+func (srv *HttpServer) wrapListener(listener net.Listener) (net.Listener, error) {
+	if !srv.ProxyProtocol {
+		return listener, nil
+	}
+
+	ppListener, err := NewProxyProtocolListener(listener, srv.ProxyProtocolSources)
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+	ppListener.(*proxyProtocolListener).logger = srv.Log
+	return ppListener, nil
+}
+
+// Accepts connections from the given listener, which must have already been added to the server's list of listeners using addListener().
+// This function blocks until the listener stops accepting connections.
+func (srv *HttpServer) serve(listener net.Listener, scheme string) error {
+	defer srv.wg.Done()
+	if strings.EqualFold(listener.Addr().Network(), "unix") {
+		srv.Log(fmt.Sprintf("Web server is listening for %s requests at Unix domain socket %s", strings.ToUpper(scheme), listener.Addr().String()), WARN_LEVEL)
+	} else {
+		srv.Log(fmt.Sprintf("Web server is listening at %s://%s", scheme, listener.Addr().String()), WARN_LEVEL)
+	}
+	return srv.acceptConnections(listener)
 }
 
 // Logs the given message and classification to the server log stream.
@@ -0,0 +1,217 @@
+package internal
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Structure to represent the details of the TLS session negotiated for a HTTPS request.
+type TLSInfo struct {
+	// TLS protocol version negotiated with the client like "TLS 1.2" or "TLS 1.3".
+	Version string
+	// Name of the cipher suite negotiated for the connection.
+	CipherSuite string
+	// Server name (SNI) sent by the client during the handshake. It is empty if the client did not send one.
+	ServerName string
+	// Application protocol negotiated using ALPN. It is empty if no protocol was negotiated.
+	NegotiatedProtocol string
+}
+
+// Creates a new TLSInfo instance from the given connection state and returns a reference to the instance.
+func newTLSInfo(state tls.ConnectionState) *TLSInfo {
+	info := new(TLSInfo)
+	info.Version = tls.VersionName(state.Version)
+	info.CipherSuite = tls.CipherSuiteName(state.CipherSuite)
+	info.ServerName = state.ServerName
+	info.NegotiatedProtocol = state.NegotiatedProtocol
+	return info
+}
+
+// Structure to hold a single certificate and key pair loaded from the local file system.
+type certificateEntry struct {
+	// Path of the PEM encoded certificate file.
+	certFile string
+	// Path of the PEM encoded private key file.
+	keyFile string
+	// Last modified time of the certificate file when it was last loaded.
+	certModified time.Time
+	// Last modified time of the key file when it was last loaded.
+	keyModified time.Time
+	// The parsed certificate and key pair.
+	certificate *tls.Certificate
+	// List of host names (lowercase) the certificate is valid for.
+	names []string
+}
+
+// Loads the certificate and key pair from the files referenced by the entry and updates the entry with the parsed values.
+func (ce *certificateEntry) load() error {
+	certStat, err := os.Stat(ce.certFile)
+	if err != nil {
+		fsfErr := new(FileSystemError)
+		fsfErr.TargetPath = ce.certFile
+		fsfErr.Message = fmt.Sprintf("Error occurred while fetching certificate file stats: %s", err.Error())
+		return fsfErr
+	}
+
+	keyStat, err := os.Stat(ce.keyFile)
+	if err != nil {
+		fsfErr := new(FileSystemError)
+		fsfErr.TargetPath = ce.keyFile
+		fsfErr.Message = fmt.Sprintf("Error occurred while fetching key file stats: %s", err.Error())
+		return fsfErr
+	}
+
+	certificate, err := tls.LoadX509KeyPair(ce.certFile, ce.keyFile)
+	if err != nil {
+		fsfErr := new(FileSystemError)
+		fsfErr.TargetPath = ce.certFile
+		fsfErr.Message = fmt.Sprintf("Error occurred while loading certificate and key pair: %s", err.Error())
+		return fsfErr
+	}
+
+	if certificate.Leaf == nil {
+		certificate.Leaf, err = x509.ParseCertificate(certificate.Certificate[0])
+		if err != nil {
+			fsfErr := new(FileSystemError)
+			fsfErr.TargetPath = ce.certFile
+			fsfErr.Message = fmt.Sprintf("Error occurred while parsing the certificate: %s", err.Error())
+			return fsfErr
+		}
+	}
+
+	names := make([]string, 0)
+	for _, dnsName := range certificate.Leaf.DNSNames {
+		names = append(names, strings.ToLower(strings.TrimSpace(dnsName)))
+	}
+	if len(names) == 0 && strings.TrimSpace(certificate.Leaf.Subject.CommonName) != "" {
+		names = append(names, strings.ToLower(strings.TrimSpace(certificate.Leaf.Subject.CommonName)))
+	}
+
+	ce.certificate = &certificate
+	ce.names = names
+	ce.certModified = certStat.ModTime()
+	ce.keyModified = keyStat.ModTime()
+	return nil
+}
+
+// Returns true if either the certificate file or the key file has been modified since the entry was last loaded.
+func (ce *certificateEntry) isModified() bool {
+	certStat, err := os.Stat(ce.certFile)
+	if err != nil {
+		return false
+	}
+	keyStat, err := os.Stat(ce.keyFile)
+	if err != nil {
+		return false
+	}
+	return !certStat.ModTime().Equal(ce.certModified) || !keyStat.ModTime().Equal(ce.keyModified)
+}
+
+// Structure to hold all the certificates configured for a server instance.
+// The certificate presented to a client is selected using the server name (SNI) sent by the client during the TLS handshake.
+// Certificate files are polled for changes and reloaded without restarting the server.
+type CertificateStore struct {
+	// Mutex to synchronize read-write activities on the list of certificates.
+	mu sync.RWMutex
+	// List of all certificates in the order in which they were added. The first certificate is used when no other certificate matches the server name.
+	entries []*certificateEntry
+}
+
+// Loads the certificate and key pair from the given files and adds it to the certificate store.
+func (cs *CertificateStore) AddCertificate(certFile string, keyFile string) error {
+	entry := new(certificateEntry)
+	entry.certFile = strings.TrimSpace(certFile)
+	entry.keyFile = strings.TrimSpace(keyFile)
+	err := entry.load()
+	if err != nil {
+		return err
+	}
+
+	cs.mu.Lock()
+	cs.entries = append(cs.entries, entry)
+	cs.mu.Unlock()
+	return nil
+}
+
+// Returns the number of certificates available in the certificate store.
+func (cs *CertificateStore) Length() int {
+	cs.mu.RLock()
+	count := len(cs.entries)
+	cs.mu.RUnlock()
+	return count
+}
+
+// Selects the certificate to be presented to the client based on the server name sent in the client hello message.
+// An exact name match is preferred over a wildcard match. If no certificate matches, the first certificate added to the store is returned.
+// This function can be used as the "GetCertificate" callback of a tls.Config instance.
+func (cs *CertificateStore) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	if len(cs.entries) == 0 {
+		return nil, &CustomError{ Message: "No certificates have been configured for the server" }
+	}
+
+	serverName := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(hello.ServerName), "."))
+	if serverName != "" {
+		for _, entry := range cs.entries {
+			for _, name := range entry.names {
+				if name == serverName {
+					return entry.certificate, nil
+				}
+			}
+		}
+
+		_, parentDomain, found := strings.Cut(serverName, ".")
+		if found {
+			wildcardName := "*." + parentDomain
+			for _, entry := range cs.entries {
+				for _, name := range entry.names {
+					if name == wildcardName {
+						return entry.certificate, nil
+					}
+				}
+			}
+		}
+	}
+
+	return cs.entries[0].certificate, nil
+}
+
+// Reloads all the certificates whose certificate or key files have changed since they were last loaded.
+// If a changed certificate cannot be loaded, the previously loaded certificate continues to be served and the error is returned.
+func (cs *CertificateStore) Reload() error {
+	cs.mu.RLock()
+	entries := make([]*certificateEntry, len(cs.entries))
+	copy(entries, cs.entries)
+	cs.mu.RUnlock()
+
+	var reloadErr error
+	for index, entry := range entries {
+		if !entry.isModified() {
+			continue
+		}
+
+		newEntry := new(certificateEntry)
+		newEntry.certFile = entry.certFile
+		newEntry.keyFile = entry.keyFile
+		err := newEntry.load()
+		if err != nil {
+			reloadErr = err
+			continue
+		}
+
+		cs.mu.Lock()
+		if index < len(cs.entries) && cs.entries[index] == entry {
+			cs.entries[index] = newEntry
+		}
+		cs.mu.Unlock()
+	}
+
+	return reloadErr
+}
@@ -0,0 +1,215 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Environment variable listing the listener sockets passed to the new process during an upgrade, as file descriptors along with their addresses, like "3=localhost:8080;4=unix:/run/proteus.sock".
+	UPGRADE_LISTENERS_ENV = "PROTEUS_UPGRADE_LISTENERS"
+	// Environment variable containing the file descriptor used by the new process to notify the old process once it has taken over all the listener sockets.
+	UPGRADE_READY_FD_ENV = "PROTEUS_UPGRADE_READY_FD"
+)
+
+// Listener socket created for the configured address of a server, which is handed over to the new process during an upgrade.
+type boundListener struct {
+	// Address of the server for which the listener was created.
+	address string
+	// The listener socket, before it is wrapped for TLS or the PROXY protocol.
+	listener net.Listener
+}
+
+// Structure to track the listener sockets inherited from the old process during an upgrade, until they are taken over by the servers of the new process.
+type inheritedSockets struct {
+	// Ensures that the inherited sockets are read from the environment only once.
+	once sync.Once
+	// Mutex to manage the access to the inherited sockets.
+	mu sync.Mutex
+	// File descriptors of the inherited sockets that have not been taken over yet, keyed by their address.
+	fds map[string]uintptr
+	// Pipe used to notify the old process once all the inherited sockets have been taken over. It is nil if the process was not started by an upgrade.
+	ready *os.File
+}
+
+// Structure to coordinate the upgrade of the process, which hands over the listeners of all its servers to a single new process.
+type processUpgrade struct {
+	// Mutex to manage the access to the servers and the current upgrade attempt.
+	mu sync.Mutex
+	// Servers of the process whose listeners are handed over during an upgrade.
+	servers map[*HttpServer]struct{}
+	// The upgrade attempt in progress or completed successfully, shared by all the servers receiving the upgrade signal. It is nil if no upgrade has been attempted or if the last attempt failed.
+	attempt *upgradeAttempt
+}
+
+// Result of an attempt to upgrade the process.
+type upgradeAttempt struct {
+	// Channel closed once the attempt has completed.
+	done chan struct{}
+	// Error raised while starting the new process, if any.
+	err error
+}
+
+// Listener sockets inherited by the process from the process it has replaced.
+var inherited = new(inheritedSockets)
+
+// Upgrade coordinator of the process.
+var upgrader = &processUpgrade{ servers: make(map[*HttpServer]struct{}) }
+
+// Reads the inherited listener sockets and the notification pipe from the environment, which are unset afterwards so that they are not inherited by child processes.
+func (is *inheritedSockets) load() {
+	listeners := os.Getenv(UPGRADE_LISTENERS_ENV)
+	readyFd := strings.TrimSpace(os.Getenv(UPGRADE_READY_FD_ENV))
+	os.Unsetenv(UPGRADE_LISTENERS_ENV)
+	os.Unsetenv(UPGRADE_READY_FD_ENV)
+
+	is.fds = make(map[string]uintptr)
+	for entry := range strings.SplitSeq(listeners, ";") {
+		fdString, address, found := strings.Cut(entry, "=")
+		if !found {
+			continue
+		}
+		fd, err := strconv.ParseUint(strings.TrimSpace(fdString), 10, 0)
+		if err == nil {
+			is.fds[address] = uintptr(fd)
+		}
+	}
+
+	fd, err := strconv.ParseUint(readyFd, 10, 0)
+	if err == nil && len(is.fds) > 0 {
+		is.ready = os.NewFile(uintptr(fd), UPGRADE_READY_FD_ENV)
+	}
+}
+
+// Takes over the listener socket inherited for the given address, if any. The boolean value returned is false if no socket was inherited for the address.
+// Once all the inherited sockets have been taken over, the old process is notified so that it can stop accepting connections.
+func (is *inheritedSockets) take(address string) (net.Listener, bool, error) {
+	is.once.Do(is.load)
+	is.mu.Lock()
+	defer is.mu.Unlock()
+
+	fd, ok := is.fds[address]
+	if !ok {
+		return nil, false, nil
+	}
+	delete(is.fds, address)
+	listener, err := ListenerFromFd(fd, address)
+	if err != nil {
+		return nil, true, err
+	}
+
+	if len(is.fds) == 0 && is.ready != nil {
+		is.ready.Write([]byte{ 1 })
+		is.ready.Close()
+		is.ready = nil
+	}
+	return listener, true, nil
+}
+
+// Adds the given server to the servers whose listeners are handed over during an upgrade.
+func (pu *processUpgrade) register(srv *HttpServer) {
+	pu.mu.Lock()
+	defer pu.mu.Unlock()
+	pu.servers[srv] = struct{}{}
+}
+
+// Removes the given server from the servers whose listeners are handed over during an upgrade.
+func (pu *processUpgrade) unregister(srv *HttpServer) {
+	pu.mu.Lock()
+	defer pu.mu.Unlock()
+	delete(pu.servers, srv)
+}
+
+// Starts a new process taking over the listeners of all the registered servers and waits until it has taken over all of them.
+// Servers receiving the upgrade signal at the same time share the same attempt, so that a single new process is started. Once an attempt has succeeded, it is not repeated.
+func (pu *processUpgrade) run() error {
+	pu.mu.Lock()
+	attempt := pu.attempt
+	if attempt == nil {
+		attempt = &upgradeAttempt{ done: make(chan struct{}) }
+		pu.attempt = attempt
+		servers := make([]*HttpServer, 0, len(pu.servers))
+		for srv := range pu.servers {
+			servers = append(servers, srv)
+		}
+
+		go func() {
+			listeners := make([]boundListener, 0)
+			for _, srv := range servers {
+				listeners = append(listeners, srv.getBoundListeners()...)
+			}
+			slices.SortFunc(listeners, func(first boundListener, second boundListener) int {
+				return strings.Compare(first.address, second.address)
+			})
+
+			attempt.err = startUpgradedProcess(listeners)
+			if attempt.err != nil {
+				pu.mu.Lock()
+				pu.attempt = nil
+				pu.mu.Unlock()
+			}
+			close(attempt.done)
+		}()
+	}
+	pu.mu.Unlock()
+
+	<-attempt.done
+	return attempt.err
+}
+
+// Creates the listener for the configured address of the server, taking over the listener inherited for the address if the process was started by an upgrade.
+// The listener is handed over to the new process when the server is upgraded.
+func (srv *HttpServer) listen() (net.Listener, error) {
+	address := srv.address()
+	listener, ok, err := inherited.take(address)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		srv.Log(fmt.Sprintf("Server upgrade :: The listener for [%s] has been taken over from the previous process", address), INFO_LEVEL)
+	} else {
+		listener, err = NewListener(address)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	srv.limu.Lock()
+	srv.boundListeners = append(srv.boundListeners, boundListener{ address: address, listener: listener })
+	srv.limu.Unlock()
+	upgrader.register(srv)
+	return listener, nil
+}
+
+// Returns the listeners created for the configured address of the server, which are handed over to the new process during an upgrade. No listeners are returned once the server has been closed.
+func (srv *HttpServer) getBoundListeners() []boundListener {
+	srv.limu.RLock()
+	defer srv.limu.RUnlock()
+	if srv.listClosed {
+		return nil
+	}
+	return slices.Clone(srv.boundListeners)
+}
+
+// Upgrades the process without refusing any connection, by starting a new instance of the executable that takes over the listeners created by Start() and StartTLS() for all the servers of the process.
+// The new process is started with the same arguments and environment, and must start listening on the same addresses. Once it has taken over all the listeners, the server is gracefully shutdown, while the new process accepts the new connections.
+// The time given for the new process to take over the listeners is determined by the "upgrade_timeout" server default, after which the new process is killed and the server continues to accept connections.
+func (srv *HttpServer) Upgrade() error {
+	err := upgrader.run()
+	if err != nil {
+		return err
+	}
+
+	srv.Log("Server upgrade :: The listeners have been taken over by the new process.", INFO_LEVEL)
+	srvShutTimeout := GetServerDefaults("shutdown_timeout").(int)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(srvShutTimeout) * time.Second)
+	defer cancel()
+	return srv.Shutdown(ctx)
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package internal
+
+import (
+	"os"
+)
+
+// Signals that upgrade the process when received while waiting in ShutdownOnSignal(). Upgrades are not supported on this platform.
+var upgradeSignals = []os.Signal{}
+
+// Upgrades are not supported on this platform, since listener sockets cannot be passed to a new process as inherited file descriptors.
+func startUpgradedProcess(listeners []boundListener) error {
+	return &CustomError{ Message: "Server upgrade :: Upgrading the process is not supported on this platform" }
+}
@@ -0,0 +1,103 @@
+//go:build unix
+
+package internal
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Signals that upgrade the process when received while waiting in ShutdownOnSignal().
+var upgradeSignals = []os.Signal{ syscall.SIGHUP, syscall.SIGUSR2 }
+
+// Starts a new instance of the executable, passing the given listeners as inherited file descriptors, and waits until the new process has taken over all of them.
+// The new process is killed if it exits or does not take over the listeners within the upgrade timeout.
+func startUpgradedProcess(listeners []boundListener) error {
+	if len(listeners) == 0 {
+		return &CustomError{ Message: "Server upgrade :: No listeners created by Start() or StartTLS() are available to be handed over" }
+	}
+
+	// The inherited file descriptors are numbered from 3 onwards, after the standard input, output and error streams.
+	files := make([]*os.File, 0, len(listeners) + 1)
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+	entries := make([]string, 0, len(listeners))
+	for index, bound := range listeners {
+		fileListener, ok := bound.listener.(interface{ File() (*os.File, error) })
+		if !ok {
+			return &CustomError{ Message: fmt.Sprintf("Server upgrade :: The listener for [%s] cannot be handed over", bound.address) }
+		}
+		file, err := fileListener.File()
+		if err != nil {
+			return &CustomError{ Message: fmt.Sprintf("Server upgrade :: The listener for [%s] cannot be handed over: %s", bound.address, err.Error()) }
+		}
+		files = append(files, file)
+		entries = append(entries, fmt.Sprintf("%d=%s", 3 + index, bound.address))
+	}
+
+	readyReader, readyWriter, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer readyReader.Close()
+	files = append(files, readyWriter)
+
+	// The path of the executable is looked up again, so that a new binary installed at the same path is started.
+	executable, err := exec.LookPath(os.Args[0])
+	if err != nil {
+		executable, err = os.Executable()
+		if err != nil {
+			return err
+		}
+	}
+
+	environment := make([]string, 0)
+	for _, variable := range os.Environ() {
+		if !strings.HasPrefix(variable, UPGRADE_LISTENERS_ENV + "=") && !strings.HasPrefix(variable, UPGRADE_READY_FD_ENV + "=") {
+			environment = append(environment, variable)
+		}
+	}
+	environment = append(environment, UPGRADE_LISTENERS_ENV + "=" + strings.Join(entries, ";"), UPGRADE_READY_FD_ENV + "=" + strconv.Itoa(3 + len(listeners)))
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = environment
+	cmd.ExtraFiles = files
+	err = cmd.Start()
+	if err != nil {
+		return &CustomError{ Message: fmt.Sprintf("Server upgrade :: The new process could not be started: %s", err.Error()) }
+	}
+	// The write end of the pipe is closed in this process, so that reading from the pipe fails if the new process exits without notifying.
+	readyWriter.Close()
+	files = files[:len(files) - 1]
+
+	upgradeTimeout := GetServerDefaults("upgrade_timeout").(int)
+	readyReader.SetReadDeadline(time.Now().Add(time.Duration(upgradeTimeout) * time.Second))
+	_, err = readyReader.Read(make([]byte, 1))
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return &CustomError{ Message: fmt.Sprintf("Server upgrade :: The new process [%d] did not take over the listeners: %s", cmd.Process.Pid, err.Error()) }
+	}
+	go cmd.Wait()
+
+	// The socket files of the Unix domain sockets are still used by the new process, and must not be removed when the listeners of this process are closed.
+	for _, bound := range listeners {
+		unixListener, ok := bound.listener.(*net.UnixListener)
+		if ok {
+			unixListener.SetUnlinkOnClose(false)
+		}
+	}
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 	"path"
+	"unicode"
 )
 
 // Returns the value for the given key from server default configuration values.
@@ -73,6 +74,19 @@ func IsMethodAllowed(version string, requestMethod string) bool {
 	return false
 }
 
+// Checks if the given value is a token as per RFC 9110, which is the syntax of HTTP methods and header names.
+func isToken(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, char := range value {
+		if char > unicode.MaxASCII || !(unicode.IsLetter(char) || unicode.IsDigit(char) || strings.ContainsRune("!#$%&'*+-.^_`|~", char)) {
+			return false
+		}
+	}
+	return true
+}
+
 // Returns the HTTP response version for the given request version value.
 func GetResponseVersion(requestVersion string) string {
 	return GetHighestVersion(requestVersion)
@@ -147,6 +161,24 @@ func NewServer(HostAddress string, PortNumber int) *HttpServer {
 	server.logFormat = COMMON_LOGGER
 	server.middlewares = make([]Middleware, 0)
 	server.Locals = make(map[string]any)
+	server.shutdown = make(chan struct{})
+	server.cw = new(ConnectionWatcher)
+	server.ReadHeaderTimeout = time.Duration(GetServerDefaults("read_header_timeout").(int)) * time.Second
+	server.ReadBodyTimeout = time.Duration(GetServerDefaults("read_body_timeout").(int)) * time.Second
+	server.WriteTimeout = time.Duration(GetServerDefaults("write_timeout").(int)) * time.Second
+	server.IdleTimeout = time.Duration(GetServerDefaults("idle_timeout").(int)) * time.Second
+	server.MaxRequestsPerConnection = GetServerDefaults("max_requests_per_connection").(int)
+	server.OverloadPolicy = OVERLOAD_REJECT
+	server.MaxQueuedConnections = GetServerDefaults("max_queued_connections").(int)
+	server.QueueTimeout = time.Duration(GetServerDefaults("queue_timeout").(int)) * time.Second
+	server.RetryAfter = time.Duration(GetServerDefaults("retry_after").(int)) * time.Second
+	server.Limits = RequestLimits{
+		MaxRequestLineLength: GetServerDefaults("max_request_line_length").(int),
+		MaxHeaderBytes: GetServerDefaults("max_header_bytes").(int),
+		MaxHeaderCount: GetServerDefaults("max_header_count").(int),
+		MaxBodySize: int64(GetServerDefaults("max_body_size").(int)),
+	}
+	server.EnableHTTP2 = true
 
 	return server
 }
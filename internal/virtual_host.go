@@ -0,0 +1,139 @@
+package internal
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Structure to contain a virtual host declared on the server, serving the requests sent for the host names matching its pattern using its own router.
+type VirtualHost struct {
+	// Host name or wildcard pattern matched with the host of the requests, like "api.example.com", "*.example.com", or "*" for the default virtual host.
+	Pattern string
+	// Router instance that contains all the routes and static paths served for the virtual host.
+	Router *Router
+	// Virtual host level middlewares executed for all requests to the virtual host, after the server level middlewares.
+	middlewares []Middleware
+}
+
+// Adds a virtual host level middleware to the virtual host.
+func (vh *VirtualHost) Use(middleware Middleware) {
+	vh.middlewares = append(vh.middlewares, middleware)
+}
+
+// Adds a new static route and target folder to the router of the virtual host.
+func (vh *VirtualHost) Static(RoutePath string, TargetPath string) error {
+	return vh.Router.Static(RoutePath, TargetPath)
+}
+
+// Declares a virtual host on the server, serving the requests sent for the host names matching the given pattern using the given router. If the router is nil, a new router is created.
+// The pattern is either an exact host name like "api.example.com", a wildcard pattern like "*.example.com" matching all the subdomains of "example.com", or "*" for the default virtual host.
+// Once a virtual host has been declared, the Router of the server is no longer used, and requests for host names not matching any virtual host are answered with "421 - Misdirected Request".
+func (srv *HttpServer) AddVirtualHost(Pattern string, router *Router) (*VirtualHost, error) {
+	pattern := normalizeHostName(Pattern)
+	if !isValidHostPattern(pattern) {
+		return nil, &CustomError{ Message: fmt.Sprintf("AddVirtualHost: [%s] is neither a host name nor a wildcard pattern", Pattern) }
+	}
+	if _, exists := srv.virtualHosts[pattern]; exists {
+		return nil, &CustomError{ Message: fmt.Sprintf("AddVirtualHost: A virtual host has already been declared for [%s]", pattern) }
+	}
+
+	if router == nil {
+		router = NewRouter()
+	}
+	virtualHost := new(VirtualHost)
+	virtualHost.Pattern = pattern
+	virtualHost.Router = router
+	virtualHost.middlewares = make([]Middleware, 0)
+	if srv.virtualHosts == nil {
+		srv.virtualHosts = make(map[string]*VirtualHost)
+	}
+	srv.virtualHosts[pattern] = virtualHost
+	return virtualHost, nil
+}
+
+// Selects the virtual host serving the given request, based on its host name. An exact match is preferred over a wildcard match, and the most specific wildcard pattern is preferred over the others.
+// If no pattern matches, the default virtual host is selected if it has been declared, otherwise an error with the status "421 - Misdirected Request" is returned. It returns nil if the server has no virtual hosts.
+func (srv *HttpServer) getVirtualHost(request *HttpRequest) (*VirtualHost, error) {
+	if len(srv.virtualHosts) == 0 {
+		return nil, nil
+	}
+
+	hostName := normalizeHostName(request.Host())
+	if hostName != "" {
+		virtualHost, ok := srv.virtualHosts[hostName]
+		if ok {
+			return virtualHost, nil
+		}
+
+		_, parentDomain, found := strings.Cut(hostName, ".")
+		for found {
+			virtualHost, ok = srv.virtualHosts["*." + parentDomain]
+			if ok {
+				return virtualHost, nil
+			}
+			_, parentDomain, found = strings.Cut(parentDomain, ".")
+		}
+	}
+
+	virtualHost, ok := srv.virtualHosts["*"]
+	if ok {
+		return virtualHost, nil
+	}
+	reError := new(RoutingError)
+	reError.RoutePath = request.ResourcePath
+	reError.Message = fmt.Sprintf("getVirtualHost: No virtual host has been declared for the host [%s]", hostName)
+	reError.Status = Status421
+	return nil, reError
+}
+
+// Matches the given request with the routes of the router serving its host, and stores the virtual host selected for the request, if any.
+func (srv *HttpServer) matchRoute(request *HttpRequest) (*Route, error) {
+	virtualHost, err := srv.getVirtualHost(request)
+	if err != nil {
+		return nil, err
+	}
+	request.virtualHost = virtualHost
+	return srv.getRouter(request).Match(request)
+}
+
+// Gets the router serving the given request, which is the router of its virtual host if one has been selected, or the Router of the server otherwise.
+func (srv *HttpServer) getRouter(request *HttpRequest) *Router {
+	if request.virtualHost != nil {
+		return request.virtualHost.Router
+	}
+	return srv.Router
+}
+
+// Converts the given host name to lower case, and removes the trailing dot of a fully qualified name and the brackets around an IPv6 address.
+func normalizeHostName(hostName string) string {
+	hostName = strings.TrimSuffix(strings.TrimSpace(hostName), ".")
+	hostName = strings.TrimSuffix(strings.TrimPrefix(hostName, "["), "]")
+	return strings.ToLower(hostName)
+}
+
+// Checks if the given pattern is "*", a host name or an IP address, or a host name prefixed with the "*." wildcard label.
+func isValidHostPattern(pattern string) bool {
+	if pattern == "*" {
+		return true
+	}
+	if net.ParseIP(pattern) != nil {
+		return true
+	}
+
+	pattern = strings.TrimPrefix(pattern, "*.")
+	if pattern == "" || len(pattern) > 253 {
+		return false
+	}
+	for label := range strings.SplitSeq(pattern, ".") {
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+		for _, char := range label {
+			if !(char >= 'a' && char <= 'z') && !(char >= '0' && char <= '9') && char != '-' && char != '_' {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,700 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// GUID appended to the key sent by the client to compute the "Sec-WebSocket-Accept" header value, as per RFC 6455.
+	WEBSOCKET_GUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	// The only version of the WebSocket protocol supported by the server.
+	WEBSOCKET_VERSION = "13"
+	// Name of the WebSocket extension which compresses messages using the DEFLATE algorithm, as per RFC 7692.
+	WEBSOCKET_DEFLATE_EXTENSION = "permessage-deflate"
+)
+
+// Types of the data messages exchanged over a WebSocket connection.
+const (
+	// Message containing UTF-8 encoded text.
+	WEBSOCKET_TEXT_MESSAGE = 1
+	// Message containing binary data.
+	WEBSOCKET_BINARY_MESSAGE = 2
+)
+
+// Status codes sent in the close frame of a WebSocket connection, as per RFC 6455.
+const (
+	// The purpose for which the connection was established has been fulfilled.
+	WEBSOCKET_CLOSE_NORMAL = 1000
+	// The endpoint is going away, like a server shutting down.
+	WEBSOCKET_CLOSE_GOING_AWAY = 1001
+	// The endpoint received a frame that violates the protocol.
+	WEBSOCKET_CLOSE_PROTOCOL_ERROR = 1002
+	// The endpoint received a type of data it cannot accept.
+	WEBSOCKET_CLOSE_UNSUPPORTED_DATA = 1003
+	// The close frame received did not contain a status code. It is never sent in a close frame.
+	WEBSOCKET_CLOSE_NO_STATUS = 1005
+	// The connection was closed without a close frame. It is never sent in a close frame.
+	WEBSOCKET_CLOSE_ABNORMAL = 1006
+	// The endpoint received a text message that is not valid UTF-8.
+	WEBSOCKET_CLOSE_INVALID_PAYLOAD = 1007
+	// The endpoint received a message that violates its policy.
+	WEBSOCKET_CLOSE_POLICY_VIOLATION = 1008
+	// The endpoint received a message that is too big to process.
+	WEBSOCKET_CLOSE_MESSAGE_TOO_BIG = 1009
+	// The endpoint encountered an unexpected condition that prevented it from fulfilling the request.
+	WEBSOCKET_CLOSE_INTERNAL_ERROR = 1011
+)
+
+// Opcodes of the frames exchanged over a WebSocket connection.
+const (
+	websocketContinuationFrame byte = 0x0
+	websocketTextFrame byte = 0x1
+	websocketBinaryFrame byte = 0x2
+	websocketCloseFrame byte = 0x8
+	websocketPingFrame byte = 0x9
+	websocketPongFrame byte = 0xA
+)
+
+// Bytes appended to a compressed message before it is decompressed. It restores the end of the DEFLATE block removed by the sender, followed by an empty final block so that the decompressor reaches the end of the stream.
+const websocketDeflateTail = "\x00\x00\xff\xff\x01\x00\x00\xff\xff"
+
+// Size of the window used by the DEFLATE algorithm, which is the largest amount of data that can be referenced from previous messages.
+const websocketDeflateWindow = 32768
+
+// Pool of compressors reused across messages, since creating a compressor allocates a large amount of memory.
+var websocketCompressors = sync.Pool{
+	New: func() any {
+		compressor, _ := flate.NewWriter(nil, flate.DefaultCompression)
+		return compressor
+	},
+}
+
+// Represents a handler function that is executed once a WebSocket connection has been established for a request.
+// The connection is closed once the handler returns.
+type WebSocketHandler func (*HttpRequest, *WebSocketConn)
+
+// Custom error returned once the WebSocket connection has been closed, either by the client or by the server.
+type WebSocketCloseError struct {
+	// Status code sent in the close frame.
+	Code int
+	// Reason sent in the close frame, if any.
+	Reason string
+}
+
+// Returns the error message associated with the WebSocketCloseError instance.
+func (wce *WebSocketCloseError) Error() string {
+	return fmt.Sprintf("WebSocketCloseError :: Code: (%d) :: %s", wce.Code, wce.Reason)
+}
+
+// Structure to represent a WebSocket connection established with a client.
+type WebSocketConn struct {
+	// The request which was upgraded to the WebSocket connection.
+	Request *HttpRequest
+	// Maximum size of a message received from the client, after decompression. Larger messages close the connection with status 1009. A zero value means there is no limit.
+	MaxMessageSize int64
+	// Function executed when a pong frame is received from the client, with the payload of the frame. It is executed by ReadMessage().
+	PongHandler func([]byte)
+	// The server instance which upgraded the connection.
+	server *HttpServer
+	// The client connection.
+	conn net.Conn
+	// Buffered reader for the frames received from the client, which may already hold frames sent right after the handshake.
+	reader *bufio.Reader
+	// Mutex to ensure that frames are written to the client one at a time. It also guards the closeSent flag.
+	writeMu sync.Mutex
+	// Mutex to ensure that the frames of a fragmented message are not interleaved with other data messages.
+	messageMu sync.Mutex
+	// Flag to determine if messages are compressed using the "permessage-deflate" extension.
+	compress bool
+	// Last bytes of the messages decompressed so far, which can be referenced by the next compressed message received from the client.
+	inflateWindow []byte
+	// Flag to determine if a close frame has been sent to the client.
+	closeSent bool
+	// Error returned once a close frame has been received from the client or the connection has failed.
+	closeErr error
+	// Channel closed once the handler of the connection has returned.
+	done chan struct{}
+}
+
+// Creates a new WebSocket endpoint at the given route path. The handler function is invoked once the server and route middlewares have accepted the request and the connection has been upgraded.
+// Requests which are not valid WebSocket handshakes are answered with "400 - Bad Request" or "426 - Upgrade Required".
+func (rtr *Router) WebSocket(RoutePath string, handlerFunc WebSocketHandler, middlewareList ...Middleware) error {
+	RoutePath = CleanRoute(RoutePath)
+	return rtr.addRoute("GET", RoutePath, func(request *HttpRequest, response *HttpResponse) {
+		ws, ok := upgradeWebSocket(request, response)
+		if !ok {
+			return
+		}
+		ws.serve(handlerFunc)
+	}, middlewareList)
+}
+
+// Validates the WebSocket handshake sent by the client and switches the connection to the WebSocket protocol.
+// The boolean value returned is false if the handshake is not valid, in which case an error response has already been sent to the client.
+func upgradeWebSocket(request *HttpRequest, response *HttpResponse) (*WebSocketConn, bool) {
+	// WebSocket connections are only established over HTTP/1.1 connections, since the server does not support bootstrapping them over HTTP/2.
+	if request.conn == nil || response.stream != nil || !strings.EqualFold(request.Version, "1.1") {
+		request.Server.Log("WebSocket handshake must be sent as a HTTP/1.1 request", ERROR_LEVEL)
+		response.Status(Status400)
+		ErrorHandler(request, response)
+		return nil, false
+	}
+
+	upgrade, _ := request.Headers.Get("Upgrade")
+	connection, _ := request.Headers.Get("Connection")
+	if !hasHeaderToken(upgrade, "websocket") || !hasHeaderToken(connection, "upgrade") {
+		request.Server.Log("WebSocket handshake must contain the \"Upgrade: websocket\" and \"Connection: Upgrade\" headers", ERROR_LEVEL)
+		response.Status(Status426)
+		response.Headers["Upgrade"] = []string{ "websocket" }
+		ErrorHandler(request, response)
+		return nil, false
+	}
+
+	version, _ := request.Headers.Get("Sec-WebSocket-Version")
+	if strings.TrimSpace(version) != WEBSOCKET_VERSION {
+		request.Server.Log(fmt.Sprintf("WebSocket version [%s] is not supported", version), ERROR_LEVEL)
+		response.Status(Status426)
+		response.Headers["Sec-Websocket-Version"] = []string{ WEBSOCKET_VERSION }
+		ErrorHandler(request, response)
+		return nil, false
+	}
+
+	key, _ := request.Headers.Get("Sec-WebSocket-Key")
+	key = strings.TrimSpace(key)
+	decodedKey, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(decodedKey) != 16 {
+		request.Server.Log(fmt.Sprintf("WebSocket key [%s] is not valid", key), ERROR_LEVEL)
+		response.Status(Status400)
+		ErrorHandler(request, response)
+		return nil, false
+	}
+
+	ws := new(WebSocketConn)
+	ws.Request = request
+	ws.MaxMessageSize = int64(GetServerDefaults("websocket_max_message_size").(int))
+	ws.server = request.Server
+	ws.conn = request.conn
+	ws.reader = request.reader
+	ws.done = make(chan struct{})
+
+	accept := sha1.Sum([]byte(key + WEBSOCKET_GUID))
+	response.Status(Status101)
+	delete(response.Headers, "Keep-Alive")
+	response.Headers["Connection"] = []string{ "Upgrade" }
+	response.Headers["Upgrade"] = []string{ "websocket" }
+	response.Headers["Sec-Websocket-Accept"] = []string{ base64.StdEncoding.EncodeToString(accept[:]) }
+	if request.Server.WebSocketCompression {
+		extensions, _ := request.Headers.Get("Sec-WebSocket-Extensions")
+		accepted, ok := negotiateDeflate(extensions)
+		if ok {
+			ws.compress = true
+			response.Headers["Sec-Websocket-Extensions"] = []string{ accepted }
+		}
+	}
+
+	response.upgraded = true
+	err = response.Write()
+	if err != nil {
+		request.Server.Log(err.Error(), ERROR_LEVEL)
+		return nil, false
+	}
+	ws.conn.SetWriteDeadline(time.Time{})
+	return ws, true
+}
+
+// Returns the "permessage-deflate" extension response for the first offer sent by the client that the server can accept.
+// The server never reuses the compression context across messages it sends, and does not support a window smaller than the default size for them.
+// The boolean value returned is false if the client did not offer the extension or none of its offers can be accepted.
+func negotiateDeflate(extensions string) (string, bool) {
+	for _, offer := range strings.Split(extensions, ",") {
+		params := strings.Split(offer, ";")
+		if !strings.EqualFold(strings.TrimSpace(params[0]), WEBSOCKET_DEFLATE_EXTENSION) {
+			continue
+		}
+
+		accepted := []string{ WEBSOCKET_DEFLATE_EXTENSION, "server_no_context_takeover" }
+		valid := true
+		for _, param := range params[1:] {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			name = strings.ToLower(strings.TrimSpace(name))
+			value = strings.Trim(strings.TrimSpace(value), "\"")
+			switch name {
+			case "server_no_context_takeover", "client_no_context_takeover", "client_max_window_bits":
+			case "server_max_window_bits":
+				if value != "15" {
+					valid = false
+				} else {
+					accepted = append(accepted, "server_max_window_bits=15")
+				}
+			default:
+				valid = false
+			}
+		}
+		if valid {
+			return strings.Join(accepted, "; "), true
+		}
+	}
+	return "", false
+}
+
+// Returns true if the given comma separated header value contains the given token, ignoring the case.
+func hasHeaderToken(value string, token string) bool {
+	for _, option := range strings.Split(value, ",") {
+		if strings.EqualFold(strings.TrimSpace(option), token) {
+			return true
+		}
+	}
+	return false
+}
+
+// Executes the given handler for the connection and completes the closing handshake once the handler returns.
+// Connections still open when the server shuts down are closed with status 1001.
+func (ws *WebSocketConn) serve(handlerFunc WebSocketHandler) {
+	go func() {
+		select {
+		case <-ws.server.shutdown:
+			ws.Close(WEBSOCKET_CLOSE_GOING_AWAY, "Server is shutting down")
+		case <-ws.done:
+		}
+	}()
+	defer close(ws.done)
+
+	handlerFunc(ws.Request, ws)
+	ws.Close(WEBSOCKET_CLOSE_NORMAL, "")
+	// The client is given some time to answer the close frame, before the server closes the connection.
+	for ws.closeErr == nil {
+		ws.ReadMessage()
+	}
+}
+
+// Reads the next data message sent by the client and returns its type (WEBSOCKET_TEXT_MESSAGE or WEBSOCKET_BINARY_MESSAGE) and its payload.
+// Fragmented messages are returned once all their frames have been received. Ping frames are answered automatically, and pong frames are passed to the PongHandler function.
+// Once the connection is closed, a WebSocketCloseError is returned containing the status code sent by the client, or an error describing why the connection failed.
+func (ws *WebSocketConn) ReadMessage() (int, []byte, error) {
+	if ws.closeErr != nil {
+		return 0, nil, ws.closeErr
+	}
+
+	messageType := 0
+	compressed := false
+	message := make([]byte, 0)
+	for {
+		fin, rsv1, opcode, payload, err := ws.readFrame(int64(len(message)))
+		if err != nil {
+			return 0, nil, ws.fail(err)
+		}
+
+		switch opcode {
+		case websocketPingFrame:
+			err = ws.writeFrame(websocketPongFrame, true, false, payload)
+			if err != nil {
+				return 0, nil, ws.fail(err)
+			}
+			continue
+		case websocketPongFrame:
+			if ws.PongHandler != nil {
+				ws.PongHandler(payload)
+			}
+			continue
+		case websocketCloseFrame:
+			return 0, nil, ws.receiveClose(payload)
+		case websocketTextFrame, websocketBinaryFrame:
+			if messageType != 0 {
+				return 0, nil, ws.fail(&WebSocketCloseError{ Code: WEBSOCKET_CLOSE_PROTOCOL_ERROR, Reason: "New message started before the previous message was completed" })
+			}
+			messageType = int(opcode)
+			compressed = rsv1
+		case websocketContinuationFrame:
+			if messageType == 0 {
+				return 0, nil, ws.fail(&WebSocketCloseError{ Code: WEBSOCKET_CLOSE_PROTOCOL_ERROR, Reason: "Continuation frame received without a message to continue" })
+			}
+		default:
+			return 0, nil, ws.fail(&WebSocketCloseError{ Code: WEBSOCKET_CLOSE_PROTOCOL_ERROR, Reason: fmt.Sprintf("Frame with reserved opcode [%d] received", opcode) })
+		}
+
+		message = append(message, payload...)
+		if !fin {
+			continue
+		}
+
+		if compressed {
+			message, err = ws.inflate(message)
+			if err != nil {
+				return 0, nil, ws.fail(err)
+			}
+		}
+		if messageType == WEBSOCKET_TEXT_MESSAGE && !utf8.Valid(message) {
+			return 0, nil, ws.fail(&WebSocketCloseError{ Code: WEBSOCKET_CLOSE_INVALID_PAYLOAD, Reason: "Text message is not valid UTF-8" })
+		}
+		return messageType, message, nil
+	}
+}
+
+// Reads a single frame sent by the client and returns its FIN flag, its RSV1 flag, its opcode and its unmasked payload.
+// The size of the message received so far is used to enforce the maximum message size.
+func (ws *WebSocketConn) readFrame(messageSize int64) (bool, bool, byte, []byte, error) {
+	header := make([]byte, 2)
+	_, err := io.ReadFull(ws.reader, header)
+	if err != nil {
+		return false, false, 0, nil, err
+	}
+
+	fin := header[0] & 0x80 != 0
+	rsv1 := header[0] & 0x40 != 0
+	opcode := header[0] & 0x0f
+	masked := header[1] & 0x80 != 0
+	length := uint64(header[1] & 0x7f)
+	isControl := opcode & 0x8 != 0
+
+	if header[0] & 0x30 != 0 || (rsv1 && (!ws.compress || isControl || opcode == websocketContinuationFrame)) {
+		return false, false, 0, nil, &WebSocketCloseError{ Code: WEBSOCKET_CLOSE_PROTOCOL_ERROR, Reason: "Reserved bits must not be set without a negotiated extension" }
+	}
+	if !masked {
+		return false, false, 0, nil, &WebSocketCloseError{ Code: WEBSOCKET_CLOSE_PROTOCOL_ERROR, Reason: "Frames sent by the client must be masked" }
+	}
+	if isControl && (!fin || length > 125) {
+		return false, false, 0, nil, &WebSocketCloseError{ Code: WEBSOCKET_CLOSE_PROTOCOL_ERROR, Reason: "Control frames must not be fragmented and must have a payload of at most 125 bytes" }
+	}
+
+	switch length {
+	case 126:
+		extended := make([]byte, 2)
+		_, err = io.ReadFull(ws.reader, extended)
+		length = uint64(binary.BigEndian.Uint16(extended))
+	case 127:
+		extended := make([]byte, 8)
+		_, err = io.ReadFull(ws.reader, extended)
+		length = binary.BigEndian.Uint64(extended)
+		if length >> 63 != 0 {
+			return false, false, 0, nil, &WebSocketCloseError{ Code: WEBSOCKET_CLOSE_PROTOCOL_ERROR, Reason: "Frame length must not have the most significant bit set" }
+		}
+	}
+	if err != nil {
+		return false, false, 0, nil, err
+	}
+	if !isControl && ws.MaxMessageSize > 0 && int64(length) > ws.MaxMessageSize - messageSize {
+		return false, false, 0, nil, &WebSocketCloseError{ Code: WEBSOCKET_CLOSE_MESSAGE_TOO_BIG, Reason: fmt.Sprintf("Message is larger than the limit of %d bytes", ws.MaxMessageSize) }
+	}
+
+	maskKey := make([]byte, 4)
+	_, err = io.ReadFull(ws.reader, maskKey)
+	if err != nil {
+		return false, false, 0, nil, err
+	}
+	// The payload is read in pieces, so that the memory used grows with the bytes received rather than the length declared by the client.
+	buffer := new(bytes.Buffer)
+	_, err = io.CopyN(buffer, ws.reader, int64(length))
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return false, false, 0, nil, err
+	}
+	payload := buffer.Bytes()
+	for index := range payload {
+		payload[index] ^= maskKey[index % 4]
+	}
+	return fin, rsv1, opcode, payload, nil
+}
+
+// Decompresses a message compressed using the "permessage-deflate" extension. The client may reference the data of its previous messages, which are kept in the inflate window.
+func (ws *WebSocketConn) inflate(message []byte) ([]byte, error) {
+	source := io.MultiReader(bytes.NewReader(message), strings.NewReader(websocketDeflateTail))
+	decompressor := flate.NewReaderDict(source, ws.inflateWindow)
+	defer decompressor.Close()
+
+	var limited io.Reader = decompressor
+	if ws.MaxMessageSize > 0 {
+		limited = io.LimitReader(decompressor, ws.MaxMessageSize + 1)
+	}
+	inflated, err := io.ReadAll(limited)
+	if err != nil {
+		return nil, &WebSocketCloseError{ Code: WEBSOCKET_CLOSE_PROTOCOL_ERROR, Reason: "Compressed message could not be decompressed" }
+	}
+	if ws.MaxMessageSize > 0 && int64(len(inflated)) > ws.MaxMessageSize {
+		return nil, &WebSocketCloseError{ Code: WEBSOCKET_CLOSE_MESSAGE_TOO_BIG, Reason: fmt.Sprintf("Message is larger than the limit of %d bytes", ws.MaxMessageSize) }
+	}
+
+	ws.inflateWindow = append(ws.inflateWindow, inflated...)
+	if len(ws.inflateWindow) > websocketDeflateWindow {
+		ws.inflateWindow = ws.inflateWindow[len(ws.inflateWindow) - websocketDeflateWindow:]
+	}
+	return inflated, nil
+}
+
+// Processes a close frame received from the client, answering it with a close frame if the server has not sent one already.
+func (ws *WebSocketConn) receiveClose(payload []byte) error {
+	closeErr := &WebSocketCloseError{ Code: WEBSOCKET_CLOSE_NO_STATUS }
+	if len(payload) == 1 {
+		return ws.fail(&WebSocketCloseError{ Code: WEBSOCKET_CLOSE_PROTOCOL_ERROR, Reason: "Close frame payload must contain a status code" })
+	}
+	if len(payload) >= 2 {
+		closeErr.Code = int(binary.BigEndian.Uint16(payload))
+		closeErr.Reason = string(payload[2:])
+		if !isValidCloseCode(closeErr.Code) {
+			return ws.fail(&WebSocketCloseError{ Code: WEBSOCKET_CLOSE_PROTOCOL_ERROR, Reason: fmt.Sprintf("Close frame contains an invalid status code [%d]", closeErr.Code) })
+		}
+		if !utf8.ValidString(closeErr.Reason) {
+			return ws.fail(&WebSocketCloseError{ Code: WEBSOCKET_CLOSE_INVALID_PAYLOAD, Reason: "Close reason is not valid UTF-8" })
+		}
+	}
+
+	ws.closeErr = closeErr
+	replyCode := closeErr.Code
+	if replyCode == WEBSOCKET_CLOSE_NO_STATUS {
+		replyCode = WEBSOCKET_CLOSE_NORMAL
+	}
+	ws.writeClose(replyCode, "")
+	return closeErr
+}
+
+// Returns true if the given status code can be sent in a close frame.
+func isValidCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1011:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
+
+// Marks the connection as failed because of the given error. Protocol violations are reported to the client with a close frame, while other errors close the connection without one.
+func (ws *WebSocketConn) fail(err error) error {
+	closeErr, ok := err.(*WebSocketCloseError)
+	if ok {
+		ws.server.Log(fmt.Sprintf("Closing WebSocket connection with client [%s] :: %s", ws.conn.RemoteAddr().String(), closeErr.Error()), ERROR_LEVEL)
+		ws.writeClose(closeErr.Code, closeErr.Reason)
+	} else {
+		closeErr = &WebSocketCloseError{ Code: WEBSOCKET_CLOSE_ABNORMAL, Reason: err.Error() }
+	}
+	ws.closeErr = closeErr
+	return closeErr
+}
+
+// Writes a single frame to the client. Frames sent by the server are never masked.
+func (ws *WebSocketConn) writeFrame(opcode byte, fin bool, rsv1 bool, payload []byte) error {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	if ws.closeSent {
+		return &WebSocketCloseError{ Code: WEBSOCKET_CLOSE_ABNORMAL, Reason: "Close frame has already been sent and no more frames can be written" }
+	}
+	return ws.writeFrameLocked(opcode, fin, rsv1, payload)
+}
+
+// Writes a single frame to the client. The caller must hold writeMu.
+func (ws *WebSocketConn) writeFrameLocked(opcode byte, fin bool, rsv1 bool, payload []byte) error {
+	frame := make([]byte, 0, len(payload) + 10)
+	firstByte := opcode
+	if fin {
+		firstByte |= 0x80
+	}
+	if rsv1 {
+		firstByte |= 0x40
+	}
+	frame = append(frame, firstByte)
+
+	switch {
+	case len(payload) <= 125:
+		frame = append(frame, byte(len(payload)))
+	case len(payload) <= 65535:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(payload)))
+	}
+	frame = append(frame, payload...)
+
+	if ws.server.WriteTimeout > 0 {
+		ws.conn.SetWriteDeadline(time.Now().Add(ws.server.WriteTimeout))
+	}
+	_, err := ws.conn.Write(frame)
+	return err
+}
+
+// Writes a close frame with the given status code and reason to the client, if one has not been sent already.
+// Once the close frame is sent, reading from the connection is limited by the close timeout so that an unresponsive client cannot keep it open.
+func (ws *WebSocketConn) writeClose(code int, reason string) error {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	if ws.closeSent {
+		return nil
+	}
+	ws.closeSent = true
+
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	payload = append(payload, reason...)
+	if len(payload) > 125 {
+		payload = payload[:125]
+	}
+	err := ws.writeFrameLocked(websocketCloseFrame, true, false, payload)
+	closeTimeout := GetServerDefaults("websocket_close_timeout").(int)
+	ws.conn.SetReadDeadline(time.Now().Add(time.Duration(closeTimeout) * time.Second))
+	return err
+}
+
+// Sends a data message of the given type (WEBSOCKET_TEXT_MESSAGE or WEBSOCKET_BINARY_MESSAGE) to the client in a single frame.
+// The message is compressed if the "permessage-deflate" extension has been negotiated with the client.
+func (ws *WebSocketConn) WriteMessage(messageType int, data []byte) error {
+	if messageType != WEBSOCKET_TEXT_MESSAGE && messageType != WEBSOCKET_BINARY_MESSAGE {
+		return &CustomError{ Message: fmt.Sprintf("Invalid WebSocket message type [%d]", messageType) }
+	}
+
+	ws.messageMu.Lock()
+	defer ws.messageMu.Unlock()
+	if ws.compress {
+		compressed, err := deflateMessage(data)
+		if err != nil {
+			return err
+		}
+		return ws.writeFrame(byte(messageType), true, true, compressed)
+	}
+	return ws.writeFrame(byte(messageType), true, false, data)
+}
+
+// Sends the given text to the client as a text message.
+func (ws *WebSocketConn) WriteText(text string) error {
+	return ws.WriteMessage(WEBSOCKET_TEXT_MESSAGE, []byte(text))
+}
+
+// Returns a writer which sends a data message of the given type to the client as a sequence of fragments, one for each call to Write().
+// The message is completed by calling Close() on the writer. Other data messages cannot be sent until the writer is closed, while control frames can still be sent in between the fragments.
+func (ws *WebSocketConn) MessageWriter(messageType int) (io.WriteCloser, error) {
+	if messageType != WEBSOCKET_TEXT_MESSAGE && messageType != WEBSOCKET_BINARY_MESSAGE {
+		return nil, &CustomError{ Message: fmt.Sprintf("Invalid WebSocket message type [%d]", messageType) }
+	}
+
+	ws.messageMu.Lock()
+	writer := &webSocketMessageWriter{ ws: ws, opcode: byte(messageType) }
+	if ws.compress {
+		writer.buffer = new(bytes.Buffer)
+		writer.compressor = websocketCompressors.Get().(*flate.Writer)
+		writer.compressor.Reset(writer.buffer)
+	}
+	return writer, nil
+}
+
+// Sends a ping frame with the given payload to the client, which must answer it with a pong frame. The payload must not be larger than 125 bytes.
+func (ws *WebSocketConn) Ping(data []byte) error {
+	if len(data) > 125 {
+		return &CustomError{ Message: "Ping payload must not be larger than 125 bytes" }
+	}
+	return ws.writeFrame(websocketPingFrame, true, false, data)
+}
+
+// Starts the closing handshake by sending a close frame with the given status code and reason to the client.
+// Any pending or subsequent call to ReadMessage() returns once the client answers with its own close frame, or once the close timeout expires.
+func (ws *WebSocketConn) Close(code int, reason string) error {
+	if !isValidCloseCode(code) {
+		return &CustomError{ Message: fmt.Sprintf("Invalid WebSocket close code [%d]", code) }
+	}
+	return ws.writeClose(code, reason)
+}
+
+// Returns the client connection on which the WebSocket connection has been established.
+func (ws *WebSocketConn) RemoteAddr() net.Addr {
+	return ws.conn.RemoteAddr()
+}
+
+// Compresses the given message using the DEFLATE algorithm, without the empty block marking the end of the compressed data, as per RFC 7692.
+func deflateMessage(data []byte) ([]byte, error) {
+	buffer := new(bytes.Buffer)
+	compressor := websocketCompressors.Get().(*flate.Writer)
+	defer websocketCompressors.Put(compressor)
+	compressor.Reset(buffer)
+	_, err := compressor.Write(data)
+	if err == nil {
+		err = compressor.Flush()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buffer.Bytes(), []byte(websocketDeflateTail[:4])), nil
+}
+
+// Writer which sends a data message to the client as a sequence of fragments.
+type webSocketMessageWriter struct {
+	// The connection on which the message is sent.
+	ws *WebSocketConn
+	// Opcode of the next fragment, which is the message type for the first fragment and continuation for the rest.
+	opcode byte
+	// Flag to determine if the first fragment has been sent.
+	started bool
+	// Flag to determine if the message has been completed.
+	closed bool
+	// Compressor for messages sent using the "permessage-deflate" extension, and the buffer receiving its output.
+	compressor *flate.Writer
+	buffer *bytes.Buffer
+}
+
+// Sends the given bytes to the client as the next fragment of the message.
+func (mw *webSocketMessageWriter) Write(data []byte) (int, error) {
+	if mw.closed {
+		return 0, &CustomError{ Message: "WebSocket message has already been completed" }
+	}
+	if len(data) == 0 {
+		return 0, nil
+	}
+
+	payload := data
+	if mw.compressor != nil {
+		mw.buffer.Reset()
+		mw.compressor.Write(data)
+		err := mw.compressor.Flush()
+		if err != nil {
+			return 0, err
+		}
+		payload = mw.buffer.Bytes()
+	}
+
+	err := mw.writeFragment(payload, false)
+	if err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// Completes the message by sending the final fragment to the client.
+func (mw *webSocketMessageWriter) Close() error {
+	if mw.closed {
+		return nil
+	}
+	defer mw.ws.messageMu.Unlock()
+	mw.closed = true
+
+	payload := []byte{}
+	if mw.compressor != nil {
+		defer websocketCompressors.Put(mw.compressor)
+		mw.buffer.Reset()
+		err := mw.compressor.Flush()
+		if err != nil {
+			return err
+		}
+		payload = bytes.TrimSuffix(mw.buffer.Bytes(), []byte(websocketDeflateTail[:4]))
+	}
+	return mw.writeFragment(payload, true)
+}
+
+// Sends a single fragment of the message to the client.
+func (mw *webSocketMessageWriter) writeFragment(payload []byte, fin bool) error {
+	opcode := websocketContinuationFrame
+	if !mw.started {
+		opcode = mw.opcode
+		mw.started = true
+	}
+	return mw.ws.writeFrame(opcode, fin, opcode != websocketContinuationFrame && mw.compressor != nil, payload)
+}
+
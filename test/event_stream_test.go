@@ -0,0 +1,141 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"github.com/citadelofcode/proteus/internal"
+)
+
+// Helper function to create a test server with Server-Sent Events endpoints, served on a random local port.
+// The channel returned receives a value every time the handler of the "/wait" endpoint notices that the stream is done.
+func NewEventStreamTestServer(t testing.TB) (*internal.HttpServer, string, chan struct{}) {
+	t.Helper()
+	testServer := NewTestServer(t)
+	finished := make(chan struct{}, 1)
+	testServer.Router.Get("/events", func(request *internal.HttpRequest, response *internal.HttpResponse) {
+		stream, err := response.EventStream(0)
+		if err != nil {
+			return
+		}
+		stream.Send(internal.ServerSentEvent{ Event: "resume", ID: "7", Data: "after " + request.LastEventID(), Retry: 3 * time.Second })
+		stream.SendData("first line\nsecond line")
+	})
+	testServer.Router.Get("/wait", func(request *internal.HttpRequest, response *internal.HttpResponse) {
+		stream, err := response.EventStream(20 * time.Millisecond)
+		if err != nil {
+			return
+		}
+		<-stream.Done()
+		finished <- struct{}{}
+	})
+	return testServer, ServeTestServer(t, testServer), finished
+}
+
+// Test case to validate the format of the events sent in a Server-Sent Events stream.
+func Test_EventStream_Events(t *testing.T) {
+	_, address, _ := NewEventStreamTestServer(t)
+	response, body, err := SendRawRequest(t, address, "GET /events HTTP/1.1\r\nHost: localhost\r\nAccept: text/event-stream\r\nLast-Event-ID: 6\r\n\r\n")
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while reading the response from the test server: %s"), err.Error())
+		return
+	}
+
+	expBody := "id: 7\nevent: resume\nretry: 3000\ndata: after 6\n\ndata: first line\ndata: second line\n\n"
+	if response.Header.Get("Content-Type") != "text/event-stream" {
+		t.Errorf(internal.TextColor.Red("Expected the content type [text/event-stream], but got [%s] instead."), response.Header.Get("Content-Type"))
+	} else if body != expBody {
+		t.Errorf(internal.TextColor.Red("Expected the event stream %q, but got %q instead."), expBody, body)
+	} else {
+		t.Log("The events were sent in the text/event-stream format as expected.")
+	}
+}
+
+// Test case to validate that heartbeats are sent on an idle stream, and that the handler is notified once the client disconnects.
+func Test_EventStream_Disconnect(t *testing.T) {
+	_, address, finished := NewEventStreamTestServer(t)
+	conn := DialTestServer(t, address)
+
+	conn.Write([]byte("GET /wait HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while reading the response from the test server: %s"), err.Error())
+		return
+	}
+	line, err := bufio.NewReader(response.Body).ReadString('\n')
+	if err != nil || line != ": heartbeat\n" {
+		t.Errorf(internal.TextColor.Red("Expected a heartbeat comment on the idle stream, but got %q with error %v instead."), line, err)
+	} else {
+		t.Log("A heartbeat comment was received on the idle stream as expected.")
+	}
+
+	conn.Close()
+	select {
+	case <-finished:
+		t.Log("The handler was notified that the client has disconnected as expected.")
+	case <-time.After(3 * time.Second):
+		t.Error(internal.TextColor.Red("The handler was not notified that the client has disconnected."))
+	}
+}
+
+// Test case to validate that the handler of an open stream is notified once the server is shutting down.
+func Test_EventStream_Shutdown(t *testing.T) {
+	testServer, address, finished := NewEventStreamTestServer(t)
+	conn := DialTestServer(t, address)
+
+	conn.Write([]byte("GET /wait HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	_, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while reading the response from the test server: %s"), err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3 * time.Second)
+	defer cancel()
+	err = testServer.Shutdown(ctx)
+	select {
+	case <-finished:
+		if err != nil {
+			t.Errorf(internal.TextColor.Red("Expected the server to shutdown gracefully, but got an error instead - %s"), err.Error())
+		} else {
+			t.Log("The handler was notified of the shutdown and the server was shutdown gracefully as expected.")
+		}
+	default:
+		t.Error(internal.TextColor.Red("The handler was not notified that the server is shutting down."))
+	}
+}
+
+// Test case to validate that the handler is notified once a HTTP/2 client cancels the stream.
+func Test_EventStream_HTTP2Cancel(t *testing.T) {
+	_, address, finished := NewEventStreamTestServer(t)
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{ Transport: &http.Transport{ Protocols: protocols } }
+	defer client.CloseIdleConnections()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	request, _ := http.NewRequestWithContext(ctx, "GET", "http://" + address + "/wait", nil)
+	response, err := client.Do(request)
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while sending the request to the test server: %s"), err.Error())
+		return
+	}
+	line, _ := bufio.NewReader(response.Body).ReadString('\n')
+	cancel()
+	response.Body.Close()
+
+	select {
+	case <-finished:
+		if strings.HasPrefix(line, ":") && response.ProtoMajor == 2 {
+			t.Log("The handler was notified that the HTTP/2 stream was cancelled as expected.")
+		} else {
+			t.Errorf(internal.TextColor.Red("Expected a heartbeat over HTTP/2, but got %q over %s instead."), line, response.Proto)
+		}
+	case <-time.After(3 * time.Second):
+		t.Error(internal.TextColor.Red("The handler was not notified that the HTTP/2 stream was cancelled."))
+	}
+}
@@ -0,0 +1,91 @@
+package test
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+	"github.com/citadelofcode/proteus/internal"
+)
+
+// Helper function to create a test server with upload routes for testing the "Expect" request header, served on a random local port.
+func NewExpectTestServer(t testing.TB) string {
+	t.Helper()
+	testServer := NewTestServer(t)
+	testServer.Limits.MaxBodySize = 1024
+	echoHandler := func(request *internal.HttpRequest, response *internal.HttpResponse) {
+		response.Status(internal.Status200)
+		response.Send(string(request.BodyBytes))
+	}
+	authorize := func(request *internal.HttpRequest, response *internal.HttpResponse, stop internal.StopFunction) {
+		_, ok := request.Headers.Get("Authorization")
+		if !ok {
+			response.Status(internal.Status401)
+			internal.ErrorHandler(request, response)
+			stop()
+		}
+	}
+	testServer.Router.Post("/upload", echoHandler, authorize)
+	return ServeTestServer(t, testServer)
+}
+
+// Test case to validate that "100 Continue" is sent only for requests accepted by the route middlewares, before the request body is read.
+func Test_Expect_Continue(t *testing.T) {
+	address := NewExpectTestServer(t)
+	testCases := []struct {
+		Name string
+		Headers string
+		ExpContinue bool
+		ExpStatus string
+	} {
+		{ "Request accepted by the route middlewares", "Authorization: Bearer token\r\nConnection: close\r\nContent-Length: 5\r\n", true, "200" },
+		{ "Request rejected by the route middlewares", "Content-Length: 5\r\n", false, "401" },
+		{ "Request with a body larger than the limit", "Authorization: Bearer token\r\nContent-Length: 2048\r\n", false, "413" },
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(tt *testing.T) {
+			conn := DialTestServer(tt, address)
+
+			conn.Write([]byte("POST /upload HTTP/1.1\r\nHost: localhost\r\nExpect: 100-continue\r\n" + testCase.Headers + "\r\n"))
+			reader := bufio.NewReader(conn)
+			statusLine, err := reader.ReadString('\n')
+			if err != nil {
+				tt.Fatalf(internal.TextColor.Red("Error occurred while reading the response from the test server: %s"), err.Error())
+				return
+			}
+
+			if strings.HasPrefix(statusLine, "HTTP/1.1 100") != testCase.ExpContinue {
+				tt.Fatalf(internal.TextColor.Red("Expected the interim response to be sent - %t, but got the status line - %q"), testCase.ExpContinue, statusLine)
+				return
+			}
+
+			if testCase.ExpContinue {
+				reader.ReadString('\n')
+				conn.Write([]byte("hello"))
+				statusLine, _ = reader.ReadString('\n')
+			}
+
+			remaining, _ := io.ReadAll(reader)
+			if strings.HasPrefix(statusLine, "HTTP/1.1 " + testCase.ExpStatus) {
+				tt.Logf("The server answered the request with the expected status code - %s.", testCase.ExpStatus)
+			} else {
+				tt.Errorf(internal.TextColor.Red("The server was expected to answer the request with %s, but sent this instead - %q"), testCase.ExpStatus, statusLine + string(remaining))
+			}
+		})
+	}
+}
+
+// Test case to validate that requests with an unsupported expectation are answered with "417 - Expectation Failed".
+func Test_Expect_Unsupported(t *testing.T) {
+	address := NewExpectTestServer(t)
+	conn := DialTestServer(t, address)
+
+	conn.Write([]byte("POST /upload HTTP/1.1\r\nHost: localhost\r\nExpect: 200-ok\r\nContent-Length: 5\r\n\r\nhello"))
+	responseBytes, _ := io.ReadAll(conn)
+	if strings.HasPrefix(string(responseBytes), "HTTP/1.1 417") {
+		t.Log("The server answered the unsupported expectation with 417 as expected.")
+	} else {
+		t.Errorf(internal.TextColor.Red("The server was expected to answer the unsupported expectation with 417, but sent this instead - %q"), string(responseBytes))
+	}
+}
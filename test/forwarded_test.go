@@ -0,0 +1,83 @@
+package test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"github.com/citadelofcode/proteus/internal"
+)
+
+// Helper function to create a test server trusting the given proxies, served on a random local port.
+// The endpoint "/origin" responds with the client IP address, scheme, host, port and client address resolved for the request.
+func NewForwardedTestServer(t testing.TB, proxies ...string) string {
+	t.Helper()
+	testServer := NewTestServer(t)
+	err := testServer.SetTrustedProxies(proxies...)
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while setting the trusted proxies: %s"), err.Error())
+	}
+	testServer.Router.Get("/origin", func(request *internal.HttpRequest, response *internal.HttpResponse) {
+		response.Status(internal.Status200)
+		response.Send(fmt.Sprintf("%s %s %s %d %s", request.ClientIP(), request.Scheme(), request.Host(), request.Port(), request.ClientAddress))
+	})
+	return ServeTestServer(t, testServer)
+}
+
+// Test case to validate that the client details are resolved from the forwarding headers sent by trusted proxies.
+func Test_Forwarded_TrustedProxy(t *testing.T) {
+	address := NewForwardedTestServer(t, "127.0.0.1", "10.0.0.0/8")
+	testCases := []struct {
+		Name string
+		Headers map[string]string
+		ExpPrefix string
+	} {
+		{ "Request without forwarding headers", nil, "127.0.0.1 http app.local 80 127.0.0.1:" },
+		{ "X-Forwarded headers with a chain of proxies", map[string]string{ "X-Forwarded-For": "203.0.113.5, 10.1.1.1", "X-Forwarded-Proto": "https", "X-Forwarded-Host": "example.com" }, "203.0.113.5 https example.com 443 203.0.113.5" },
+		{ "X-Forwarded-For with an address spoofed by the client", map[string]string{ "X-Forwarded-For": "192.0.2.1, 203.0.113.5" }, "203.0.113.5 http app.local 80 203.0.113.5" },
+		{ "Forwarded header with quoted host and port", map[string]string{ "Forwarded": "for=198.51.100.17;proto=https;host=\"shop.example.com:8443\", for=10.0.0.2" }, "198.51.100.17 https shop.example.com 8443 198.51.100.17" },
+		{ "Forwarded header with an IPv6 client and port", map[string]string{ "Forwarded": "for=\"[2001:db8::17]:4711\"" }, "2001:db8::17 http app.local 80 [2001:db8::17]:4711" },
+		{ "Forwarded header taking precedence over X-Forwarded-For", map[string]string{ "Forwarded": "for=198.51.100.17", "X-Forwarded-For": "203.0.113.5" }, "198.51.100.17 http app.local 80 198.51.100.17" },
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(tt *testing.T) {
+			_, origin, err := SendTestRequest(tt, "GET", address, "/origin", "app.local", testCase.Headers)
+			if err != nil {
+				tt.Errorf(internal.TextColor.Red("Error occurred while sending the request to the test server: %s"), err.Error())
+			} else if strings.HasPrefix(origin, testCase.ExpPrefix) {
+				tt.Logf("The client details were resolved as [%s] as expected.", origin)
+			} else {
+				tt.Errorf(internal.TextColor.Red("Expected the client details to start with [%s], but got [%s] instead."), testCase.ExpPrefix, origin)
+			}
+		})
+	}
+}
+
+// Test case to validate that the forwarding headers are ignored when they are not sent by a trusted proxy.
+func Test_Forwarded_UntrustedPeer(t *testing.T) {
+	address := NewForwardedTestServer(t, "10.0.0.0/8")
+	_, origin, err := SendTestRequest(t, "GET", address, "/origin", "app.local:8080", map[string]string{
+		"X-Forwarded-For": "203.0.113.5",
+		"X-Forwarded-Proto": "https",
+		"Forwarded": "for=198.51.100.17;host=evil.example",
+	})
+	expPrefix := "127.0.0.1 http app.local 8080 127.0.0.1:"
+	if err != nil {
+		t.Errorf(internal.TextColor.Red("Error occurred while sending the request to the test server: %s"), err.Error())
+	} else if strings.HasPrefix(origin, expPrefix) {
+		t.Logf("The forwarding headers sent by an untrusted peer were ignored as expected - [%s].", origin)
+	} else {
+		t.Errorf(internal.TextColor.Red("Expected the client details to start with [%s], but got [%s] instead."), expPrefix, origin)
+	}
+}
+
+// Test case to validate that invalid addresses are rejected while setting the trusted proxies of the server.
+func Test_Forwarded_InvalidProxies(t *testing.T) {
+	testServer := NewTestServer(t)
+	err := testServer.SetTrustedProxies("10.0.0.0/33")
+	if err != nil {
+		t.Logf("The invalid network was rejected as expected - %s", err.Error())
+	} else {
+		t.Error(internal.TextColor.Red("Expected an error while setting an invalid network as a trusted proxy."))
+	}
+}
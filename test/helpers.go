@@ -1,10 +1,21 @@
 package test
 
 import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"io"
+	"math/big"
+	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 	"github.com/citadelofcode/proteus/internal"
 )
 
@@ -19,6 +30,78 @@ func NewTestServer(t testing.TB) *internal.HttpServer {
 	return internal.NewServer("", 0)
 }
 
+// Helper function to serve the given server instance on a random local port. It returns the address where the server is listening.
+// The server is closed once the test completes.
+func ServeTestServer(t testing.TB, server *internal.HttpServer) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while creating test listener: %s"), err.Error())
+		return ""
+	}
+
+	go server.Serve(listener)
+	t.Cleanup(func() {
+		server.Close()
+	})
+	return listener.Addr().String()
+}
+
+// Helper function to open a connection to the test server listening at the given address, with a deadline of 5 seconds for all reads and writes.
+// The connection is closed once the test completes.
+func DialTestServer(t testing.TB, address string) net.Conn {
+	t.Helper()
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while connecting to the test server: %s"), err.Error())
+		return nil
+	}
+	t.Cleanup(func() {
+		conn.Close()
+	})
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+// Helper function to send the given raw request to the test server listening at the given address over a new connection, and read a single response.
+// It returns the response along with its body, which has been read completely.
+func SendRawRequest(t testing.TB, address string, rawRequest string) (*http.Response, string, error) {
+	t.Helper()
+	conn := DialTestServer(t, address)
+	defer conn.Close()
+	conn.Write([]byte(rawRequest))
+	response, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	return response, string(body), err
+}
+
+// Helper function to send a request with the given method, host name and headers to the test server listening at the given address, using the default client.
+// The host name of the address is used when no host name is given. It returns the response along with its body, which has been read completely.
+func SendTestRequest(t testing.TB, method string, address string, path string, host string, headers map[string]string) (*http.Response, string, error) {
+	t.Helper()
+	request, err := http.NewRequest(method, "http://" + address + path, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if host != "" {
+		request.Host = host
+	}
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, "", err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	return response, string(body), err
+}
+
 // Helper function to create a new test request for the given server instance.
 func NewTestRequest(t testing.TB, server *internal.HttpServer, reader io.Reader) *internal.HttpRequest {
 	t.Helper()
@@ -97,3 +180,47 @@ func CreateFiles(t testing.TB, root string, files map[string][]byte) error {
 	}
 	return nil
 }
+
+// Helper function to create a self-signed certificate valid for the given host names. The PEM encoded certificate and key are written to "<name>.crt" and "<name>.key" files in the root directory provided.
+func CreateCertificate(t testing.TB, root string, name string, hostNames []string) (string, string, error) {
+	t.Helper()
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, big.NewInt(1 << 62))
+	if err != nil {
+		return "", "", err
+	}
+
+	template := x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{ CommonName: hostNames[0] },
+		DNSNames: hostNames,
+		NotBefore: time.Now().Add(-1 * time.Hour),
+		NotAfter: time.Now().Add(24 * time.Hour),
+		KeyUsage: x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{ x509.ExtKeyUsageServerAuth },
+	}
+
+	certBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	keyBytes, err := x509.MarshalECPrivateKey(privateKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	err = CreateFiles(t, root, map[string][]byte {
+		name + ".crt": pem.EncodeToMemory(&pem.Block{ Type: "CERTIFICATE", Bytes: certBytes }),
+		name + ".key": pem.EncodeToMemory(&pem.Block{ Type: "EC PRIVATE KEY", Bytes: keyBytes }),
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return filepath.Join(root, name + ".crt"), filepath.Join(root, name + ".key"), nil
+}
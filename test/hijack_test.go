@@ -0,0 +1,116 @@
+package test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"github.com/citadelofcode/proteus/internal"
+)
+
+// Helper function to create a test server with an endpoint that hijacks the connection and switches to a protocol echoing every line in upper case, served on a random local port.
+// The endpoint "/late" tries to hijack the connection after the response headers have been sent, and responds with the error message received.
+func NewHijackTestServer(t testing.TB) (*internal.HttpServer, string) {
+	t.Helper()
+	testServer := NewTestServer(t)
+	testServer.Router.Get("/shout", func(request *internal.HttpRequest, response *internal.HttpResponse) {
+		conn, buffered, err := response.Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: shout\r\n\r\n"))
+		reader := bufio.NewReader(io.MultiReader(bytes.NewReader(buffered), conn))
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			conn.Write([]byte(strings.ToUpper(line)))
+		}
+	})
+	testServer.Router.Get("/late", func(request *internal.HttpRequest, response *internal.HttpResponse) {
+		response.Status(internal.Status200)
+		response.SendHeaders()
+		_, _, err := response.Hijack()
+		if err != nil {
+			response.Stream([]byte(err.Error()))
+		}
+	})
+	return testServer, ServeTestServer(t, testServer)
+}
+
+// Test case to validate that a hijacked connection is handed over along with the bytes already buffered, and is no longer managed by the server.
+func Test_Hijack_Connection(t *testing.T) {
+	testServer, address := NewHijackTestServer(t)
+	conn := DialTestServer(t, address)
+
+	// The first line of the custom protocol is sent along with the request, so that it is already buffered by the server when the connection is hijacked.
+	conn.Write([]byte("GET /shout HTTP/1.1\r\nHost: localhost\r\n\r\nbuffered line\n"))
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, nil)
+	if err != nil || response.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf(internal.TextColor.Red("Expected status 101 from the hijacked connection, but got %v with error %v instead."), response, err)
+		return
+	}
+
+	t.Run("Buffered bytes handed over to the handler", func(tt *testing.T) {
+		line, _ := reader.ReadString('\n')
+		conn.Write([]byte("next line\n"))
+		nextLine, _ := reader.ReadString('\n')
+		if line == "BUFFERED LINE\n" && nextLine == "NEXT LINE\n" {
+			tt.Log("The bytes buffered before and sent after the hijack were both received by the handler as expected.")
+		} else {
+			tt.Errorf(internal.TextColor.Red("Expected the lines [BUFFERED LINE] and [NEXT LINE], but got %q and %q instead."), line, nextLine)
+		}
+	})
+
+	t.Run("Connection no longer tracked by the server", func(tt *testing.T) {
+		stats := testServer.Stats()
+		if stats.ActiveConnections == 0 {
+			tt.Log("The hijacked connection is no longer counted as an active connection as expected.")
+		} else {
+			tt.Errorf(internal.TextColor.Red("Expected no active connections, but got %d instead."), stats.ActiveConnections)
+		}
+	})
+
+	t.Run("Shutdown does not wait for the hijacked connection", func(tt *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 2 * time.Second)
+		defer cancel()
+		err := testServer.Shutdown(ctx)
+		if err != nil {
+			tt.Errorf(internal.TextColor.Red("Expected the server to shutdown gracefully, but got an error instead - %s"), err.Error())
+			return
+		}
+
+		conn.Write([]byte("after shutdown\n"))
+		line, _ := reader.ReadString('\n')
+		if line == "AFTER SHUTDOWN\n" {
+			tt.Log("The server was shutdown while the hijacked connection remained open as expected.")
+		} else {
+			tt.Errorf(internal.TextColor.Red("Expected the hijacked connection to remain open after the shutdown, but got %q instead."), line)
+		}
+	})
+}
+
+// Test case to validate that a connection cannot be hijacked once the response headers have been sent.
+func Test_Hijack_AfterHeaders(t *testing.T) {
+	_, address := NewHijackTestServer(t)
+	response, err := http.Get("http://" + address + "/late")
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while sending the request to the test server: %s"), err.Error())
+		return
+	}
+	defer response.Body.Close()
+	body, _ := io.ReadAll(response.Body)
+
+	if response.StatusCode == http.StatusOK && strings.Contains(string(body), "Connection cannot be hijacked once the response headers have been sent") {
+		t.Log("The connection could not be hijacked after the response headers were sent as expected.")
+	} else {
+		t.Errorf(internal.TextColor.Red("Expected status 200 with the hijack error, but got status %d with body %q instead."), response.StatusCode, string(body))
+	}
+}
@@ -0,0 +1,426 @@
+package test
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"github.com/citadelofcode/proteus/internal"
+)
+
+// Helper function to add the routes used by the HTTP/2 tests to the given server instance.
+func AddHTTP2TestRoutes(t testing.TB, server *internal.HttpServer) {
+	t.Helper()
+	server.Router.Get("/hello/:name", func(request *internal.HttpRequest, response *internal.HttpResponse) {
+		name, _ := request.Segments.Get("name")
+		response.Status(internal.Status200)
+		response.Send(fmt.Sprintf("Hello, %s (HTTP/%s)", name[0], request.Version))
+	})
+	server.Router.Post("/echo", func(request *internal.HttpRequest, response *internal.HttpResponse) {
+		response.Status(internal.Status200)
+		response.Send(string(request.BodyBytes))
+	})
+	server.Router.Get("/stream", func(request *internal.HttpRequest, response *internal.HttpResponse) {
+		response.Status(internal.Status200)
+		response.SetTrailer("X-Checksum", "done")
+		for index := range 3 {
+			response.Stream([]byte(fmt.Sprintf("part-%d;", index)))
+			response.Flush()
+		}
+	})
+}
+
+// Helper function to create a HTTPS test server with the HTTP/2 test routes, served on a random local port.
+func NewHTTP2TLSTestServer(t testing.TB) string {
+	t.Helper()
+	certFile, keyFile, err := CreateCertificate(t, t.TempDir(), "server", []string{ "localhost" })
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while creating test certificate: %s"), err.Error())
+		return ""
+	}
+
+	testServer := NewTestServer(t)
+	AddHTTP2TestRoutes(t, testServer)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while creating test listener: %s"), err.Error())
+		return ""
+	}
+
+	go testServer.ServeTLS(listener, certFile, keyFile)
+	t.Cleanup(func() {
+		testServer.Close()
+	})
+	return listener.Addr().String()
+}
+
+// Test case to validate that HTTPS clients negotiating HTTP/2 using ALPN have their requests processed by the same routes, multiplexed on a single connection.
+func Test_HTTP2_ALPN(t *testing.T) {
+	address := NewHTTP2TLSTestServer(t)
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{ InsecureSkipVerify: true },
+			ForceAttemptHTTP2: true,
+		},
+	}
+	defer client.CloseIdleConnections()
+
+	testCases := []struct {
+		Name string
+		Method string
+		Path string
+		Body string
+		ExpBody string
+	} {
+		{ "Request with a path parameter", "GET", "/hello/proteus", "", "Hello, proteus (HTTP/2.0)" },
+		{ "Request with a body larger than a single frame", "POST", "/echo", strings.Repeat("a", 100000), strings.Repeat("a", 100000) },
+		{ "Request for a streamed response", "GET", "/stream", "", "part-0;part-1;part-2;" },
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(tt *testing.T) {
+			request, _ := http.NewRequest(testCase.Method, "https://" + address + testCase.Path, strings.NewReader(testCase.Body))
+			response, err := client.Do(request)
+			if err != nil {
+				tt.Fatalf(internal.TextColor.Red("Error occurred while sending the request to the test server: %s"), err.Error())
+				return
+			}
+			defer response.Body.Close()
+			body, err := io.ReadAll(response.Body)
+			if err != nil {
+				tt.Fatalf(internal.TextColor.Red("Error occurred while reading the response body: %s"), err.Error())
+				return
+			}
+
+			if response.ProtoMajor != 2 {
+				tt.Errorf(internal.TextColor.Red("Expected the response to be sent over HTTP/2, but got %s instead."), response.Proto)
+			} else if response.StatusCode != http.StatusOK || string(body) != testCase.ExpBody {
+				tt.Errorf(internal.TextColor.Red("Expected status 200 with a body of %d bytes, but got status %d with a body of %d bytes."), len(testCase.ExpBody), response.StatusCode, len(body))
+			} else {
+				tt.Logf("The response to [%s %s] was received over HTTP/2 as expected.", testCase.Method, testCase.Path)
+			}
+
+			if testCase.Path == "/stream" && response.Trailer.Get("X-Checksum") != "done" {
+				tt.Errorf(internal.TextColor.Red("Expected the trailer [X-Checksum] to be sent after the streamed body, but got %v instead."), response.Trailer)
+			}
+		})
+	}
+
+	t.Run("Concurrent requests on a single connection", func(tt *testing.T) {
+		var wg sync.WaitGroup
+		errs := make(chan error, 20)
+		for index := range 20 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				response, err := client.Get(fmt.Sprintf("https://%s/hello/client%d", address, index))
+				if err != nil {
+					errs <- err
+					return
+				}
+				defer response.Body.Close()
+				body, _ := io.ReadAll(response.Body)
+				if string(body) != fmt.Sprintf("Hello, client%d (HTTP/2.0)", index) {
+					errs <- fmt.Errorf("unexpected response body [%s] for client %d", string(body), index)
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		failed := false
+		for err := range errs {
+			failed = true
+			tt.Errorf(internal.TextColor.Red("Concurrent request failed: %s"), err.Error())
+		}
+		if !failed {
+			tt.Log("All the concurrent requests multiplexed on the connection were answered correctly.")
+		}
+	})
+}
+
+// Test case to validate that cleartext HTTP/2 connections started with prior knowledge are processed by the same routes.
+func Test_HTTP2_PriorKnowledge(t *testing.T) {
+	testServer := NewTestServer(t)
+	AddHTTP2TestRoutes(t, testServer)
+	address := ServeTestServer(t, testServer)
+
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{ Protocols: protocols },
+	}
+	defer client.CloseIdleConnections()
+
+	response, err := client.Post("http://" + address + "/echo", "text/plain", strings.NewReader("prior knowledge"))
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while sending the request to the test server: %s"), err.Error())
+		return
+	}
+	defer response.Body.Close()
+	body, _ := io.ReadAll(response.Body)
+
+	if response.ProtoMajor == 2 && string(body) == "prior knowledge" {
+		t.Log("The request sent with HTTP/2 prior knowledge was answered over HTTP/2 as expected.")
+	} else {
+		t.Errorf(internal.TextColor.Red("Expected the body [prior knowledge] over HTTP/2, but got [%s] over %s instead."), string(body), response.Proto)
+	}
+}
+
+// Test case to validate that a cleartext HTTP/1.1 request asking for "Upgrade: h2c" is answered on the first stream of a HTTP/2 connection.
+func Test_HTTP2_Upgrade(t *testing.T) {
+	testServer := NewTestServer(t)
+	AddHTTP2TestRoutes(t, testServer)
+	address := ServeTestServer(t, testServer)
+
+	conn := DialTestServer(t, address)
+
+	conn.Write([]byte("GET /hello/upgrade HTTP/1.1\r\nHost: localhost\r\nConnection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: AAMAAABkAAQAAP__\r\n\r\n"))
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while reading the upgrade response: %s"), err.Error())
+		return
+	}
+	if response.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf(internal.TextColor.Red("Expected status 101 for the upgrade request, but got %d instead."), response.StatusCode)
+		return
+	}
+
+	// The client preface is followed by an empty SETTINGS frame.
+	conn.Write([]byte("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n\x00\x00\x00\x04\x00\x00\x00\x00\x00"))
+	body := make([]byte, 0)
+	for {
+		header := make([]byte, 9)
+		_, err := io.ReadFull(reader, header)
+		if err != nil {
+			t.Fatalf(internal.TextColor.Red("Error occurred while reading frames from the test server: %s"), err.Error())
+			return
+		}
+		payload := make([]byte, int(header[0]) << 16 | int(header[1]) << 8 | int(header[2]))
+		io.ReadFull(reader, payload)
+		streamID := binary.BigEndian.Uint32(header[5:]) & 0x7fffffff
+		// DATA frames for stream 1 carry the response to the upgrade request.
+		if header[3] == 0x0 && streamID == 1 {
+			body = append(body, payload...)
+			if header[4] & 0x1 != 0 {
+				break
+			}
+		}
+	}
+
+	if string(body) == "Hello, upgrade (HTTP/2.0)" {
+		t.Log("The upgrade request was answered on stream 1 of the HTTP/2 connection as expected.")
+	} else {
+		t.Errorf(internal.TextColor.Red("Expected the body [Hello, upgrade (HTTP/2.0)] on stream 1, but got [%s] instead."), string(body))
+	}
+}
+
+// Helper function to open a HTTP/2 connection with prior knowledge to the given address, sending the client preface followed by an empty SETTINGS frame.
+func DialHTTP2(t testing.TB, address string) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	conn := DialTestServer(t, address)
+	conn.Write([]byte("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"))
+	WriteHTTP2Frame(conn, 0x4, 0, 0, nil)
+	return conn, bufio.NewReader(conn)
+}
+
+// Helper function to write a single HTTP/2 frame to the server.
+func WriteHTTP2Frame(conn net.Conn, frameType byte, flags byte, streamID uint32, payload []byte) {
+	frame := []byte{ byte(len(payload) >> 16), byte(len(payload) >> 8), byte(len(payload)), frameType, flags }
+	frame = binary.BigEndian.AppendUint32(frame, streamID)
+	conn.Write(append(frame, payload...))
+}
+
+// Helper function to read a single HTTP/2 frame sent by the server and return its type, flags, stream ID and payload.
+func ReadHTTP2Frame(reader *bufio.Reader) (byte, byte, uint32, []byte, error) {
+	header := make([]byte, 9)
+	_, err := io.ReadFull(reader, header)
+	if err != nil {
+		return 0, 0, 0, nil, err
+	}
+	payload := make([]byte, int(header[0]) << 16 | int(header[1]) << 8 | int(header[2]))
+	_, err = io.ReadFull(reader, payload)
+	return header[3], header[4], binary.BigEndian.Uint32(header[5:]) & 0x7fffffff, payload, err
+}
+
+// Helper function to encode the given header fields as an HPACK header block, using literal fields that are not added to the dynamic table.
+func EncodeHTTP2Headers(fields [][2]string) []byte {
+	block := make([]byte, 0)
+	for _, field := range fields {
+		block = append(block, 0x00, byte(len(field[0])))
+		block = append(block, field[0]...)
+		block = append(block, byte(len(field[1])))
+		block = append(block, field[1]...)
+	}
+	return block
+}
+
+// Test case to validate that the flow control windows for the data received from the client are reduced by every DATA frame, and restored only once half of them has been used.
+func Test_HTTP2_FlowControl(t *testing.T) {
+	testServer := NewTestServer(t)
+	AddHTTP2TestRoutes(t, testServer)
+	address := ServeTestServer(t, testServer)
+	conn, reader := DialHTTP2(t, address)
+
+	frameCount := 40
+	WriteHTTP2Frame(conn, 0x1, 0x4, 1, EncodeHTTP2Headers([][2]string{ { ":method", "POST" }, { ":scheme", "http" }, { ":path", "/echo" }, { ":authority", "localhost" } }))
+	go func() {
+		for index := range frameCount {
+			flags := byte(0)
+			if index == frameCount - 1 {
+				flags = 0x1
+			}
+			WriteHTTP2Frame(conn, 0x0, flags, 1, make([]byte, 16384))
+		}
+	}()
+
+	windowUpdates := map[uint32]int{}
+	bodyLength := 0
+	for {
+		frameType, flags, streamID, payload, err := ReadHTTP2Frame(reader)
+		if err != nil {
+			t.Fatalf(internal.TextColor.Red("Error occurred while reading frames from the test server: %s"), err.Error())
+			return
+		}
+		if frameType == 0x8 {
+			windowUpdates[streamID] += 1
+		}
+		if frameType == 0x0 && streamID == 1 {
+			bodyLength += len(payload)
+			if flags & 0x1 != 0 {
+				break
+			}
+			// The echoed body is larger than the default flow control windows of the client.
+			if len(payload) > 0 {
+				increment := binary.BigEndian.AppendUint32(nil, uint32(len(payload)))
+				WriteHTTP2Frame(conn, 0x8, 0, 0, increment)
+				WriteHTTP2Frame(conn, 0x8, 0, 1, increment)
+			}
+		}
+	}
+
+	if bodyLength != frameCount * 16384 {
+		t.Errorf(internal.TextColor.Red("Expected the echoed body to have %d bytes, but got %d bytes instead."), frameCount * 16384, bodyLength)
+	}
+	if windowUpdates[0] <= 2 && windowUpdates[1] <= 2 {
+		t.Logf("The %d DATA frames were acknowledged with %d connection and %d stream WINDOW_UPDATE frames as expected.", frameCount, windowUpdates[0], windowUpdates[1])
+	} else {
+		t.Errorf(internal.TextColor.Red("Expected at most 2 WINDOW_UPDATE frames for the connection and the stream, but got %v instead."), windowUpdates)
+	}
+}
+
+// Test case to validate that a header block split across CONTINUATION frames is capped even when the size of the request headers is not limited.
+func Test_HTTP2_ContinuationLimit(t *testing.T) {
+	testServer := NewTestServer(t)
+	testServer.Limits.MaxHeaderBytes = 0
+	AddHTTP2TestRoutes(t, testServer)
+	address := ServeTestServer(t, testServer)
+	conn, reader := DialHTTP2(t, address)
+
+	WriteHTTP2Frame(conn, 0x1, 0, 1, EncodeHTTP2Headers([][2]string{ { ":method", "GET" }, { ":scheme", "http" }, { ":path", "/hello/limit" }, { ":authority", "localhost" } }))
+	go func() {
+		for range 100 {
+			WriteHTTP2Frame(conn, 0x9, 0, 1, make([]byte, 16384))
+		}
+	}()
+
+	for {
+		frameType, _, _, payload, err := ReadHTTP2Frame(reader)
+		if err != nil {
+			t.Errorf(internal.TextColor.Red("Expected a GOAWAY frame for the oversized header block, but got this error instead - %s"), err.Error())
+			return
+		}
+		if frameType == 0x7 {
+			t.Logf("The oversized header block was rejected with a GOAWAY frame carrying the error code %d as expected.", binary.BigEndian.Uint32(payload[4:8]))
+			return
+		}
+	}
+}
+
+// Test case to validate that the body of a request to a route streaming the request body is passed to the handler as it arrives, and that the stream window is restored only as the handler reads the body.
+func Test_HTTP2_StreamBody(t *testing.T) {
+	testServer := NewTestServer(t)
+	release := make(chan struct{})
+	testServer.Router.Post("/upload", func(request *internal.HttpRequest, response *internal.HttpResponse) {
+		<-release
+		body, err := io.ReadAll(request.BodyReader())
+		if err != nil {
+			response.Status(internal.Status400)
+			response.Send(err.Error())
+			return
+		}
+		response.Status(internal.Status200)
+		response.Send(fmt.Sprintf("%d", len(body)))
+	})
+	testServer.Router.StreamBody("POST", "/upload")
+	address := ServeTestServer(t, testServer)
+	conn, reader := DialHTTP2(t, address)
+
+	// The whole stream window is sent while the handler is not reading the body, followed by a PING to know when all the frames have been processed.
+	frameCount := 64
+	WriteHTTP2Frame(conn, 0x1, 0x4, 1, EncodeHTTP2Headers([][2]string{ { ":method", "POST" }, { ":scheme", "http" }, { ":path", "/upload" }, { ":authority", "localhost" } }))
+	for range frameCount {
+		WriteHTTP2Frame(conn, 0x0, 0, 1, make([]byte, 16384))
+	}
+	WriteHTTP2Frame(conn, 0x6, 0, 0, make([]byte, 8))
+
+	for {
+		frameType, flags, streamID, _, err := ReadHTTP2Frame(reader)
+		if err != nil {
+			t.Fatalf(internal.TextColor.Red("Error occurred while reading frames from the test server: %s"), err.Error())
+			return
+		}
+		if frameType == 0x8 && streamID == 1 {
+			t.Error(internal.TextColor.Red("Expected no WINDOW_UPDATE frame for the stream before the handler reads the body, but got one instead."))
+		}
+		if frameType == 0x6 && flags & 0x1 != 0 {
+			break
+		}
+	}
+
+	// The stream window is restored once the handler reads the body, after which the client can send the rest of the body.
+	close(release)
+	for {
+		frameType, _, streamID, _, err := ReadHTTP2Frame(reader)
+		if err != nil {
+			t.Fatalf(internal.TextColor.Red("Expected a WINDOW_UPDATE frame for the stream once the handler reads the body, but got this error instead - %s"), err.Error())
+			return
+		}
+		if frameType == 0x8 && streamID == 1 {
+			break
+		}
+	}
+	WriteHTTP2Frame(conn, 0x0, 0x1, 1, []byte("last-part"))
+
+	responseBody := ""
+	for {
+		frameType, flags, streamID, payload, err := ReadHTTP2Frame(reader)
+		if err != nil {
+			t.Fatalf(internal.TextColor.Red("Error occurred while reading the response from the test server: %s"), err.Error())
+			return
+		}
+		if frameType == 0x0 && streamID == 1 {
+			responseBody += string(payload)
+			if flags & 0x1 != 0 {
+				break
+			}
+		}
+	}
+
+	expected := fmt.Sprintf("%d", frameCount * 16384 + len("last-part"))
+	if responseBody == expected {
+		t.Logf("The streamed request body of %s bytes was read by the handler as expected.", responseBody)
+	} else {
+		t.Errorf(internal.TextColor.Red("Expected the handler to read %s bytes, but got %q instead."), expected, responseBody)
+	}
+}
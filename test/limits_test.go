@@ -0,0 +1,225 @@
+package test
+
+import (
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+	"github.com/citadelofcode/proteus/internal"
+)
+
+// Helper function to create a test server with a single GET route and the given connection limits, served on a random local port.
+func NewLimitsTestServer(t testing.TB, configure func(*internal.HttpServer)) (*internal.HttpServer, string) {
+	t.Helper()
+	testServer := NewTestServer(t)
+	testServer.Router.Get("/hello", func(request *internal.HttpRequest, response *internal.HttpResponse) {
+		response.Status(internal.Status200)
+		response.Send("Hello!")
+	})
+	configure(testServer)
+	return testServer, ServeTestServer(t, testServer)
+}
+
+// Helper function to wait until the statistics of the given server satisfy the given condition.
+func WaitForStats(t testing.TB, server *internal.HttpServer, condition func(internal.ServerStats) bool) bool {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if condition(server.Stats()) {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+// Test case to validate that connections over the configured limits are rejected with "503 - Service Unavailable" and a "Retry-After" header.
+func Test_Limits_RejectConnections(t *testing.T) {
+	testCases := []struct {
+		Name string
+		Configure func(*internal.HttpServer)
+	} {
+		{ "Maximum number of connections reached", func(server *internal.HttpServer) { server.MaxConnections = 1 } },
+		{ "Maximum number of connections per IP address reached", func(server *internal.HttpServer) { server.MaxConnectionsPerIP = 1 } },
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(tt *testing.T) {
+			server, address := NewLimitsTestServer(tt, testCase.Configure)
+			DialTestServer(tt, address)
+
+			if !WaitForStats(tt, server, func(stats internal.ServerStats) bool { return stats.ActiveConnections == 1 }) {
+				tt.Fatalf(internal.TextColor.Red("The first connection was not admitted by the test server - %#v"), server.Stats())
+				return
+			}
+
+			secondConn := DialTestServer(tt, address)
+
+			secondConn.Write([]byte("GET /hello HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+			responseBytes, _ := io.ReadAll(secondConn)
+			response := string(responseBytes)
+			if strings.HasPrefix(response, "HTTP/1.1 503") && strings.Contains(response, "Retry-After: 5\r\n") {
+				tt.Log("The connection over the limit was rejected with 503 and a Retry-After header as expected.")
+			} else {
+				tt.Errorf(internal.TextColor.Red("The connection over the limit was expected to be rejected with 503 and a Retry-After header, but got this instead - %q"), response)
+			}
+
+			if WaitForStats(tt, server, func(stats internal.ServerStats) bool { return stats.RejectedConnections == 1 && stats.ActiveConnections == 1 }) {
+				tt.Log("The server statistics report the rejected connection as expected.")
+			} else {
+				tt.Errorf(internal.TextColor.Red("The server statistics do not report the rejected connection - %#v"), server.Stats())
+			}
+		})
+	}
+}
+
+// Test case to validate that connections over the maximum number of connections are queued until an active connection is closed.
+func Test_Limits_QueueConnections(t *testing.T) {
+	server, address := NewLimitsTestServer(t, func(server *internal.HttpServer) {
+		server.MaxConnections = 1
+		server.OverloadPolicy = internal.OVERLOAD_QUEUE
+	})
+
+	firstConn := DialTestServer(t, address)
+
+	if !WaitForStats(t, server, func(stats internal.ServerStats) bool { return stats.ActiveConnections == 1 }) {
+		t.Fatalf(internal.TextColor.Red("The first connection was not admitted by the test server - %#v"), server.Stats())
+		return
+	}
+
+	secondConn := DialTestServer(t, address)
+	secondConn.Write([]byte("GET /hello HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n"))
+
+	if !WaitForStats(t, server, func(stats internal.ServerStats) bool { return stats.QueuedConnections == 1 }) {
+		t.Fatalf(internal.TextColor.Red("The second connection was not queued by the test server - %#v"), server.Stats())
+		return
+	}
+
+	firstConn.Close()
+	responseBytes, _ := io.ReadAll(secondConn)
+	if strings.HasPrefix(string(responseBytes), "HTTP/1.1 200") {
+		t.Log("The queued connection was admitted and its request processed once the active connection was closed.")
+	} else {
+		t.Errorf(internal.TextColor.Red("The queued connection was expected to be answered with 200, but got this instead - %q"), string(responseBytes))
+	}
+}
+
+// Connection with a fixed remote address, used to open connections from different client IP addresses.
+type AddressedConn struct {
+	net.Conn
+	Address net.Addr
+}
+
+// Returns the fixed remote address of the connection.
+func (conn *AddressedConn) RemoteAddr() net.Addr {
+	return conn.Address
+}
+
+// Helper function to create a connection with the given client IP address as its remote address. The connection is closed once the test completes.
+func NewAddressedConn(t testing.TB, clientIP string) *AddressedConn {
+	t.Helper()
+	clientConn, serverConn := net.Pipe()
+	t.Cleanup(func() {
+		clientConn.Close()
+		serverConn.Close()
+	})
+	return &AddressedConn{ Conn: serverConn, Address: &net.TCPAddr{ IP: net.ParseIP(clientIP), Port: 40000 } }
+}
+
+// Test case to validate that connections waiting in the queue count towards the limit of their client IP address, and are not admitted over that limit.
+func Test_Limits_QueuePerIP(t *testing.T) {
+	t.Run("Second queued connection from the same IP address", func(tt *testing.T) {
+		watcher := new(internal.ConnectionWatcher)
+		watcher.SetLimits(1, 1)
+		watcher.TryAdd(NewAddressedConn(tt, "10.0.0.1"))
+		first := NewAddressedConn(tt, "10.0.0.2")
+		if added, ipLimitReached := watcher.TryAdd(first); added || ipLimitReached {
+			tt.Fatal(internal.TextColor.Red("The first connection from the IP address was expected to wait for an active connection to be closed."))
+			return
+		}
+		watcher.Enqueue(first, 10)
+
+		added, ipLimitReached := watcher.TryAdd(NewAddressedConn(tt, "10.0.0.2"))
+		if !added && ipLimitReached {
+			tt.Log("The second connection from the IP address was refused for the limit of the IP address as expected.")
+		} else {
+			tt.Errorf(internal.TextColor.Red("Expected the second connection to reach the limit of the IP address, but got added=%t and ipLimitReached=%t instead."), added, ipLimitReached)
+		}
+	})
+
+	t.Run("Queued connections admitted once an active connection is closed", func(tt *testing.T) {
+		watcher := new(internal.ConnectionWatcher)
+		watcher.SetLimits(1, 0)
+		active := NewAddressedConn(tt, "10.0.0.1")
+		watcher.TryAdd(active)
+		queued := []*AddressedConn{ NewAddressedConn(tt, "10.0.0.2"), NewAddressedConn(tt, "10.0.0.2"), NewAddressedConn(tt, "10.0.0.3") }
+		for _, conn := range queued {
+			watcher.Enqueue(conn, 10)
+		}
+
+		watcher.SetLimits(3, 1)
+		watcher.Remove(active)
+		stats := watcher.GetStats()
+		if stats.ActiveConnections == 2 && stats.QueuedConnections == 1 && stats.ConnectionsPerIP["10.0.0.2"] == 1 && stats.ConnectionsPerIP["10.0.0.3"] == 1 {
+			tt.Log("The queued connections were admitted without going over the limit of their IP address as expected.")
+		} else {
+			tt.Errorf(internal.TextColor.Red("Expected one admitted connection for each IP address and one queued connection, but got %#v instead."), stats)
+		}
+	})
+}
+
+// Test case to validate that requests exceeding the configured size limits are answered with the matching status code.
+func Test_Limits_RequestSize(t *testing.T) {
+	_, address := NewLimitsTestServer(t, func(server *internal.HttpServer) {
+		server.Limits = internal.RequestLimits{ MaxRequestLineLength: 64, MaxHeaderBytes: 128, MaxHeaderCount: 4, MaxBodySize: 16 }
+		handler := func(request *internal.HttpRequest, response *internal.HttpResponse) {
+			response.Status(internal.Status200)
+			response.Send("Uploaded!")
+		}
+		server.Router.Post("/upload", handler)
+		server.Router.Post("/large", handler)
+		server.Router.Post("/small", handler)
+		server.Router.Limit("POST", "/large", internal.RequestLimits{ MaxBodySize: 64 })
+		server.Router.Limit("POST", "/small", internal.RequestLimits{ MaxBodySize: 4, MaxHeaderCount: 2 })
+		server.Router.Post("/unlimited", handler)
+		server.Router.Post("/headers/:name", handler)
+		server.Router.Limit("POST", "/unlimited", internal.RequestLimits{ MaxBodySize: internal.NO_LIMIT })
+		server.Router.Limit("POST", "/headers/:name", internal.RequestLimits{ MaxRequestLineLength: 256, MaxHeaderBytes: 512, MaxHeaderCount: 8 })
+	})
+
+	testCases := []struct {
+		Name string
+		Request string
+		ExpStatus string
+	} {
+		{ "Request line within the limit", "GET /hello HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n", "200" },
+		{ "Request line longer than the limit", "GET /" + strings.Repeat("a", 64) + " HTTP/1.1\r\nHost: localhost\r\n\r\n", "414" },
+		{ "Header bytes larger than the limit", "GET /hello HTTP/1.1\r\nHost: localhost\r\nX-Large: " + strings.Repeat("a", 128) + "\r\n\r\n", "431" },
+		{ "Header count larger than the limit", "GET /hello HTTP/1.1\r\nHost: localhost\r\nA: 1\r\nB: 2\r\nC: 3\r\nD: 4\r\n\r\n", "431" },
+		{ "Body with a content length larger than the limit", "POST /upload HTTP/1.1\r\nHost: localhost\r\nContent-Length: 17\r\n\r\n" + strings.Repeat("a", 17), "413" },
+		{ "Chunked body larger than the limit", "POST /upload HTTP/1.1\r\nHost: localhost\r\nTransfer-Encoding: chunked\r\n\r\na\r\n0123456789\r\na\r\n0123456789\r\n0\r\n\r\n", "413" },
+		{ "Body within the larger limit of the route", "POST /large HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\nContent-Length: 32\r\n\r\n" + strings.Repeat("a", 32), "200" },
+		{ "Body larger than the smaller limit of the route", "POST /small HTTP/1.1\r\nHost: localhost\r\nContent-Length: 5\r\n\r\nabcde", "413" },
+		{ "Header count larger than the smaller limit of the route", "POST /small HTTP/1.1\r\nHost: localhost\r\nA: 1\r\nContent-Length: 0\r\n\r\n", "431" },
+		{ "Body larger than the server limit on a route without a body limit", "POST /unlimited HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\nContent-Length: 64\r\n\r\n" + strings.Repeat("a", 64), "200" },
+		{ "Request line within the larger limit of the route", "POST /headers/" + strings.Repeat("a", 128) + " HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\nContent-Length: 0\r\n\r\n", "200" },
+		{ "Headers within the larger limits of the route", "POST /headers/one HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\nA: 1\r\nB: 2\r\nX-Large: " + strings.Repeat("a", 128) + "\r\nContent-Length: 0\r\n\r\n", "200" },
+		{ "Request line longer than the limit of the route", "POST /headers/" + strings.Repeat("a", 256) + " HTTP/1.1\r\nHost: localhost\r\nContent-Length: 0\r\n\r\n", "414" },
+		{ "Header count larger than the server limit on another route", "GET /hello HTTP/1.1\r\nHost: localhost\r\nA: 1\r\nB: 2\r\nC: 3\r\nD: 4\r\nE: 5\r\nF: 6\r\n\r\n", "431" },
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(tt *testing.T) {
+			conn := DialTestServer(tt, address)
+
+			conn.Write([]byte(testCase.Request))
+			responseBytes, _ := io.ReadAll(conn)
+			if strings.HasPrefix(string(responseBytes), "HTTP/1.1 " + testCase.ExpStatus) {
+				tt.Logf("The server answered the request with the expected status code - %s.", testCase.ExpStatus)
+			} else {
+				tt.Errorf(internal.TextColor.Red("The server was expected to answer the request with %s, but sent this instead - %q"), testCase.ExpStatus, string(responseBytes))
+			}
+		})
+	}
+}
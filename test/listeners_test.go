@@ -0,0 +1,86 @@
+package test
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"github.com/citadelofcode/proteus/internal"
+)
+
+// Test case to validate that a server can accept requests over a Unix domain socket.
+func Test_Listener_UnixSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "proteus.sock")
+	err := os.WriteFile(socketPath, []byte{}, 0644)
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while creating a regular file at the socket path: %s"), err.Error())
+		return
+	}
+
+	_, err = internal.NewListener("unix:" + socketPath)
+	if err != nil {
+		t.Logf("A regular file at the socket path was not removed as expected - %s", err.Error())
+	} else {
+		t.Error(internal.TextColor.Red("A regular file at the socket path was removed while creating the Unix domain socket."))
+	}
+	os.Remove(socketPath)
+
+	listener, err := internal.NewListener("unix:" + socketPath)
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while creating Unix domain socket listener: %s"), err.Error())
+		return
+	}
+
+	testServer := NewTestServer(t)
+	testServer.Router.Get("/hello", func(request *internal.HttpRequest, response *internal.HttpResponse) {
+		response.Status(internal.Status200)
+		response.Send("Hello over a Unix socket!")
+	})
+	go testServer.Serve(listener)
+	defer testServer.Close()
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while connecting to the Unix domain socket: %s"), err.Error())
+		return
+	}
+	defer conn.Close()
+
+	conn.Write([]byte("GET /hello HTTP/1.0\r\n\r\n"))
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	responseBytes, _ := io.ReadAll(conn)
+	if strings.HasPrefix(string(responseBytes), "HTTP/1.0 200 OK") && strings.HasSuffix(string(responseBytes), "Hello over a Unix socket!") {
+		t.Log("The server processed the request received over the Unix domain socket as expected.")
+	} else {
+		t.Errorf(internal.TextColor.Red("The response received over the Unix domain socket was not as expected - %q"), string(responseBytes))
+	}
+}
+
+// Test case to validate that no listeners are returned when the process was not socket activated.
+func Test_Listener_SystemdWithoutActivation(t *testing.T) {
+	t.Setenv("LISTEN_FDS", "")
+	t.Setenv("LISTEN_PID", "")
+	listeners, err := internal.SystemdListeners()
+	if err != nil {
+		t.Errorf(internal.TextColor.Red("Was not expecting an error, but yet got one - %#v"), err)
+		return
+	}
+
+	if len(listeners) == 0 {
+		t.Log("No listeners were returned for a process that was not socket activated.")
+	} else {
+		t.Errorf(internal.TextColor.Red("Expected no listeners, but got %d listeners instead."), len(listeners))
+	}
+
+	t.Setenv("LISTEN_FDS", "1")
+	t.Setenv("LISTEN_PID", "1")
+	listeners, err = internal.SystemdListeners()
+	if err == nil && len(listeners) == 0 {
+		t.Log("No listeners were returned when the sockets were passed to a different process.")
+	} else {
+		t.Errorf(internal.TextColor.Red("Expected no listeners for sockets passed to a different process, but got %d listeners and error %v."), len(listeners), err)
+	}
+}
@@ -0,0 +1,55 @@
+//go:build unix
+
+package test
+
+import (
+	"net"
+	"strconv"
+	"syscall"
+	"testing"
+	"github.com/citadelofcode/proteus/internal"
+)
+
+// Test case to validate the creation of listeners from inherited file descriptors.
+func Test_Listener_FromFd(t *testing.T) {
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while creating test listener: %s"), err.Error())
+		return
+	}
+	defer tcpListener.Close()
+
+	file, err := tcpListener.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while fetching the listener file descriptor: %s"), err.Error())
+		return
+	}
+	defer file.Close()
+
+	// The listener takes ownership of the file descriptor given, so a duplicate is passed to keep the file open.
+	fd, err := syscall.Dup(int(file.Fd()))
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while duplicating the listener file descriptor: %s"), err.Error())
+		return
+	}
+
+	listener, err := internal.NewListener("fd:" + strconv.Itoa(fd))
+	if err != nil {
+		t.Errorf(internal.TextColor.Red("Was not expecting an error while creating a listener from the file descriptor, but yet got one - %#v"), err)
+		return
+	}
+	defer listener.Close()
+
+	if listener.Addr().String() == tcpListener.Addr().String() {
+		t.Logf("The listener created from the file descriptor is bound to the expected address [%s].", listener.Addr().String())
+	} else {
+		t.Errorf(internal.TextColor.Red("The listener created from the file descriptor is bound to [%s] instead of [%s]."), listener.Addr().String(), tcpListener.Addr().String())
+	}
+
+	_, err = internal.NewListener("fd:abc")
+	if err != nil {
+		t.Logf("An invalid file descriptor value returned an error as expected - %s", err.Error())
+	} else {
+		t.Error(internal.TextColor.Red("An invalid file descriptor value was expected to return an error."))
+	}
+}
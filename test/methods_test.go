@@ -0,0 +1,246 @@
+package test
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"github.com/citadelofcode/proteus/internal"
+)
+
+// Helper function to create a test server with PATCH, extension method and catch-all method endpoints, served on a random local port.
+// Every endpoint responds with the name of the endpoint followed by the request method.
+func NewMethodsTestServer(t testing.TB) (*internal.HttpServer, string) {
+	t.Helper()
+	testServer := NewTestServer(t)
+	respond := func(name string) internal.RouteHandler {
+		return func(request *internal.HttpRequest, response *internal.HttpResponse) {
+			response.Status(internal.Status200)
+			response.Send(name + " " + request.Method)
+		}
+	}
+	testServer.Router.Patch("/items/:id", respond("patch"))
+	testServer.Router.Handle("PROPFIND", "/dav", respond("handle"))
+	testServer.Router.Handle("MKCOL", "/dav", respond("handle"))
+	testServer.Router.Any("/any", respond("any"))
+	testServer.Router.Get("/any", respond("get"))
+	testServer.Router.Get("/docs", respond("docs"))
+	testServer.Router.Post("/docs", respond("docs"))
+	return testServer, ServeTestServer(t, testServer)
+}
+
+// Test case to validate the routing of requests to the PATCH, extension method and catch-all method endpoints.
+func Test_Methods_Routing(t *testing.T) {
+	testServer, address := NewMethodsTestServer(t)
+	err := testServer.AllowMethods("propfind", "MKCOL")
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while allowing the extension methods: %s"), err.Error())
+		return
+	}
+	testCases := []struct {
+		Name string
+		Method string
+		Path string
+		ExpStatus int
+		ExpBody string
+	} {
+		{ "PATCH request to a PATCH endpoint", "PATCH", "/items/12", http.StatusOK, "patch PATCH" },
+		{ "PROPFIND request to an extension method endpoint", "PROPFIND", "/dav", http.StatusOK, "handle PROPFIND" },
+		{ "MKCOL request to an extension method endpoint", "MKCOL", "/dav", http.StatusOK, "handle MKCOL" },
+		{ "DELETE request to a catch-all method endpoint", "DELETE", "/any", http.StatusOK, "any DELETE" },
+		{ "PROPFIND request to a catch-all method endpoint", "PROPFIND", "/any", http.StatusOK, "any PROPFIND" },
+		{ "GET request to a catch-all method endpoint with a GET endpoint", "GET", "/any", http.StatusOK, "get GET" },
+		{ "Extension method not allowed for the server", "LOCK", "/any", http.StatusMethodNotAllowed, "" },
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(tt *testing.T) {
+			request, _ := http.NewRequest(testCase.Method, "http://" + address + testCase.Path, nil)
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				tt.Errorf(internal.TextColor.Red("Error occurred while sending the request to the test server: %s"), err.Error())
+				return
+			}
+			defer response.Body.Close()
+			body, _ := io.ReadAll(response.Body)
+
+			if response.StatusCode != testCase.ExpStatus {
+				tt.Errorf(internal.TextColor.Red("Expected status %d, but got %d instead."), testCase.ExpStatus, response.StatusCode)
+			} else if testCase.ExpBody != "" && string(body) != testCase.ExpBody {
+				tt.Errorf(internal.TextColor.Red("Expected the response body [%s], but got [%s] instead."), testCase.ExpBody, string(body))
+			} else if testCase.ExpStatus == http.StatusMethodNotAllowed && !strings.Contains(response.Header.Get("Allow"), "PROPFIND, MKCOL") {
+				tt.Errorf(internal.TextColor.Red("Expected the allowed extension methods in the Allow header, but got [%s] instead."), response.Header.Get("Allow"))
+			} else {
+				tt.Logf("The %s request to [%s] was answered with status %d as expected.", testCase.Method, testCase.Path, response.StatusCode)
+			}
+		})
+	}
+}
+
+// Test case to validate that extension methods are rejected until they are allowed for the server, and that invalid methods cannot be registered.
+func Test_Methods_Registration(t *testing.T) {
+	testServer, address := NewMethodsTestServer(t)
+	request, _ := http.NewRequest("PROPFIND", "http://" + address + "/dav", nil)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while sending the request to the test server: %s"), err.Error())
+		return
+	}
+	response.Body.Close()
+	if response.StatusCode == http.StatusMethodNotAllowed {
+		t.Log("The extension method was rejected before being allowed for the server as expected.")
+	} else {
+		t.Errorf(internal.TextColor.Red("Expected status 405 for an extension method not allowed for the server, but got %d instead."), response.StatusCode)
+	}
+
+	err = testServer.Router.Handle("BAD METHOD", "/dav", func(request *internal.HttpRequest, response *internal.HttpResponse) {})
+	if _, ok := err.(*internal.RoutingError); ok {
+		t.Log("The endpoint with an invalid method was rejected with a routing error as expected.")
+	} else {
+		t.Errorf(internal.TextColor.Red("Expected a routing error for an invalid method, but got this instead - %#v"), err)
+	}
+
+	err = testServer.AllowMethods("PROP(FIND)")
+	if err != nil {
+		t.Log("The invalid extension method was not allowed for the server as expected.")
+	} else {
+		t.Error(internal.TextColor.Red("Expected an error while allowing an invalid extension method for the server."))
+	}
+}
+
+// Test case to validate that requests to a route path without an endpoint for the request method are answered with the methods declared for the route path.
+func Test_Methods_NotAllowed(t *testing.T) {
+	_, address := NewMethodsTestServer(t)
+	testCases := []struct {
+		Name string
+		Method string
+		Path string
+		ExpStatus int
+		ExpAllow string
+	} {
+		{ "PUT request to a route path with GET and POST endpoints", "PUT", "/docs", http.StatusMethodNotAllowed, "GET, HEAD, OPTIONS, POST" },
+		{ "GET request to a route path with a PATCH endpoint", "GET", "/items/7", http.StatusMethodNotAllowed, "OPTIONS, PATCH" },
+		{ "GET request to a route path without any endpoint", "GET", "/missing", http.StatusNotFound, "" },
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(tt *testing.T) {
+			request, _ := http.NewRequest(testCase.Method, "http://" + address + testCase.Path, nil)
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				tt.Errorf(internal.TextColor.Red("Error occurred while sending the request to the test server: %s"), err.Error())
+				return
+			}
+			response.Body.Close()
+
+			if response.StatusCode != testCase.ExpStatus || response.Header.Get("Allow") != testCase.ExpAllow {
+				tt.Errorf(internal.TextColor.Red("Expected status %d with the Allow header [%s], but got status %d with [%s] instead."), testCase.ExpStatus, testCase.ExpAllow, response.StatusCode, response.Header.Get("Allow"))
+			} else {
+				tt.Logf("The request was answered with status %d and the Allow header [%s] as expected.", response.StatusCode, response.Header.Get("Allow"))
+			}
+		})
+	}
+}
+
+// Test case to validate that HEAD requests are served by the GET endpoint without the response body, keeping the connection usable for the next request.
+func Test_Methods_AutomaticHead(t *testing.T) {
+	_, address := NewMethodsTestServer(t)
+	conn := DialTestServer(t, address)
+
+	conn.Write([]byte("HEAD /docs HTTP/1.1\r\nHost: localhost\r\n\r\nGET /docs HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	reader := bufio.NewReader(conn)
+	headRequest, _ := http.NewRequest("HEAD", "http://" + address + "/docs", nil)
+	headResponse, err := http.ReadResponse(reader, headRequest)
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while reading the response to the HEAD request: %s"), err.Error())
+		return
+	}
+	headResponse.Body.Close()
+	getResponse, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while reading the response to the GET request: %s"), err.Error())
+		return
+	}
+	body, _ := io.ReadAll(getResponse.Body)
+
+	if headResponse.StatusCode != http.StatusOK || headResponse.ContentLength != int64(len("docs HEAD")) {
+		t.Errorf(internal.TextColor.Red("Expected status 200 with the length of the GET response body for the HEAD request, but got status %d with length %d instead."), headResponse.StatusCode, headResponse.ContentLength)
+	} else if string(body) != "docs GET" {
+		t.Errorf(internal.TextColor.Red("Expected the body [docs GET] for the next request on the connection, but got [%s] instead."), string(body))
+	} else {
+		t.Log("The HEAD request was served by the GET endpoint without a body as expected.")
+	}
+}
+
+// Test case to validate that OPTIONS requests are answered automatically for route paths and for the server as a whole.
+func Test_Methods_AutomaticOptions(t *testing.T) {
+	testServer, address := NewMethodsTestServer(t)
+	testServer.AllowMethods("PROPFIND", "MKCOL")
+	testCases := []struct {
+		Name string
+		Target string
+		ExpAllow string
+	} {
+		{ "OPTIONS request to a route path with GET and POST endpoints", "/docs", "GET, HEAD, OPTIONS, POST" },
+		{ "OPTIONS request to a route path with extension method endpoints", "/dav", "MKCOL, OPTIONS, PROPFIND" },
+		{ "OPTIONS request for the server as a whole", "*", "GET, HEAD, POST, PUT, DELETE, TRACE, OPTIONS, CONNECT, PATCH, PROPFIND, MKCOL" },
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(tt *testing.T) {
+			response, _, err := SendRawRequest(tt, address, "OPTIONS " + testCase.Target + " HTTP/1.1\r\nHost: localhost\r\n\r\n")
+			if err != nil {
+				tt.Errorf(internal.TextColor.Red("Error occurred while reading the response from the test server: %s"), err.Error())
+				return
+			}
+
+			if response.StatusCode != http.StatusNoContent || response.Header.Get("Allow") != testCase.ExpAllow {
+				tt.Errorf(internal.TextColor.Red("Expected status 204 with the Allow header [%s], but got status %d with [%s] instead."), testCase.ExpAllow, response.StatusCode, response.Header.Get("Allow"))
+			} else {
+				tt.Logf("The OPTIONS request was answered with the Allow header [%s] as expected.", response.Header.Get("Allow"))
+			}
+		})
+	}
+}
+
+// Test case to validate that the NotFound and MethodNotAllowed handlers set for the router are used instead of the default error handler.
+func Test_Methods_CustomHandlers(t *testing.T) {
+	testServer, address := NewMethodsTestServer(t)
+	testServer.Router.NotFound(func(request *internal.HttpRequest, response *internal.HttpResponse) {
+		response.Send("nothing at " + request.ResourcePath)
+	})
+	testServer.Router.MethodNotAllowed(func(request *internal.HttpRequest, response *internal.HttpResponse) {
+		allowed, _ := response.Headers.Get("Allow")
+		response.Send("use " + allowed)
+	})
+	testCases := []struct {
+		Name string
+		Method string
+		Path string
+		ExpStatus int
+		ExpBody string
+	} {
+		{ "Request to a route path without any endpoint", "GET", "/missing", http.StatusNotFound, "nothing at /missing" },
+		{ "Request to a route path without an endpoint for the method", "DELETE", "/docs", http.StatusMethodNotAllowed, "use GET, HEAD, OPTIONS, POST" },
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(tt *testing.T) {
+			request, _ := http.NewRequest(testCase.Method, "http://" + address + testCase.Path, nil)
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				tt.Errorf(internal.TextColor.Red("Error occurred while sending the request to the test server: %s"), err.Error())
+				return
+			}
+			defer response.Body.Close()
+			body, _ := io.ReadAll(response.Body)
+
+			if response.StatusCode != testCase.ExpStatus || string(body) != testCase.ExpBody {
+				tt.Errorf(internal.TextColor.Red("Expected status %d with the body [%s], but got status %d with [%s] instead."), testCase.ExpStatus, testCase.ExpBody, response.StatusCode, string(body))
+			} else {
+				tt.Logf("The request was answered by the custom handler with status %d as expected.", response.StatusCode)
+			}
+		})
+	}
+}
@@ -67,3 +67,44 @@ func Test_Params_Get(t *testing.T) {
 		})
 	}
 }
+
+// Test case to validate the typed accessors parsing the first value for a 'key' from the params collection.
+func Test_Params_TypedGet(t *testing.T) {
+	testParams := make(internal.Params)
+	testParams.Add("id", []string{ "-42", "7" })
+	testParams.Add("page", []string{ "3" })
+	testParams.Add("ratio", []string{ "0.75" })
+	testParams.Add("enabled", []string{ "true" })
+	testParams.Add("name", []string{ "proteus" })
+
+	if value, ok := testParams.GetInt("id"); ok && value == -42 {
+		t.Logf("The first value of [id] was parsed as the integer %d as expected.", value)
+	} else {
+		t.Errorf(internal.TextColor.Red("Expected the integer -42 for [id], but got %d (%v) instead."), value, ok)
+	}
+	if value, ok := testParams.GetUint("page"); ok && value == 3 {
+		t.Logf("The first value of [page] was parsed as the unsigned integer %d as expected.", value)
+	} else {
+		t.Errorf(internal.TextColor.Red("Expected the unsigned integer 3 for [page], but got %d (%v) instead."), value, ok)
+	}
+	if value, ok := testParams.GetFloat("ratio"); ok && value == 0.75 {
+		t.Logf("The first value of [ratio] was parsed as the number %v as expected.", value)
+	} else {
+		t.Errorf(internal.TextColor.Red("Expected the number 0.75 for [ratio], but got %v (%v) instead."), value, ok)
+	}
+	if value, ok := testParams.GetBool("enabled"); ok && value {
+		t.Logf("The first value of [enabled] was parsed as the boolean %v as expected.", value)
+	} else {
+		t.Errorf(internal.TextColor.Red("Expected the boolean true for [enabled], but got %v (%v) instead."), value, ok)
+	}
+	if _, ok := testParams.GetInt("name"); !ok {
+		t.Log("A value that is not an integer was reported as such.")
+	} else {
+		t.Error(internal.TextColor.Red("Was not expecting the value of [name] to be parsed as an integer."))
+	}
+	if _, ok := testParams.GetUint("missing"); !ok {
+		t.Log("A missing parameter was reported as such.")
+	} else {
+		t.Error(internal.TextColor.Red("Was not expecting a value for the missing parameter."))
+	}
+}
@@ -0,0 +1,107 @@
+package test
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+	"github.com/citadelofcode/proteus/internal"
+)
+
+// Helper function to create a test server which echoes the path parameter of the request, served on a random local port.
+func NewPersistenceTestServer(t testing.TB) string {
+	t.Helper()
+	testServer := NewTestServer(t)
+	testServer.Router.Get("/echo/:name", func(request *internal.HttpRequest, response *internal.HttpResponse) {
+		name, _ := request.Segments.Get("name")
+		response.Status(internal.Status200)
+		response.Send(name[0])
+	})
+	return ServeTestServer(t, testServer)
+}
+
+// Test case to validate that persistent connections are kept open or closed as per the HTTP version and the connection options sent by the client.
+func Test_Persistence_ConnectionOptions(t *testing.T) {
+	address := NewPersistenceTestServer(t)
+	testCases := []struct {
+		Name string
+		Version string
+		Connection string
+		ExpKeepAlive bool
+		ExpConnection string
+	} {
+		{ "HTTP/1.1 request without connection options", "1.1", "", true, "" },
+		{ "HTTP/1.1 request with the close option", "1.1", "close", false, "close" },
+		{ "HTTP/1.0 request without connection options", "1.0", "", false, "close" },
+		{ "HTTP/1.0 request with the keep-alive option", "1.0", "keep-alive", true, "keep-alive" },
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(tt *testing.T) {
+			conn := DialTestServer(tt, address)
+
+			request := "GET /echo/first HTTP/" + testCase.Version + "\r\nHost: localhost\r\n"
+			if testCase.Connection != "" {
+				request += "Connection: " + testCase.Connection + "\r\n"
+			}
+			conn.Write([]byte(request + "\r\n"))
+			reader := bufio.NewReader(conn)
+			response, err := http.ReadResponse(reader, nil)
+			if err != nil {
+				tt.Fatalf(internal.TextColor.Red("Error occurred while reading the response from the test server: %s"), err.Error())
+				return
+			}
+			io.ReadAll(response.Body)
+
+			// The "close" connection option is reported by the Close field of the response, instead of the Connection header.
+			connection := response.Header.Get("Connection")
+			if response.Close {
+				connection = "close"
+			}
+			if connection != testCase.ExpConnection {
+				tt.Errorf(internal.TextColor.Red("Expected the Connection header to be %q, but got %q instead."), testCase.ExpConnection, connection)
+			}
+
+			// The connection is closed by the server within the deadline only if it is not persistent.
+			conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+			_, err = reader.ReadByte()
+			netErr, isNetErr := err.(net.Error)
+			keptAlive := isNetErr && netErr.Timeout()
+			if keptAlive == testCase.ExpKeepAlive {
+				tt.Logf("The connection was kept open by the server - %t, as expected.", keptAlive)
+			} else {
+				tt.Errorf(internal.TextColor.Red("Expected the connection to be kept open - %t, but got %t instead."), testCase.ExpKeepAlive, keptAlive)
+			}
+		})
+	}
+}
+
+// Test case to validate that pipelined requests are answered in the order in which they were received.
+func Test_Persistence_PipelinedRequests(t *testing.T) {
+	address := NewPersistenceTestServer(t)
+	conn := DialTestServer(t, address)
+
+	names := []string{ "first", "second", "third" }
+	conn.Write([]byte("GET /echo/first HTTP/1.1\r\nHost: localhost\r\n\r\nGET /echo/second HTTP/1.1\r\nHost: localhost\r\n\r\nGET /echo/third HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n"))
+	reader := bufio.NewReader(conn)
+	for index, name := range names {
+		response, err := http.ReadResponse(reader, nil)
+		if err != nil {
+			t.Fatalf(internal.TextColor.Red("Error occurred while reading response %d from the test server: %s"), index + 1, err.Error())
+			return
+		}
+		body, _ := io.ReadAll(response.Body)
+		if string(body) != name {
+			t.Errorf(internal.TextColor.Red("Expected response %d to contain %q, but got %q instead."), index + 1, name, string(body))
+		}
+	}
+
+	_, err := reader.ReadByte()
+	if err == io.EOF {
+		t.Log("The pipelined requests were answered in order and the connection was closed after the last request.")
+	} else {
+		t.Errorf(internal.TextColor.Red("The connection was expected to be closed after the last pipelined request, but got %v instead."), err)
+	}
+}
@@ -0,0 +1,165 @@
+package test
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"github.com/citadelofcode/proteus/internal"
+)
+
+// Helper function to create a test server accepting PROXY protocol headers from the given sources, served on a random local port.
+// The endpoint "/whoami" responds with the client address of the request, followed by the authority TLV field of the PROXY protocol header, if any.
+func NewProxyProtocolTestServer(t testing.TB, sources []string) (*internal.HttpServer, string) {
+	t.Helper()
+	testServer := NewTestServer(t)
+	testServer.ProxyProtocol = true
+	testServer.ProxyProtocolSources = sources
+	testServer.Router.Get("/whoami", func(request *internal.HttpRequest, response *internal.HttpResponse) {
+		content := request.ClientAddress
+		if request.ProxyHeader != nil {
+			authority, ok := request.ProxyHeader.TLV(internal.PROXY_TLV_AUTHORITY)
+			if ok {
+				content += " " + string(authority)
+			}
+		}
+		response.Status(internal.Status200)
+		response.Send(content)
+	})
+	return testServer, ServeTestServer(t, testServer)
+}
+
+// Request sent to the "/whoami" endpoint after the PROXY protocol header, asking for the connection to be closed once it is answered.
+const WhoAmIRequest = "GET /whoami HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n"
+
+// Helper function to build a PROXY protocol v2 header for a TCP over IPv4 connection, with the given command, authority TLV and CRC32C checksum.
+func BuildProxyHeaderV2(command byte, source string, sourcePort int, authority string) []byte {
+	payload := make([]byte, 12)
+	copy(payload[0:4], net.ParseIP(source).To4())
+	copy(payload[4:8], net.ParseIP("10.0.0.1").To4())
+	binary.BigEndian.PutUint16(payload[8:10], uint16(sourcePort))
+	binary.BigEndian.PutUint16(payload[10:12], 443)
+	payload = append(payload, internal.PROXY_TLV_AUTHORITY, 0, byte(len(authority)))
+	payload = append(payload, []byte(authority)...)
+	payload = append(payload, internal.PROXY_TLV_CRC32C, 0, 4, 0, 0, 0, 0)
+
+	header := []byte("\r\n\r\n\x00\r\nQUIT\n")
+	header = append(header, 0x20 | command, 0x11, 0, 0)
+	binary.BigEndian.PutUint16(header[14:16], uint16(len(payload)))
+	header = append(header, payload...)
+	checksum := crc32.Checksum(header, crc32.MakeTable(crc32.Castagnoli))
+	binary.BigEndian.PutUint32(header[len(header) - 4:], checksum)
+	return header
+}
+
+// Test case to validate that the source address sent in the PROXY protocol header of a trusted load balancer is used as the client address.
+func Test_ProxyProtocol_Headers(t *testing.T) {
+	_, address := NewProxyProtocolTestServer(t, []string{ "127.0.0.0/8" })
+	testCases := []struct {
+		Name string
+		Header []byte
+		ExpBody string
+	} {
+		{ "Version 1 header for TCP over IPv4", []byte("PROXY TCP4 203.0.113.7 10.0.0.1 40000 80\r\n"), "203.0.113.7:40000" },
+		{ "Version 1 header for TCP over IPv6", []byte("PROXY TCP6 2001:db8::7 2001:db8::1 40001 80\r\n"), "[2001:db8::7]:40001" },
+		{ "Version 2 header with TLV fields", BuildProxyHeaderV2(0x01, "198.51.100.9", 40002, "api.example.com"), "198.51.100.9:40002 api.example.com" },
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(tt *testing.T) {
+			response, body, err := SendRawRequest(tt, address, string(testCase.Header) + WhoAmIRequest)
+			if err != nil {
+				tt.Errorf(internal.TextColor.Red("Error occurred while sending the request to the test server: %s"), err.Error())
+			} else if response.StatusCode != http.StatusOK || body != testCase.ExpBody {
+				tt.Errorf(internal.TextColor.Red("Expected status 200 with the body [%s], but got status %d with [%s] instead."), testCase.ExpBody, response.StatusCode, body)
+			} else {
+				tt.Logf("The client address [%s] was taken from the PROXY protocol header as expected.", body)
+			}
+		})
+	}
+
+	t.Run("Version 2 header for a local connection", func(tt *testing.T) {
+		_, body, err := SendRawRequest(tt, address, string(BuildProxyHeaderV2(0x00, "198.51.100.9", 40003, "local")) + WhoAmIRequest)
+		host, _, _ := net.SplitHostPort(body)
+		if err == nil && host == "127.0.0.1" {
+			tt.Log("The address of the load balancer was used for a local connection as expected.")
+		} else {
+			tt.Errorf(internal.TextColor.Red("Expected the load balancer address for a local connection, but got [%s] with error %v instead."), body, err)
+		}
+	})
+}
+
+// Test case to validate that connections from trusted sources are closed if they do not start with a valid PROXY protocol header.
+func Test_ProxyProtocol_InvalidHeaders(t *testing.T) {
+	_, address := NewProxyProtocolTestServer(t, []string{ "127.0.0.1" })
+	corrupted := BuildProxyHeaderV2(0x01, "198.51.100.9", 40002, "api.example.com")
+	corrupted[17] ^= 0xFF
+	testCases := []struct {
+		Name string
+		Header []byte
+	} {
+		{ "No header sent", []byte("") },
+		{ "Version 1 header with an invalid address", []byte("PROXY TCP4 203.0.113 10.0.0.1 40000 80\r\n") },
+		{ "Version 1 header with mismatched address families", []byte("PROXY TCP4 2001:db8::7 10.0.0.1 40000 80\r\n") },
+		{ "Version 2 header not matching its checksum", corrupted },
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(tt *testing.T) {
+			_, _, err := SendRawRequest(tt, address, string(testCase.Header) + WhoAmIRequest)
+			if err != nil {
+				tt.Logf("The connection was closed without a response as expected - %s", err.Error())
+			} else {
+				tt.Error(internal.TextColor.Red("Expected the connection to be closed without a response."))
+			}
+		})
+	}
+}
+
+// Test case to validate that the PROXY protocol header is not accepted from untrusted sources, and that per-IP limits use the address sent in the header.
+func Test_ProxyProtocol_Trust(t *testing.T) {
+	t.Run("Header sent by an untrusted source", func(tt *testing.T) {
+		_, address := NewProxyProtocolTestServer(tt, []string{ "10.0.0.0/8" })
+		// The header is read as a malformed request line, which is answered with an error instead of the response of the endpoint.
+		response, body, err := SendRawRequest(tt, address, "PROXY TCP4 203.0.113.7 10.0.0.1 40000 80\r\n" + WhoAmIRequest)
+		if err != nil || (response.StatusCode != http.StatusOK && !strings.Contains(body, "203.0.113.7")) {
+			tt.Log("The header sent by an untrusted source was not accepted as expected.")
+		} else {
+			tt.Errorf(internal.TextColor.Red("Expected the header sent by an untrusted source to be rejected, but got status %d with [%s] instead."), response.StatusCode, body)
+		}
+	})
+
+	t.Run("Connections counted for the address in the header", func(tt *testing.T) {
+		testServer, address := NewProxyProtocolTestServer(tt, []string{ "127.0.0.1" })
+		_, _, err := SendRawRequest(tt, address, "PROXY TCP4 203.0.113.7 10.0.0.1 40000 80\r\nGET /whoami HTTP/1.1\r\nHost: localhost\r\n\r\n")
+		if err != nil {
+			tt.Fatalf(internal.TextColor.Red("Error occurred while reading the response from the test server: %s"), err.Error())
+			return
+		}
+
+		stats := testServer.Stats()
+		if stats.ConnectionsPerIP["203.0.113.7"] == 1 && stats.ConnectionsPerIP["127.0.0.1"] == 0 {
+			tt.Log("The connection was counted for the address sent in the header as expected.")
+		} else {
+			tt.Errorf(internal.TextColor.Red("Expected one connection for [203.0.113.7], but got %v instead."), stats.ConnectionsPerIP)
+		}
+	})
+
+	t.Run("No trusted sources configured", func(tt *testing.T) {
+		testServer := NewTestServer(tt)
+		testServer.ProxyProtocol = true
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			tt.Fatalf(internal.TextColor.Red("Error occurred while creating test listener: %s"), err.Error())
+			return
+		}
+		err = testServer.Serve(listener)
+		if err != nil {
+			tt.Logf("The server could not be started without trusted sources as expected - %s", err.Error())
+		} else {
+			tt.Error(internal.TextColor.Red("Expected an error while serving PROXY protocol connections without trusted sources."))
+		}
+	})
+}
@@ -0,0 +1,378 @@
+package test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"github.com/citadelofcode/proteus/internal"
+)
+
+// Helper function to create an upstream server which answers every request with its name, the request path and the headers of interest for the proxy tests.
+// Requests for "/slow" are answered after the given delay, and requests for "/upload" are answered with the number of body bytes received.
+func NewUpstreamServer(t testing.TB, name string, delay time.Duration) *httptest.Server {
+	t.Helper()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.WriteHeader(http.StatusOK)
+			return
+		case "/slow":
+			time.Sleep(delay)
+		case "/upload":
+			length, _ := io.Copy(io.Discard, r.Body)
+			fmt.Fprintf(w, "%s received %d bytes", name, length)
+			return
+		case "/stream":
+			w.Header().Set("Trailer", "X-Parts")
+			for index := range 3 {
+				fmt.Fprintf(w, "part-%d;", index)
+				w.(http.Flusher).Flush()
+				time.Sleep(delay)
+			}
+			w.Header().Set("X-Parts", "3")
+			return
+		}
+		w.Header().Add("Set-Cookie", "a=1; Expires=Wed, 21 Oct 2037 07:28:00 GMT")
+		w.Header().Add("Set-Cookie", "b=2")
+		w.Header().Set("Connection", "X-Internal")
+		w.Header().Set("X-Internal", "secret")
+		fmt.Fprintf(w, "%s %s %s?%s|xff=%s|fwd=%s|proto=%s|host=%s|cookie=%s|hop=%s", name, r.Method, r.URL.EscapedPath(), r.URL.RawQuery, r.Header.Get("X-Forwarded-For"), r.Header.Get("Forwarded"), r.Header.Get("X-Forwarded-Proto"), r.Host, strings.Join(r.Header.Values("Cookie"), "|"), r.Header.Get("X-Hop"))
+	}))
+	t.Cleanup(upstream.Close)
+	return upstream
+}
+
+// Helper function to create a test server proxying the GET, POST, OPTIONS and TRACE requests for the paths under "/api" to the given proxy handler, served on a random local port.
+func NewProxyTestServer(t testing.TB, proxy *internal.ProxyHandler) string {
+	t.Helper()
+	testServer := NewTestServer(t)
+	testServer.Router.Get("/api/:name", proxy.Handle)
+	testServer.Router.Post("/api/:name", proxy.Handle)
+	testServer.Router.Options("/api/:name", proxy.Handle)
+	testServer.Router.Trace("/api/:name", proxy.Handle)
+	testServer.Router.StreamBody("POST", "/api/:name")
+	t.Cleanup(proxy.Close)
+	return ServeTestServer(t, testServer)
+}
+
+// Helper function to send a GET request for the given path to the given address and return the status code and the body of the response.
+func ProxyGet(t testing.TB, client *http.Client, address string, path string) (int, string) {
+	t.Helper()
+	response, err := client.Get("http://" + address + path)
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while sending the request to the test server: %s"), err.Error())
+		return 0, ""
+	}
+	defer response.Body.Close()
+	body, _ := io.ReadAll(response.Body)
+	return response.StatusCode, string(body)
+}
+
+// Test case to validate that requests are forwarded with the hop-by-hop headers removed and the forwarding headers added, and that the upstream response is sent back.
+func Test_Proxy_Forwarding(t *testing.T) {
+	upstream := NewUpstreamServer(t, "alpha", 0)
+	proxy, err := internal.NewProxyHandler(upstream.URL + "/base")
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while creating the proxy: %s"), err.Error())
+		return
+	}
+	proxy.StripPrefix = "/api"
+	address := NewProxyTestServer(t, proxy)
+
+	response, body, err := SendRawRequest(t, address, "GET /api/items?b=2&a=1 HTTP/1.1\r\nHost: shop.example.com\r\nConnection: keep-alive, X-Hop\r\nX-Hop: drop-me\r\nX-Forwarded-For: 203.0.113.9\r\nCookie: a=1; b=2\r\nCookie: c=3\r\n\r\n")
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while reading the response from the test server: %s"), err.Error())
+		return
+	}
+
+	testCases := []struct {
+		Name string
+		Passed bool
+	} {
+		{ "Path rewritten and query string kept in order", strings.HasPrefix(body, "alpha GET /base/items?b=2&a=1|") },
+		{ "Cookie header lines joined into a single header", strings.Contains(body, "|cookie=a=1; b=2; c=3|") },
+		{ "Client address appended to X-Forwarded-For", strings.Contains(body, "|xff=203.0.113.9, 127.0.0.1|") },
+		{ "Forwarded header added", strings.Contains(body, "|fwd=for=127.0.0.1;host=\"shop.example.com\";proto=http|") },
+		{ "X-Forwarded-Proto header added", strings.Contains(body, "|proto=http|") },
+		{ "Host of the upstream server sent", strings.Contains(body, "|host=" + strings.TrimPrefix(upstream.URL, "http://") + "|") },
+		{ "Header listed in the Connection header removed", strings.HasSuffix(body, "|hop=") },
+		{ "Hop-by-hop header of the response removed", response.Header.Get("X-Internal") == "" },
+		{ "Cookies sent as separate header lines", len(response.Header.Values("Set-Cookie")) == 2 && response.Header.Values("Set-Cookie")[0] == "a=1; Expires=Wed, 21 Oct 2037 07:28:00 GMT" },
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(tt *testing.T) {
+			if testCase.Passed {
+				tt.Log("The request was forwarded as expected.")
+			} else {
+				tt.Errorf(internal.TextColor.Red("The forwarded request or response was not as expected - %q with headers %v"), body, response.Header)
+			}
+		})
+	}
+}
+
+// Test case to validate that TRACE and OPTIONS requests are forwarded with "Max-Forwards" decremented, and are answered by the proxy once it reaches zero.
+func Test_Proxy_MaxForwards(t *testing.T) {
+	upstream := NewUpstreamServer(t, "alpha", 0)
+	proxy, _ := internal.NewProxyHandler(upstream.URL)
+	proxy.StripPrefix = "/api"
+	address := NewProxyTestServer(t, proxy)
+	testCases := []struct {
+		Name string
+		Method string
+		MaxForwards string
+		ExpStatus int
+		ExpPrefix string
+	} {
+		{ "OPTIONS request with hops left", "OPTIONS", "1", http.StatusOK, "alpha OPTIONS /items?" },
+		{ "OPTIONS request with no hops left", "OPTIONS", "0", http.StatusNoContent, "" },
+		{ "TRACE request with hops left", "TRACE", "1", http.StatusOK, "alpha TRACE /items?" },
+		{ "TRACE request with no hops left", "TRACE", "0", http.StatusOK, "TRACE /api/items HTTP/1.1\r\n" },
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(tt *testing.T) {
+			response, body, err := SendTestRequest(tt, testCase.Method, address, "/api/items", "", map[string]string{ "Max-Forwards": testCase.MaxForwards })
+			if err != nil {
+				tt.Errorf(internal.TextColor.Red("Error occurred while sending the request to the test server: %s"), err.Error())
+			} else if response.StatusCode != testCase.ExpStatus || !strings.HasPrefix(body, testCase.ExpPrefix) {
+				tt.Errorf(internal.TextColor.Red("Expected status %d with a body starting with %q, but got status %d with %q instead."), testCase.ExpStatus, testCase.ExpPrefix, response.StatusCode, body)
+			} else {
+				tt.Logf("The %s request was answered with status %d as expected.", testCase.Method, response.StatusCode)
+			}
+		})
+	}
+}
+
+// Test case to validate that request and response bodies are streamed through the proxy, including the response trailers.
+func Test_Proxy_Streaming(t *testing.T) {
+	upstream := NewUpstreamServer(t, "alpha", 100 * time.Millisecond)
+	proxy, _ := internal.NewProxyHandler(upstream.URL)
+	proxy.StripPrefix = "/api"
+	address := NewProxyTestServer(t, proxy)
+	client := &http.Client{ Timeout: 5 * time.Second }
+
+	t.Run("Chunked request body streamed to the upstream server", func(tt *testing.T) {
+		reader, writer := io.Pipe()
+		go func() {
+			for range 64 {
+				writer.Write([]byte(strings.Repeat("x", 1024)))
+			}
+			writer.Close()
+		}()
+		response, err := client.Post("http://" + address + "/api/upload", "application/octet-stream", reader)
+		if err != nil {
+			tt.Fatalf(internal.TextColor.Red("Error occurred while sending the request to the test server: %s"), err.Error())
+			return
+		}
+		defer response.Body.Close()
+		body, _ := io.ReadAll(response.Body)
+		if string(body) == "alpha received 65536 bytes" {
+			tt.Log("The chunked request body was streamed to the upstream server as expected.")
+		} else {
+			tt.Errorf(internal.TextColor.Red("Expected the upstream server to receive 65536 bytes, but got %q instead."), string(body))
+		}
+	})
+
+	t.Run("Response body streamed to the client as it arrives", func(tt *testing.T) {
+		started := time.Now()
+		response, err := client.Get("http://" + address + "/api/stream")
+		if err != nil {
+			tt.Fatalf(internal.TextColor.Red("Error occurred while sending the request to the test server: %s"), err.Error())
+			return
+		}
+		defer response.Body.Close()
+		firstPart := make([]byte, 7)
+		io.ReadFull(response.Body, firstPart)
+		firstPartAfter := time.Since(started)
+		rest, _ := io.ReadAll(response.Body)
+
+		if string(firstPart) + string(rest) != "part-0;part-1;part-2;" {
+			tt.Errorf(internal.TextColor.Red("Expected the body [part-0;part-1;part-2;], but got %q instead."), string(firstPart) + string(rest))
+		} else if firstPartAfter >= 200 * time.Millisecond {
+			tt.Errorf(internal.TextColor.Red("Expected the first part before the upstream response was complete, but it arrived after %s."), firstPartAfter)
+		} else if response.Trailer.Get("X-Parts") != "3" {
+			tt.Errorf(internal.TextColor.Red("Expected the trailer [X-Parts] to be forwarded, but got %v instead."), response.Trailer)
+		} else {
+			tt.Log("The response body was streamed to the client along with its trailers as expected.")
+		}
+	})
+}
+
+// Test case to validate the balancing methods used to choose the upstream server for each request.
+func Test_Proxy_Balancing(t *testing.T) {
+	upstreams := []*httptest.Server{ NewUpstreamServer(t, "alpha", 300 * time.Millisecond), NewUpstreamServer(t, "beta", 300 * time.Millisecond), NewUpstreamServer(t, "gamma", 300 * time.Millisecond) }
+	urls := []string{ upstreams[0].URL, upstreams[1].URL, upstreams[2].URL }
+	client := &http.Client{ Timeout: 5 * time.Second }
+
+	t.Run("Round-robin", func(tt *testing.T) {
+		proxy, _ := internal.NewProxyHandler(urls...)
+		proxy.StripPrefix = "/api"
+		address := NewProxyTestServer(tt, proxy)
+		names := make([]string, 0)
+		for range 6 {
+			_, body := ProxyGet(tt, client, address, "/api/items")
+			name, _, _ := strings.Cut(body, " ")
+			names = append(names, name)
+		}
+		if strings.Join(names, ",") == "alpha,beta,gamma,alpha,beta,gamma" {
+			tt.Log("The upstream servers were chosen one after the other as expected.")
+		} else {
+			tt.Errorf(internal.TextColor.Red("Expected the servers to be chosen in turn, but got %v instead."), names)
+		}
+	})
+
+	t.Run("IP hash", func(tt *testing.T) {
+		proxy, _ := internal.NewProxyHandler(urls...)
+		proxy.Balancing = internal.BALANCE_IP_HASH
+		proxy.StripPrefix = "/api"
+		address := NewProxyTestServer(tt, proxy)
+		names := make(map[string]bool)
+		for range 5 {
+			_, body := ProxyGet(tt, client, address, "/api/items")
+			name, _, _ := strings.Cut(body, " ")
+			names[name] = true
+		}
+		if len(names) == 1 {
+			tt.Log("All the requests from the client were sent to the same upstream server as expected.")
+		} else {
+			tt.Errorf(internal.TextColor.Red("Expected a single upstream server for the client, but got %v instead."), names)
+		}
+	})
+
+	t.Run("Least connections", func(tt *testing.T) {
+		proxy, _ := internal.NewProxyHandler(urls[0], urls[1])
+		proxy.Balancing = internal.BALANCE_LEAST_CONNECTIONS
+		proxy.StripPrefix = "/api"
+		address := NewProxyTestServer(tt, proxy)
+
+		// A slow request keeps one of the servers busy, so the following requests are sent to the other server.
+		var wg sync.WaitGroup
+		var slowBody string
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, slowBody = ProxyGet(tt, client, address, "/api/slow")
+		}()
+		time.Sleep(100 * time.Millisecond)
+		names := make(map[string]bool)
+		for range 3 {
+			_, body := ProxyGet(tt, client, address, "/api/items")
+			name, _, _ := strings.Cut(body, " ")
+			names[name] = true
+		}
+		wg.Wait()
+		slowName, _, _ := strings.Cut(slowBody, " ")
+
+		if len(names) == 1 && !names[slowName] {
+			tt.Log("The requests were sent to the server without a request in progress as expected.")
+		} else {
+			tt.Errorf(internal.TextColor.Red("Expected the requests to avoid the busy server [%s], but they were sent to %v."), slowName, names)
+		}
+	})
+}
+
+// Test case to validate that upstream failures are answered with 502 and 504, and that failed upstream servers are skipped by the passive and active health checks.
+func Test_Proxy_Failures(t *testing.T) {
+	client := &http.Client{ Timeout: 5 * time.Second }
+	healthy := NewUpstreamServer(t, "alpha", 500 * time.Millisecond)
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	t.Run("Unreachable upstream server answered with 502", func(tt *testing.T) {
+		proxy, _ := internal.NewProxyHandler(down.URL)
+		address := NewProxyTestServer(tt, proxy)
+		status, _ := ProxyGet(tt, client, address, "/api/items")
+		if status == http.StatusBadGateway {
+			tt.Log("The request was answered with 502 as expected.")
+		} else {
+			tt.Errorf(internal.TextColor.Red("Expected status 502, but got %d instead."), status)
+		}
+	})
+
+	t.Run("Slow upstream server answered with 504", func(tt *testing.T) {
+		proxy, _ := internal.NewProxyHandler(healthy.URL)
+		proxy.StripPrefix = "/api"
+		proxy.ResponseTimeout = 100 * time.Millisecond
+		address := NewProxyTestServer(tt, proxy)
+		status, _ := ProxyGet(tt, client, address, "/api/slow")
+		if status == http.StatusGatewayTimeout {
+			tt.Log("The request was answered with 504 as expected.")
+		} else {
+			tt.Errorf(internal.TextColor.Red("Expected status 504, but got %d instead."), status)
+		}
+	})
+
+	t.Run("Unreachable upstream server retried and skipped", func(tt *testing.T) {
+		proxy, _ := internal.NewProxyHandler(down.URL, healthy.URL)
+		proxy.StripPrefix = "/api"
+		proxy.MaxFails = 1
+		proxy.FailTimeout = time.Minute
+		address := NewProxyTestServer(tt, proxy)
+		for range 4 {
+			status, body := ProxyGet(tt, client, address, "/api/items")
+			if status != http.StatusOK || !strings.HasPrefix(body, "alpha ") {
+				tt.Errorf(internal.TextColor.Red("Expected every request to be answered by the healthy server, but got status %d with %q."), status, body)
+				return
+			}
+		}
+		tt.Log("The requests were answered by the healthy server as expected.")
+	})
+
+	t.Run("Unhealthy upstream server skipped by the active health checks", func(tt *testing.T) {
+		unhealthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/health" {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			io.WriteString(w, "beta")
+		}))
+		defer unhealthy.Close()
+
+		proxy, _ := internal.NewProxyHandler(unhealthy.URL, healthy.URL)
+		proxy.StripPrefix = "/api"
+		proxy.HealthCheckPath = "/health"
+		proxy.HealthCheckInterval = 50 * time.Millisecond
+		address := NewProxyTestServer(tt, proxy)
+		proxy.StartHealthChecks()
+		time.Sleep(150 * time.Millisecond)
+
+		for range 4 {
+			_, body := ProxyGet(tt, client, address, "/api/items")
+			if !strings.HasPrefix(body, "alpha ") {
+				tt.Errorf(internal.TextColor.Red("Expected every request to be answered by the healthy server, but got %q."), body)
+				return
+			}
+		}
+		tt.Log("The server failing its health check was skipped as expected.")
+	})
+}
+
+// Test case to validate that a WebSocket handshake accepted by the upstream server switches the client connection to relaying the frames in both directions.
+func Test_Proxy_Upgrade(t *testing.T) {
+	wsAddress := NewWebSocketTestServer(t, false)
+	proxy, _ := internal.NewProxyHandler("http://" + wsAddress)
+	testServer := NewTestServer(t)
+	testServer.Router.Get("/echo", proxy.Handle)
+	t.Cleanup(proxy.Close)
+	address := ServeTestServer(t, testServer)
+
+	conn, reader, response := DialWebSocket(t, address, "/echo?token=secret", "Sec-WebSocket-Version: 13\r\n")
+	if response.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf(internal.TextColor.Red("Expected status 101 for the proxied handshake, but got %d instead."), response.StatusCode)
+		return
+	}
+
+	WriteWebSocketFrame(conn, 0x1, true, false, []byte("through the proxy"), true)
+	opcode, _, payload := ReadWebSocketFrame(t, reader)
+	if opcode == 0x1 && string(payload) == "through the proxy" {
+		t.Log("The WebSocket message was relayed through the proxy and echoed back as expected.")
+	} else {
+		t.Errorf(internal.TextColor.Red("Expected a text frame with [through the proxy], but got opcode %d with [%s] instead."), opcode, string(payload))
+	}
+}
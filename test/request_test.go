@@ -103,3 +103,63 @@ func Test_Request_AddHeader(t *testing.T) {
 		})
 	}
 }
+
+// Test case to validate the decoding of request bodies sent using the chunked transfer coding.
+func Test_Request_ChunkedBody(t *testing.T) {
+	testServer := NewTestServer(t)
+	testCases := []struct {
+		Name string
+		InputRequest string
+		ExpBody string
+		ExpTrailerCount int
+		ExpStatus internal.StatusCode
+	} {
+		{ "Chunked body with multiple chunks", "POST /upload HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nHello\r\n7\r\n, World\r\n0\r\n\r\n", "Hello, World", 0, 0 },
+		{ "Chunked body with chunk extensions", "POST /upload HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n5;name=value\r\nHello\r\n0;last\r\n\r\n", "Hello", 0, 0 },
+		{ "Chunked body with trailer fields", "POST /upload HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\nTrailer: Checksum\r\n\r\nA\r\n0123456789\r\n0\r\nChecksum: abc123\r\nContent-Length: 10\r\n\r\n", "0123456789", 1, 0 },
+		{ "Chunked body along with Content-Length header", "POST /upload HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\nContent-Length: 5\r\n\r\n5\r\nHello\r\n0\r\n\r\n", "", 0, internal.Status400 },
+		{ "Transfer coding other than chunked", "POST /upload HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: gzip, chunked\r\n\r\n0\r\n\r\n", "", 0, internal.Status501 },
+		{ "Final transfer coding is not chunked", "POST /upload HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: gzip\r\n\r\n", "", 0, internal.Status400 },
+		{ "Transfer-Encoding header in a HTTP/1.0 request", "POST /upload HTTP/1.0\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n0\r\n\r\n", "", 0, internal.Status400 },
+		{ "Invalid chunk size", "POST /upload HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\nXYZ\r\nHello\r\n0\r\n\r\n", "", 0, internal.Status400 },
+		{ "Chunk data without a CRLF terminator", "POST /upload HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n3\r\nHello\r\n0\r\n\r\n", "", 0, internal.Status400 },
+		{ "Chunk size larger than the data sent without a body size limit", "POST /upload HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n7fffffffffffffff\r\nHello\r\n0\r\n\r\n", "", 0, internal.Status400 },
+		{ "Chunk size overflowing the body length", "POST /upload HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nHello\r\n7fffffffffffffff\r\nHello\r\n0\r\n\r\n", "", 0, internal.Status400 },
+		{ "Content length larger than the body sent without a body size limit", "POST /upload HTTP/1.1\r\nHost: example.com\r\nContent-Length: 9223372036854775807\r\n\r\nHello", "", 0, internal.Status400 },
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(tt *testing.T) {
+			testReq := NewTestRequest(tt, testServer, strings.NewReader(testCase.InputRequest))
+			err := testReq.Read()
+			if testCase.ExpStatus != 0 {
+				parseErr, ok := err.(*internal.RequestParseError)
+				if !ok {
+					tt.Errorf(internal.TextColor.Red("Was expecting a request parse error, but got this instead - %#v"), err)
+				} else if parseErr.GetStatus() != testCase.ExpStatus {
+					tt.Errorf(internal.TextColor.Red("Expected the error status to be %d, but got %d instead"), testCase.ExpStatus, parseErr.GetStatus())
+				} else {
+					tt.Logf("Was expecting a request parse error with status %d and got one - %v", testCase.ExpStatus, parseErr)
+				}
+				return
+			}
+
+			if err != nil {
+				tt.Errorf(internal.TextColor.Red("The given request could not be parsed. Error :: %s"), err.Error())
+				return
+			}
+
+			if string(testReq.BodyBytes) == testCase.ExpBody {
+				tt.Logf("The decoded request body [%s] matches the expected body [%s]", string(testReq.BodyBytes), testCase.ExpBody)
+			} else {
+				tt.Errorf(internal.TextColor.Red("The decoded request body [%s] does not match the expected body [%s]"), string(testReq.BodyBytes), testCase.ExpBody)
+			}
+
+			if testReq.Trailers.Length() == testCase.ExpTrailerCount {
+				tt.Logf("The trailer count [%d] matches the expected trailer count [%d]", testReq.Trailers.Length(), testCase.ExpTrailerCount)
+			} else {
+				tt.Errorf(internal.TextColor.Red("The trailer count [%d] does not match the expected trailer count [%d]"), testReq.Trailers.Length(), testCase.ExpTrailerCount)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 	"bufio"
+	"io"
 	"github.com/citadelofcode/proteus/internal"
 )
 
@@ -127,3 +128,136 @@ func Test_Response_AddHeader(t *testing.T) {
 		})
 	}
 }
+
+// Test case to validate the framing of streamed responses for different versions of HTTP.
+func Test_Response_Stream(t *testing.T) {
+	testServer := NewTestServer(t)
+	testCases := []struct {
+		Name string
+		IpVersion string
+		IpContentLength string
+		IpTrailers map[string]string
+		ExpHeaders []string
+		ExpBody string
+	} {
+		{ "HTTP/1.1 response without a content length", "1.1", "", nil, []string{ "Transfer-Encoding: chunked" }, "5\r\nHello\r\n7\r\n, World\r\n0\r\n\r\n" },
+		{ "HTTP/1.1 response with trailers", "1.1", "", map[string]string{ "Checksum": "abc123" }, []string{ "Transfer-Encoding: chunked", "Trailer: Checksum" }, "5\r\nHello\r\n7\r\n, World\r\n0\r\nChecksum: abc123\r\n\r\n" },
+		{ "HTTP/1.1 response with a content length", "1.1", "12", nil, []string{ "Content-Length: 12" }, "Hello, World" },
+		{ "HTTP/1.0 response without a content length", "1.0", "", nil, []string{ "Connection: close" }, "Hello, World" },
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(tt *testing.T) {
+			var opBuffer bytes.Buffer
+			res := NewTestResponse(tt, testCase.IpVersion, testServer, &opBuffer)
+			res.Status(internal.Status200)
+			if testCase.IpContentLength != "" {
+				res.AddHeader("Content-Length", testCase.IpContentLength)
+			}
+			for key, value := range testCase.IpTrailers {
+				res.SetTrailer(key, value)
+			}
+
+			err := res.SendHeaders()
+			if err != nil {
+				tt.Errorf(internal.TextColor.Red("Was not expecting an error while sending headers and yet got this error - %#v"), err)
+				return
+			}
+			res.Stream([]byte("Hello"))
+			res.Flush()
+			res.Stream([]byte(", World"))
+			err = res.End()
+			if err != nil {
+				tt.Errorf(internal.TextColor.Red("Was not expecting an error while completing the response and yet got this error - %#v"), err)
+				return
+			}
+
+			head, body, found := strings.Cut(opBuffer.String(), "\r\n\r\n")
+			if !found {
+				tt.Errorf(internal.TextColor.Red("The streamed response does not contain the end of the header section - %q"), opBuffer.String())
+				return
+			}
+
+			for _, expHeader := range testCase.ExpHeaders {
+				if strings.Contains(head, expHeader) {
+					tt.Logf("The streamed response contains the expected header [%s].", expHeader)
+				} else {
+					tt.Errorf(internal.TextColor.Red("The streamed response does not contain the expected header [%s] - %q"), expHeader, head)
+				}
+			}
+
+			if body == testCase.ExpBody {
+				tt.Logf("The streamed response body matches the expected body %q.", testCase.ExpBody)
+			} else {
+				tt.Errorf(internal.TextColor.Red("The streamed response body %q does not match the expected body %q."), body, testCase.ExpBody)
+			}
+		})
+	}
+}
+
+// Test case to validate the errors raised when a streamed response is not used as expected.
+func Test_Response_StreamErrors(t *testing.T) {
+	testServer := NewTestServer(t)
+
+	t.Run("Streamed body longer than the content length", func(tt *testing.T) {
+		var opBuffer bytes.Buffer
+		res := NewTestResponse(tt, "1.1", testServer, &opBuffer)
+		res.AddHeader("Content-Length", "2")
+		_, err := res.Stream([]byte("Hello"))
+		respErr, ok := err.(*internal.ResponseError)
+		if ok {
+			tt.Logf("Was expecting a response error and got one - %v", respErr)
+		} else {
+			tt.Errorf(internal.TextColor.Red("Was expecting a response error, but got this error instead - %#v"), err)
+		}
+	})
+
+	t.Run("Streamed body shorter than the content length", func(tt *testing.T) {
+		var opBuffer bytes.Buffer
+		res := NewTestResponse(tt, "1.1", testServer, &opBuffer)
+		res.AddHeader("Content-Length", "10")
+		res.Stream([]byte("Hello"))
+		err := res.End()
+		respErr, ok := err.(*internal.ResponseError)
+		if ok {
+			tt.Logf("Was expecting a response error and got one - %v", respErr)
+		} else {
+			tt.Errorf(internal.TextColor.Red("Was expecting a response error, but got this error instead - %#v"), err)
+		}
+	})
+
+	t.Run("Complete response written after headers were sent", func(tt *testing.T) {
+		var opBuffer bytes.Buffer
+		res := NewTestResponse(tt, "1.1", testServer, &opBuffer)
+		res.SendHeaders()
+		err := res.Send("Hello")
+		respErr, ok := err.(*internal.ResponseError)
+		if ok {
+			tt.Logf("Was expecting a response error and got one - %v", respErr)
+		} else {
+			tt.Errorf(internal.TextColor.Red("Was expecting a response error, but got this error instead - %#v"), err)
+		}
+	})
+}
+
+// Test case to validate that the connection is closed when a streamed response body is shorter than its declared length, instead of being kept open for the next request.
+func Test_Response_ShortStreamedBody(t *testing.T) {
+	testServer := NewTestServer(t)
+	testServer.Router.Get("/short", func(request *internal.HttpRequest, response *internal.HttpResponse) {
+		response.Status(internal.Status200)
+		response.AddHeader("Content-Length", "10")
+		response.Stream([]byte("Hello"))
+	})
+	address := ServeTestServer(t, testServer)
+
+	conn := DialTestServer(t, address)
+	conn.Write([]byte("GET /short HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+
+	output, err := io.ReadAll(conn)
+	_, body, _ := strings.Cut(string(output), "\r\n\r\n")
+	if err == nil && body == "Hello" {
+		t.Log("The connection was closed after the partial body as expected.")
+	} else {
+		t.Errorf(internal.TextColor.Red("Expected the connection to be closed after the body [Hello], but got the body %q with error %v instead."), body, err)
+	}
+}
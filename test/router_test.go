@@ -217,3 +217,87 @@ func Test_Router_DynamicMatch(t *testing.T) {
 		})
 	}
 }
+
+// Test case to validate that catch-all segments are rejected unless they are named and placed at the end of the route path.
+func Test_Router_CatchAllRegistration(t *testing.T) {
+	testRouter := NewTestRouter(t)
+	handler := func(request *internal.HttpRequest, response *internal.HttpResponse) {}
+	testCases := []struct {
+		Name string
+		RoutePath string
+		ExpError bool
+	} {
+		{ "Named catch-all segment at the end", "/files/*filepath", false },
+		{ "Unnamed catch-all segment", "/assets/*", true },
+		{ "Catch-all segment followed by another segment", "/docs/*section/edit", true },
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(tt *testing.T) {
+			err := testRouter.Get(testCase.RoutePath, handler)
+			_, isRoutingErr := err.(*internal.RoutingError)
+			if testCase.ExpError && !isRoutingErr {
+				tt.Errorf(internal.TextColor.Red("Was expecting a routing error for the route [%s], but got this instead - %#v"), testCase.RoutePath, err)
+			} else if !testCase.ExpError && err != nil {
+				tt.Errorf(internal.TextColor.Red("Was not expecting an error for the route [%s], but yet got one - %#v"), testCase.RoutePath, err)
+			} else {
+				tt.Logf("The route [%s] was handled as expected - %v", testCase.RoutePath, err)
+			}
+		})
+	}
+}
+
+// Test case to validate that invalid path parameter constraints, and parameters ambiguous with the ones already declared at the same level, are rejected.
+func Test_Router_ConstraintRegistration(t *testing.T) {
+	testRouter := NewTestRouter(t)
+	handler := func(request *internal.HttpRequest, response *internal.HttpResponse) {}
+	testCases := []struct {
+		Name string
+		RoutePath string
+		ExpError bool
+	} {
+		{ "Unconstrained parameter", "/users/:id", false },
+		{ "Second unconstrained parameter at the same level", "/users/:name", true },
+		{ "Same unconstrained parameter with a different suffix", "/users/:id/profile", false },
+		{ "Parameter with a typed constraint", "/orders/:id<int>", false },
+		{ "Overlapping typed constraint at the same level", "/orders/:number<uint>", true },
+		{ "Overlapping regular expression constraint at the same level", "/orders/:code(\\d{4})", true },
+		{ "Disjoint regular expression constraint at the same level", "/orders/:slug<[a-z-]+>", false },
+		{ "Unconstrained parameter alongside constrained ones", "/orders/:any", false },
+		{ "Overlapping constraint on a different level", "/orders/:id<int>/items/:item<int>", false },
+		{ "Case-insensitive constraint overlapping a disjoint one", "/orders/:upper((?i)[A-Z]+)", true },
+		{ "Second catch-all segment at the same level", "/files/*path", false },
+		{ "Catch-all segment with a different name", "/files/*rest", true },
+		{ "Unknown type used as a regular expression", "/tags/:tag<[a-z]+>", false },
+		{ "Invalid regular expression constraint", "/posts/:id([0-9)", true },
+		{ "Empty constraint", "/posts/:id<>", true },
+		{ "Unnamed constrained parameter", "/posts/:<int>", true },
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(tt *testing.T) {
+			err := testRouter.Get(testCase.RoutePath, handler)
+			_, isRoutingErr := err.(*internal.RoutingError)
+			if testCase.ExpError && !isRoutingErr {
+				tt.Errorf(internal.TextColor.Red("Was expecting a routing error for the route [%s], but got this instead - %#v"), testCase.RoutePath, err)
+			} else if !testCase.ExpError && err != nil {
+				tt.Errorf(internal.TextColor.Red("Was not expecting an error for the route [%s], but yet got one - %#v"), testCase.RoutePath, err)
+			} else {
+				tt.Logf("The route [%s] was handled as expected - %v", testCase.RoutePath, err)
+			}
+		})
+	}
+
+	err := testRouter.Limit("GET", "/orders/:id<int>", internal.RequestLimits{ MaxBodySize: 10 })
+	if err == nil {
+		t.Log("The limits were set for the endpoint declared with a constrained parameter as expected.")
+	} else {
+		t.Errorf(internal.TextColor.Red("Was not expecting an error while setting the limits by the declared route path, but yet got one - %s"), err.Error())
+	}
+	err = testRouter.StreamBody("GET", "/orders/42")
+	if _, isRoutingErr := err.(*internal.RoutingError); isRoutingErr {
+		t.Log("A request route was not resolved to an endpoint declared with a constrained parameter as expected.")
+	} else {
+		t.Errorf(internal.TextColor.Red("Was expecting a routing error for a route path that was not declared, but got this instead - %#v"), err)
+	}
+}
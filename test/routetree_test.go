@@ -1,6 +1,7 @@
 package test
 
 import (
+	"fmt"
 	"slices"
 	"strings"
 	"testing"
@@ -125,3 +126,261 @@ func Test_RouteTree_MatchRoute(t *testing.T) {
 		})
 	}
 }
+
+// Test case to validate that catch-all segments capture the rest of the request route, and are used only when no literal or parameter segment matches.
+func Test_RouteTree_CatchAll(t *testing.T) {
+	pt := internal.EmptyPrefixTree()
+	pt.Insert("/files/*filepath", new(internal.Route))
+	pt.Insert("/files/readme", new(internal.Route))
+	pt.Insert("/files/:name/info", new(internal.Route))
+	pt.Insert("/files/public/*asset", new(internal.Route))
+
+	testCases := []struct {
+		Name string
+		RequestRoute string
+		MappedRoute string
+		ParamName string
+		ParamValue string
+	} {
+		{ "Request route with a single segment after the prefix", "/files/report.pdf", "/files/*filepath", "filepath", "report.pdf" },
+		{ "Request route with nested segments after the prefix", "/files/docs/2024/report.pdf", "/files/*filepath", "filepath", "docs/2024/report.pdf" },
+		{ "Literal segment preferred over the catch-all segment", "/files/readme", "/files/readme", "", "" },
+		{ "Parameter segment preferred over the catch-all segment", "/files/report/info", "/files/:name/info", "name", "report" },
+		{ "Catch-all segment used when the parameter route does not match", "/files/report/history", "/files/*filepath", "filepath", "report/history" },
+		{ "Deepest catch-all segment preferred", "/files/public/css/site.css", "/files/public/*asset", "asset", "css/site.css" },
+		{ "Value spelled like the catch-all key", "/files/*filepath", "/files/*filepath", "filepath", "*filepath" },
+		{ "Value spelled like the parameter key", "/files/:name/info", "/files/:name/info", "name", ":name" },
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(tt *testing.T) {
+			matchInfo := pt.Match(testCase.RequestRoute)
+			if matchInfo.MatchedPath != testCase.MappedRoute {
+				tt.Errorf(internal.TextColor.Red("The matched route [%s] returned does not match the expected route path [%s]"), matchInfo.MatchedPath, testCase.MappedRoute)
+				return
+			}
+
+			values, _ := matchInfo.Segments.Get(testCase.ParamName)
+			if testCase.ParamName != "" && (len(values) != 1 || values[0] != testCase.ParamValue) {
+				tt.Errorf(internal.TextColor.Red("Expected the path parameter [%s] to be [%s], but got %v instead."), testCase.ParamName, testCase.ParamValue, values)
+			} else {
+				tt.Logf("The route [%s] matched [%s] with the expected path parameters %v.", testCase.RequestRoute, matchInfo.MatchedPath, matchInfo.Segments)
+			}
+		})
+	}
+
+	matchInfo := pt.Match("/files")
+	if len(matchInfo.MatchedRoutes) == 0 {
+		t.Log("The catch-all segment did not match a route without any segment for it as expected.")
+	} else {
+		t.Errorf(internal.TextColor.Red("Expected no routes for [/files], but got a match with [%s] instead."), matchInfo.MatchedPath)
+	}
+
+	routes := pt.GetAllRoutes()
+	expRoutes := []string{ "/files/public/*asset", "/files/readme", "/files/:name/info", "/files/*filepath" }
+	if slices.Equal(routes, expRoutes) {
+		t.Logf("The routes %v were listed in the order of their matching precedence as expected.", routes)
+	} else {
+		t.Errorf(internal.TextColor.Red("Expected the routes %v, but got %v instead."), expRoutes, routes)
+	}
+}
+
+// Test case to validate that path parameters with constraints are matched only by the values satisfying them, and take precedence over unconstrained parameters.
+func Test_RouteTree_Constraints(t *testing.T) {
+	pt := internal.EmptyPrefixTree()
+	pt.Insert("/items/:id(\\d+)", new(internal.Route))
+	pt.Insert("/items/:slug<[a-z-]+>", new(internal.Route))
+	pt.Insert("/items/:key", new(internal.Route))
+	pt.Insert("/orders/:uuid<uuid>", new(internal.Route))
+	pt.Insert("/flags/:enabled<bool>/:ratio<float>", new(internal.Route))
+	pt.Insert("/pages/:page<uint>", new(internal.Route))
+
+	testCases := []struct {
+		Name string
+		RequestRoute string
+		MappedRoute string
+		ParamName string
+		ParamValue string
+	} {
+		{ "Value matching the regular expression constraint", "/items/42", "/items/:id(\\d+)", "id", "42" },
+		{ "Value matching the custom type constraint", "/items/blue-shirt", "/items/:slug<[a-z-]+>", "slug", "blue-shirt" },
+		{ "Value matching no constraint uses the unconstrained parameter", "/items/Blue_Shirt", "/items/:key", "key", "Blue_Shirt" },
+		{ "Value matching the uuid constraint", "/orders/123e4567-e89b-12d3-a456-426614174000", "/orders/:uuid<uuid>", "uuid", "123e4567-e89b-12d3-a456-426614174000" },
+		{ "Value not matching the uuid constraint", "/orders/123", "", "", "" },
+		{ "Values matching the bool and float constraints", "/flags/true/0.75", "/flags/:enabled<bool>/:ratio<float>", "ratio", "0.75" },
+		{ "Value not matching the float constraint", "/flags/true/high", "", "", "" },
+		{ "Value matching the uint constraint", "/pages/3", "/pages/:page<uint>", "page", "3" },
+		{ "Value out of the range of the uint constraint", "/pages/99999999999999999999", "", "", "" },
+		{ "Value spelled like a constrained parameter key", "/orders/:uuid<uuid>", "", "", "" },
+		{ "Value spelled like a parameter key with a fallback parameter", "/items/:id(\\d+)", "/items/:key", "key", ":id(\\d+)" },
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(tt *testing.T) {
+			matchInfo := pt.Match(testCase.RequestRoute)
+			if testCase.MappedRoute == "" {
+				if len(matchInfo.MatchedRoutes) == 0 {
+					tt.Logf("The route [%s] did not match any route as expected.", testCase.RequestRoute)
+				} else {
+					tt.Errorf(internal.TextColor.Red("Expected no routes for [%s], but got a match with [%s] instead."), testCase.RequestRoute, matchInfo.MatchedPath)
+				}
+				return
+			}
+
+			if matchInfo.MatchedPath != testCase.MappedRoute {
+				tt.Errorf(internal.TextColor.Red("The matched route [%s] returned does not match the expected route path [%s]"), matchInfo.MatchedPath, testCase.MappedRoute)
+				return
+			}
+
+			values, _ := matchInfo.Segments.Get(testCase.ParamName)
+			if len(values) != 1 || values[0] != testCase.ParamValue {
+				tt.Errorf(internal.TextColor.Red("Expected the path parameter [%s] to be [%s], but got %v instead."), testCase.ParamName, testCase.ParamValue, values)
+			} else {
+				tt.Logf("The route [%s] matched [%s] with the expected path parameters %v.", testCase.RequestRoute, matchInfo.MatchedPath, matchInfo.Segments)
+			}
+		})
+	}
+
+	routes := pt.GetRoutes("/orders/:uuid<uuid>")
+	if len(routes) == 1 {
+		t.Log("The routes of the route path [/orders/:uuid<uuid>] were found by its declared pattern as expected.")
+	} else {
+		t.Errorf(internal.TextColor.Red("Expected a single route for the declared pattern [/orders/:uuid<uuid>], but got %d instead."), len(routes))
+	}
+	if routes := pt.GetRoutes("/orders/123e4567-e89b-12d3-a456-426614174000"); len(routes) == 0 {
+		t.Log("A request route was not resolved by the declared pattern lookup as expected.")
+	} else {
+		t.Errorf(internal.TextColor.Red("Was not expecting a request route to be resolved by the declared pattern lookup, but got %d routes."), len(routes))
+	}
+}
+
+// Test case to validate that the matcher backtracks to the next segment in the order of precedence when the rest of the route does not match, and returns the same match for every call.
+func Test_RouteTree_Backtracking(t *testing.T) {
+	pt := internal.EmptyPrefixTree()
+	routePaths := []string{ "/a/:x/c", "/a/:y/d", "/a/b/e", "/a/:n<int>/c", "/a/*rest", "/users/:id/posts", "/users/me", "/docs/api/v2/index", "/docs/:section/v1/index", "/docs/*page" }
+	for _, routePath := range routePaths {
+		err := pt.Insert(routePath, new(internal.Route))
+		if err != nil {
+			t.Fatalf(internal.TextColor.Red("Was not expecting an error while inserting the route [%s], but yet got one - %s"), routePath, err.Error())
+		}
+	}
+
+	testCases := []struct {
+		Name string
+		RequestRoute string
+		MappedRoute string
+		ParamName string
+		ParamValue string
+	} {
+		{ "First parameter branch matching the rest of the route", "/a/one/c", "/a/:x/c", "x", "one" },
+		{ "Second parameter branch matching the rest of the route", "/a/one/d", "/a/:y/d", "y", "one" },
+		{ "Constrained parameter preferred over the unconstrained one", "/a/42/c", "/a/:n<int>/c", "n", "42" },
+		{ "Unconstrained parameter used when the constrained branch does not match", "/a/42/d", "/a/:y/d", "y", "42" },
+		{ "Literal segment matching the rest of the route", "/a/b/e", "/a/b/e", "", "" },
+		{ "Parameter used when the literal branch does not match", "/a/b/c", "/a/:x/c", "x", "b" },
+		{ "Catch-all segment used when no other branch matches", "/a/b/f", "/a/*rest", "rest", "b/f" },
+		{ "Literal segment without a deeper route", "/users/me/posts", "/users/:id/posts", "id", "me" },
+		{ "Literal branch rejected two levels deeper", "/docs/api/v1/index", "/docs/:section/v1/index", "section", "api" },
+		{ "Literal and parameter branches rejected three levels deeper", "/docs/api/v2/other", "/docs/*page", "page", "api/v2/other" },
+		{ "Literal branch ending on a node without routes", "/docs/api/v2", "/docs/*page", "page", "api/v2" },
+		{ "Route part spelled like a parameter key", "/a/:y/d", "/a/:y/d", "y", ":y" },
+		{ "Route part spelled like a constrained parameter key", "/a/:n<int>/c", "/a/:x/c", "x", ":n<int>" },
+		{ "Route part spelled like the catch-all key", "/a/*rest/c", "/a/:x/c", "x", "*rest" },
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(tt *testing.T) {
+			for attempt := 0; attempt < 50; attempt++ {
+				matchInfo := pt.Match(testCase.RequestRoute)
+				if matchInfo.MatchedPath != testCase.MappedRoute {
+					tt.Errorf(internal.TextColor.Red("Attempt %d: The matched route [%s] returned does not match the expected route path [%s]"), attempt, matchInfo.MatchedPath, testCase.MappedRoute)
+					return
+				}
+
+				values, _ := matchInfo.Segments.Get(testCase.ParamName)
+				if testCase.ParamName != "" && (len(values) != 1 || values[0] != testCase.ParamValue) {
+					tt.Errorf(internal.TextColor.Red("Attempt %d: Expected the path parameter [%s] to be [%s], but got %v instead."), attempt, testCase.ParamName, testCase.ParamValue, values)
+					return
+				}
+				if matchInfo.Segments.Length() > 1 {
+					tt.Errorf(internal.TextColor.Red("Attempt %d: Expected the path parameters of the failed branches to be discarded, but got %v instead."), attempt, matchInfo.Segments)
+					return
+				}
+			}
+			tt.Logf("The route [%s] matched [%s] on every attempt as expected.", testCase.RequestRoute, testCase.MappedRoute)
+		})
+	}
+
+	err := pt.Insert("/a/:z/c", new(internal.Route))
+	if _, isRoutingErr := err.(*internal.RoutingError); isRoutingErr {
+		t.Logf("The route [/a/:z/c] ambiguous with [/a/:x/c] was rejected as expected - %s", err.Error())
+	} else {
+		t.Errorf(internal.TextColor.Red("Was expecting a routing error for the ambiguous route [/a/:z/c], but got this instead - %#v"), err)
+	}
+
+	routes := pt.GetAllRoutes()
+	expRoutes := []string{ "/a/b/e", "/a/:n<int>/c", "/a/:x/c", "/a/:y/d", "/a/*rest", "/docs/api/v2/index", "/docs/:section/v1/index", "/docs/*page", "/users/me", "/users/:id/posts" }
+	if slices.Equal(routes, expRoutes) {
+		t.Logf("The routes %v were listed in the order of their matching precedence as expected.", routes)
+	} else {
+		t.Errorf(internal.TextColor.Red("Expected the routes %v, but got %v instead."), expRoutes, routes)
+	}
+}
+
+// Helper function to create a prefix tree with a large set of routes, declaring literal, parameter, constrained and catch-all segments for each of the given number of resources.
+func NewBenchmarkPrefixTree(b *testing.B, resourceCount int) *internal.PrefixTree {
+	b.Helper()
+	pt := internal.EmptyPrefixTree()
+	for index := 0; index < resourceCount; index++ {
+		resource := fmt.Sprintf("/api/v1/resource%d", index)
+		routePaths := []string{
+			resource,
+			resource + "/search",
+			resource + "/:id<int>",
+			resource + "/:id<int>/history",
+			resource + "/:name/details",
+			resource + "/:key/settings/:setting",
+			resource + "/files/*filepath",
+		}
+		for _, routePath := range routePaths {
+			err := pt.Insert(routePath, new(internal.Route))
+			if err != nil {
+				b.Fatalf(internal.TextColor.Red("Was not expecting an error while inserting the route [%s], but yet got one - %s"), routePath, err.Error())
+			}
+		}
+	}
+	return pt
+}
+
+// Benchmark for matching request routes of different kinds against a prefix tree with a large set of routes.
+func Benchmark_RouteTree_Match(b *testing.B) {
+	pt := NewBenchmarkPrefixTree(b, 1000)
+	benchCases := []struct {
+		Name string
+		RequestRoute string
+	} {
+		{ "Literal route", "/api/v1/resource500/search" },
+		{ "Constrained parameter", "/api/v1/resource500/42/history" },
+		{ "Unconstrained parameter after backtracking", "/api/v1/resource500/42/details" },
+		{ "Two parameters after backtracking", "/api/v1/resource500/42/settings/theme" },
+		{ "Catch-all segment", "/api/v1/resource500/files/docs/2024/report.pdf" },
+		{ "Route without a match", "/api/v1/resource500/42/unknown/segment" },
+	}
+
+	for _, benchCase := range benchCases {
+		b.Run(benchCase.Name, func(bb *testing.B) {
+			bb.ReportAllocs()
+			for bb.Loop() {
+				pt.Match(benchCase.RequestRoute)
+			}
+		})
+	}
+}
+
+// Benchmark for building a prefix tree with a large set of routes.
+func Benchmark_RouteTree_Insert(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		NewBenchmarkPrefixTree(b, 1000)
+	}
+}
@@ -0,0 +1,118 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+	"github.com/citadelofcode/proteus/internal"
+)
+
+// Test case to validate that a server started with Serve() processes requests and stops accepting connections once shutdown.
+func Test_Server_ServeAndShutdown(t *testing.T) {
+	testServer := NewTestServer(t)
+	testServer.Router.Get("/hello", func(request *internal.HttpRequest, response *internal.HttpResponse) {
+		response.Status(internal.Status200)
+		response.Send("Hello from Proteus!")
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while creating test listener: %s"), err.Error())
+		return
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- testServer.Serve(listener)
+	}()
+
+	conn := DialTestServer(t, listener.Addr().String())
+
+	conn.Write([]byte("GET /hello HTTP/1.0\r\nHost: localhost\r\n\r\n"))
+	responseBytes, _ := io.ReadAll(conn)
+	if strings.HasPrefix(string(responseBytes), "HTTP/1.0 200 OK") && strings.HasSuffix(string(responseBytes), "Hello from Proteus!") {
+		t.Log("The server processed the request and sent the expected response.")
+	} else {
+		t.Errorf(internal.TextColor.Red("The response received from the server was not as expected - %q"), string(responseBytes))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5 * time.Second)
+	defer cancel()
+	err = testServer.Shutdown(ctx)
+	if err != nil {
+		t.Errorf(internal.TextColor.Red("Was not expecting an error while shutting down the server, but yet got one - %#v"), err)
+	}
+
+	select {
+	case err := <-serveErr:
+		var closedErr *internal.ServerClosedError
+		if errors.As(err, &closedErr) {
+			t.Log("Serve() returned a server closed error after the server was shutdown as expected.")
+		} else {
+			t.Errorf(internal.TextColor.Red("Serve() was expected to return a server closed error, but returned this instead - %#v"), err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error(internal.TextColor.Red("Serve() did not return after the server was shutdown."))
+	}
+
+	_, err = net.DialTimeout("tcp", listener.Addr().String(), time.Second)
+	if err != nil {
+		t.Log("The server is no longer accepting new connections as expected.")
+	} else {
+		t.Error(internal.TextColor.Red("The server is still accepting new connections after being shutdown."))
+	}
+}
+
+// Test case to validate that a graceful shutdown closes idle keep-alive connections.
+func Test_Server_ShutdownClosesIdleConnections(t *testing.T) {
+	testServer := NewTestServer(t)
+	testServer.Router.Get("/hello", func(request *internal.HttpRequest, response *internal.HttpResponse) {
+		response.Status(internal.Status200)
+		response.Send("Hello from Proteus!")
+	})
+	address := ServeTestServer(t, testServer)
+
+	conn := DialTestServer(t, address)
+
+	conn.Write([]byte("GET /hello HTTP/1.1\r\nHost: localhost\r\nConnection: keep-alive\r\n\r\n"))
+	reader := bufio.NewReader(conn)
+	statusLine, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(statusLine, "HTTP/1.1 200") {
+		t.Fatalf(internal.TextColor.Red("The status line received from the server was not as expected - %q"), statusLine)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5 * time.Second)
+	defer cancel()
+	startedAt := time.Now()
+	err = testServer.Shutdown(ctx)
+	if err != nil {
+		t.Errorf(internal.TextColor.Red("Was not expecting an error while shutting down the server, but yet got one - %#v"), err)
+	} else {
+		t.Logf("The server was shutdown in %s with an idle keep-alive connection open.", time.Since(startedAt))
+	}
+}
+
+// Test case to validate that Start() returns an error when the server cannot bind to the configured address.
+func Test_Server_StartBindError(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while creating test listener: %s"), err.Error())
+		return
+	}
+	defer listener.Close()
+
+	testServer := internal.NewServer("127.0.0.1", listener.Addr().(*net.TCPAddr).Port)
+	err = testServer.Start()
+	if err != nil {
+		t.Logf("Start() returned an error as expected for an address already in use - %s", err.Error())
+	} else {
+		testServer.Close()
+		t.Error(internal.TextColor.Red("Start() was expected to return an error for an address already in use."))
+	}
+}
@@ -0,0 +1,112 @@
+package test
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+	"time"
+	"github.com/citadelofcode/proteus/internal"
+)
+
+// Helper function to create a test server with a single GET route and the given timeouts, served on a random local port.
+func NewTimeoutTestServer(t testing.TB, configure func(*internal.HttpServer)) string {
+	t.Helper()
+	testServer := NewTestServer(t)
+	testServer.Router.Get("/hello", func(request *internal.HttpRequest, response *internal.HttpResponse) {
+		response.Status(internal.Status200)
+		response.Send("Hello!")
+	})
+	testServer.Router.Post("/hello", func(request *internal.HttpRequest, response *internal.HttpResponse) {
+		response.Status(internal.Status200)
+		response.Send("Hello!")
+	})
+	configure(testServer)
+	return ServeTestServer(t, testServer)
+}
+
+// Test case to validate that the server answers requests not received completely within the configured timeouts with "408 - Request Timeout".
+func Test_Timeouts_SlowRequests(t *testing.T) {
+	address := NewTimeoutTestServer(t, func(server *internal.HttpServer) {
+		server.ReadHeaderTimeout = 200 * time.Millisecond
+		server.ReadBodyTimeout = 200 * time.Millisecond
+	})
+
+	testCases := []struct {
+		Name string
+		PartialRequest string
+	} {
+		{ "Request headers not received in time", "GET /hello HTTP/1.1\r\nHost: localhost\r\n" },
+		{ "Request body not received in time", "POST /hello HTTP/1.1\r\nHost: localhost\r\nContent-Length: 10\r\n\r\nab" },
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(tt *testing.T) {
+			conn := DialTestServer(tt, address)
+
+			conn.Write([]byte(testCase.PartialRequest))
+			responseBytes, _ := io.ReadAll(conn)
+			if strings.HasPrefix(string(responseBytes), "HTTP/1.1 408") {
+				tt.Log("The server answered the slow request with 408 and closed the connection as expected.")
+			} else {
+				tt.Errorf(internal.TextColor.Red("The server was expected to answer the slow request with 408, but sent this instead - %q"), string(responseBytes))
+			}
+		})
+	}
+}
+
+// Test case to validate that idle persistent connections are closed once the idle timeout elapses.
+func Test_Timeouts_IdleConnection(t *testing.T) {
+	address := NewTimeoutTestServer(t, func(server *internal.HttpServer) {
+		server.IdleTimeout = 200 * time.Millisecond
+	})
+
+	conn := DialTestServer(t, address)
+
+	conn.Write([]byte("GET /hello HTTP/1.1\r\nHost: localhost\r\nConnection: keep-alive\r\n\r\n"))
+	startedAt := time.Now()
+	responseBytes, _ := io.ReadAll(conn)
+	elapsed := time.Since(startedAt)
+	if !strings.HasPrefix(string(responseBytes), "HTTP/1.1 200") {
+		t.Errorf(internal.TextColor.Red("The response received from the server was not as expected - %q"), string(responseBytes))
+	}
+
+	if elapsed < 4 * time.Second {
+		t.Logf("The idle connection was closed by the server after %s as expected.", elapsed)
+	} else {
+		t.Error(internal.TextColor.Red("The idle connection was not closed by the server once the idle timeout elapsed."))
+	}
+}
+
+// Test case to validate that a persistent connection is closed once the maximum number of requests have been processed.
+func Test_Timeouts_MaxRequestsPerConnection(t *testing.T) {
+	address := NewTimeoutTestServer(t, func(server *internal.HttpServer) {
+		server.MaxRequestsPerConnection = 2
+	})
+
+	conn := DialTestServer(t, address)
+
+	reader := bufio.NewReader(conn)
+	for index := range 2 {
+		conn.Write([]byte("GET /hello HTTP/1.1\r\nHost: localhost\r\nConnection: keep-alive\r\n\r\n"))
+		statusLine, err := reader.ReadString('\n')
+		if err != nil || !strings.HasPrefix(statusLine, "HTTP/1.1 200") {
+			t.Fatalf(internal.TextColor.Red("Request %d on the connection failed with status line %q and error %v"), index + 1, statusLine, err)
+			return
+		}
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil || line == "\r\n" {
+				break
+			}
+		}
+		reader.Discard(len("Hello!"))
+	}
+
+	_, err := reader.ReadByte()
+	if err == io.EOF {
+		t.Log("The connection was closed by the server after the maximum number of requests as expected.")
+	} else {
+		t.Errorf(internal.TextColor.Red("The connection was expected to be closed after the maximum number of requests, but got %v instead."), err)
+	}
+}
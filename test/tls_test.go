@@ -0,0 +1,107 @@
+package test
+
+import (
+	"crypto/tls"
+	"os"
+	"slices"
+	"testing"
+	"time"
+	"github.com/citadelofcode/proteus/internal"
+)
+
+// Test case to validate the selection of certificates by the certificate store using the server name sent by the client.
+func Test_CertificateStore_GetCertificate(t *testing.T) {
+	root := t.TempDir()
+	store := new(internal.CertificateStore)
+	certificates := map[string][]string {
+		"default": { "default.example.com" },
+		"api": { "api.example.com" },
+		"wildcard": { "*.apps.example.com" },
+	}
+
+	for _, name := range []string{ "default", "api", "wildcard" } {
+		certFile, keyFile, err := CreateCertificate(t, root, name, certificates[name])
+		if err != nil {
+			t.Fatalf(internal.TextColor.Red("Error occurred while creating test certificate [%s]: %s"), name, err.Error())
+			return
+		}
+		err = store.AddCertificate(certFile, keyFile)
+		if err != nil {
+			t.Fatalf(internal.TextColor.Red("Error occurred while adding test certificate [%s] to the store: %s"), name, err.Error())
+			return
+		}
+	}
+
+	testCases := []struct {
+		Name string
+		ServerName string
+		ExpHostName string
+	} {
+		{ "Server name with an exact match", "api.example.com", "api.example.com" },
+		{ "Server name in uppercase with an exact match", "API.EXAMPLE.COM", "api.example.com" },
+		{ "Server name matching a wildcard certificate", "billing.apps.example.com", "*.apps.example.com" },
+		{ "Server name without a matching certificate", "unknown.example.org", "default.example.com" },
+		{ "Client hello without a server name", "", "default.example.com" },
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(tt *testing.T) {
+			certificate, err := store.GetCertificate(&tls.ClientHelloInfo{ ServerName: testCase.ServerName })
+			if err != nil {
+				tt.Errorf(internal.TextColor.Red("Was not expecting an error, but yet got one - %#v"), err)
+				return
+			}
+
+			if slices.Contains(certificate.Leaf.DNSNames, testCase.ExpHostName) {
+				tt.Logf("The certificate selected for server name [%s] is valid for the expected host name [%s].", testCase.ServerName, testCase.ExpHostName)
+			} else {
+				tt.Errorf(internal.TextColor.Red("The certificate selected for server name [%s] is valid for %v instead of the expected host name [%s]."), testCase.ServerName, certificate.Leaf.DNSNames, testCase.ExpHostName)
+			}
+		})
+	}
+}
+
+// Test case to validate that the certificate store reloads certificates whose files have changed.
+func Test_CertificateStore_Reload(t *testing.T) {
+	root := t.TempDir()
+	store := new(internal.CertificateStore)
+	certFile, keyFile, err := CreateCertificate(t, root, "server", []string{ "old.example.com" })
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while creating test certificate: %s"), err.Error())
+		return
+	}
+
+	err = store.AddCertificate(certFile, keyFile)
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while adding test certificate to the store: %s"), err.Error())
+		return
+	}
+
+	_, _, err = CreateCertificate(t, root, "server", []string{ "new.example.com" })
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while replacing test certificate: %s"), err.Error())
+		return
+	}
+
+	modifiedAt := time.Now().Add(1 * time.Minute)
+	os.Chtimes(certFile, modifiedAt, modifiedAt)
+	os.Chtimes(keyFile, modifiedAt, modifiedAt)
+
+	err = store.Reload()
+	if err != nil {
+		t.Errorf(internal.TextColor.Red("Was not expecting an error while reloading certificates, but yet got one - %#v"), err)
+		return
+	}
+
+	certificate, err := store.GetCertificate(&tls.ClientHelloInfo{ ServerName: "new.example.com" })
+	if err != nil {
+		t.Errorf(internal.TextColor.Red("Was not expecting an error, but yet got one - %#v"), err)
+		return
+	}
+
+	if slices.Contains(certificate.Leaf.DNSNames, "new.example.com") {
+		t.Log("The certificate store has reloaded the certificate from the modified files as expected.")
+	} else {
+		t.Errorf(internal.TextColor.Red("The certificate store is still serving the certificate valid for %v after the reload."), certificate.Leaf.DNSNames)
+	}
+}
@@ -0,0 +1,106 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"github.com/citadelofcode/proteus/internal"
+)
+
+// Helper function to create a test server with the built-in TRACE responder set as given, served on a random local port.
+// The endpoint "/app" has its own TRACE handler, while TRACE requests to "/proxy" are forwarded to an upstream server that echoes the "Max-Forwards" header received.
+func NewTraceTestServer(t testing.TB, enableTrace bool) (*internal.HttpServer, string) {
+	t.Helper()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("upstream " + r.Header.Get("Max-Forwards")))
+	}))
+	t.Cleanup(upstream.Close)
+	proxy, err := internal.NewProxyHandler(upstream.URL)
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while creating the proxy handler: %s"), err.Error())
+	}
+	t.Cleanup(proxy.Close)
+
+	testServer := NewTestServer(t)
+	testServer.EnableTrace = enableTrace
+	testServer.Router.Get("/app", func(request *internal.HttpRequest, response *internal.HttpResponse) {
+		response.Status(internal.Status200)
+		response.Send("app GET")
+	})
+	testServer.Router.Trace("/app", func(request *internal.HttpRequest, response *internal.HttpResponse) {
+		response.Status(internal.Status200)
+		response.Send("app TRACE")
+	})
+	testServer.Router.Trace("/proxy", proxy.Handle)
+	return testServer, ServeTestServer(t, testServer)
+}
+
+// Test case to validate that TRACE requests without an endpoint are not answered when the built-in responder is disabled, which is the default.
+func Test_Trace_Disabled(t *testing.T) {
+	_, address := NewTraceTestServer(t, false)
+	response, _, err := SendTestRequest(t, "TRACE", address, "/echo", "", nil)
+	if err != nil {
+		t.Errorf(internal.TextColor.Red("Error occurred while sending the TRACE request to the test server: %s"), err.Error())
+	} else if response.StatusCode == http.StatusNotFound {
+		t.Log("The TRACE request was not echoed back while the built-in responder is disabled as expected.")
+	} else {
+		t.Errorf(internal.TextColor.Red("Expected status 404 for the TRACE request, but got %d instead."), response.StatusCode)
+	}
+}
+
+// Test case to validate that the built-in responder echoes the request back as "message/http", with the credentials redacted.
+func Test_Trace_Echo(t *testing.T) {
+	_, address := NewTraceTestServer(t, true)
+	response, body, err := SendTestRequest(t, "TRACE", address, "/echo?name=one", "", map[string]string{
+		"Authorization": "Bearer secret-token",
+		"Cookie": "session=secret-session",
+		"X-Trace-Test": "visible",
+	})
+
+	if err != nil {
+		t.Errorf(internal.TextColor.Red("Error occurred while sending the TRACE request to the test server: %s"), err.Error())
+	} else if response.StatusCode != http.StatusOK || response.Header.Get("Content-Type") != "message/http" {
+		t.Errorf(internal.TextColor.Red("Expected status 200 with the content type [message/http], but got status %d with [%s] instead."), response.StatusCode, response.Header.Get("Content-Type"))
+	} else if !strings.HasPrefix(body, "TRACE /echo?name=one HTTP/1.1\r\n") || !strings.Contains(body, "X-Trace-Test: visible\r\n") {
+		t.Errorf(internal.TextColor.Red("Expected the request line and headers to be echoed back, but got %q instead."), body)
+	} else if strings.Contains(body, "secret") || !strings.Contains(body, "Authorization: [redacted]\r\n") || !strings.Contains(body, "Cookie: [redacted]\r\n") {
+		t.Errorf(internal.TextColor.Red("Expected the credentials to be redacted, but got %q instead."), body)
+	} else {
+		t.Log("The TRACE request was echoed back with its credentials redacted as expected.")
+	}
+}
+
+// Test case to validate that the "Max-Forwards" header decides whether a TRACE request is answered by the built-in responder or passed on to the endpoint.
+func Test_Trace_MaxForwards(t *testing.T) {
+	_, address := NewTraceTestServer(t, true)
+	testCases := []struct {
+		Name string
+		Path string
+		MaxForwards string
+		ExpPrefix string
+	} {
+		{ "TRACE request to an endpoint without Max-Forwards", "/app", "", "app TRACE" },
+		{ "TRACE request to an endpoint with hops left", "/app", "2", "app TRACE" },
+		{ "TRACE request to an endpoint with no hops left", "/app", "0", "TRACE /app HTTP/1.1" },
+		{ "TRACE request forwarded by a proxy endpoint", "/proxy", "3", "upstream 2" },
+		{ "TRACE request not forwarded by a proxy endpoint", "/proxy", "0", "TRACE /proxy HTTP/1.1" },
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(tt *testing.T) {
+			headers := make(map[string]string)
+			if testCase.MaxForwards != "" {
+				headers["Max-Forwards"] = testCase.MaxForwards
+			}
+			response, body, err := SendTestRequest(tt, "TRACE", address, testCase.Path, "", headers)
+			if err != nil {
+				tt.Errorf(internal.TextColor.Red("Error occurred while sending the TRACE request to the test server: %s"), err.Error())
+			} else if response.StatusCode == http.StatusOK && strings.HasPrefix(body, testCase.ExpPrefix) {
+				tt.Logf("The TRACE request was answered with [%s] as expected.", testCase.ExpPrefix)
+			} else {
+				tt.Errorf(internal.TextColor.Red("Expected status 200 with a body starting with [%s], but got status %d with %q instead."), testCase.ExpPrefix, response.StatusCode, body)
+			}
+		})
+	}
+}
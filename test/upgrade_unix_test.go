@@ -0,0 +1,141 @@
+//go:build unix
+
+package test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"github.com/citadelofcode/proteus/internal"
+)
+
+// Environment variable containing the path of the Unix domain socket handed over by the upgrade test, read by the new process started by the test.
+const UPGRADE_TEST_SOCKET_ENV = "PROTEUS_TEST_UPGRADE_SOCKET"
+
+// Helper function to create a test server listening on the given Unix domain socket, whose endpoint "/whoami" responds with the given name.
+// The endpoint "/slow" responds after a delay, and the endpoint "/exit" closes the given channel.
+func NewUpgradeTestServer(t testing.TB, socketPath string, name string, exit chan struct{}) *internal.HttpServer {
+	t.Helper()
+	testServer := internal.NewServer(internal.UNIX_ADDRESS_PREFIX + socketPath, 0)
+	testServer.Router.Get("/whoami", func(request *internal.HttpRequest, response *internal.HttpResponse) {
+		response.Status(internal.Status200)
+		response.Send(name)
+	})
+	testServer.Router.Get("/slow", func(request *internal.HttpRequest, response *internal.HttpResponse) {
+		time.Sleep(500 * time.Millisecond)
+		response.Status(internal.Status200)
+		response.Send(name + " slow")
+	})
+	testServer.Router.Get("/exit", func(request *internal.HttpRequest, response *internal.HttpResponse) {
+		response.Status(internal.Status200)
+		response.Send(name + " exit")
+		close(exit)
+	})
+	return testServer
+}
+
+// Helper function to send a GET request for the given path over the Unix domain socket, returning the body of the response.
+func GetOverSocket(socketPath string, path string) (string, error) {
+	client := &http.Client{
+		Transport: &http.Transport{
+			DisableKeepAlives: true,
+			DialContext: func(ctx context.Context, network string, address string) (net.Conn, error) {
+				return net.Dial("unix", socketPath)
+			},
+		},
+		Timeout: 5 * time.Second,
+	}
+	response, err := client.Get("http://localhost" + path)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	return string(body), err
+}
+
+// Test case to validate that an upgrade hands over the listener to a new process, while the requests in progress are completed by the old process.
+// The test binary is started again as the new process, in which this test takes over the listener and serves requests until asked to exit.
+func Test_Upgrade_Handoff(t *testing.T) {
+	if os.Getenv(internal.UPGRADE_LISTENERS_ENV) != "" {
+		RunUpgradedProcess(t, os.Getenv(UPGRADE_TEST_SOCKET_ENV))
+		return
+	}
+
+	socketPath := filepath.Join(t.TempDir(), "upgrade.sock")
+	t.Setenv(UPGRADE_TEST_SOCKET_ENV, socketPath)
+	testServer := NewUpgradeTestServer(t, socketPath, "parent", make(chan struct{}))
+	err := testServer.Start()
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while starting the test server: %s"), err.Error())
+	}
+	t.Cleanup(func() {
+		testServer.Close()
+	})
+
+	body, err := GetOverSocket(socketPath, "/whoami")
+	if err != nil || body != "parent" {
+		t.Fatalf(internal.TextColor.Red("Expected the response [parent] before the upgrade, but got [%s] with error %v instead."), body, err)
+	}
+
+	slowResult := make(chan string, 1)
+	go func() {
+		body, err := GetOverSocket(socketPath, "/slow")
+		if err != nil {
+			body = err.Error()
+		}
+		slowResult <- body
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	// The new process runs only this test, so that it takes over the listener instead of running the whole test suite again.
+	originalArgs := os.Args
+	os.Args = []string{ originalArgs[0], "-test.run=^Test_Upgrade_Handoff$" }
+	err = testServer.Upgrade()
+	os.Args = originalArgs
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Was not expecting an error while upgrading the server, but yet got one - %s"), err.Error())
+	}
+
+	slowBody := <-slowResult
+	if slowBody == "parent slow" {
+		t.Log("The request in progress was completed by the old process during the upgrade as expected.")
+	} else {
+		t.Errorf(internal.TextColor.Red("Expected the request in progress to be answered with [parent slow], but got [%s] instead."), slowBody)
+	}
+
+	body, err = GetOverSocket(socketPath, "/whoami")
+	if err == nil && body == "child" {
+		t.Log("The new connections were accepted by the new process as expected.")
+	} else {
+		t.Errorf(internal.TextColor.Red("Expected the response [child] after the upgrade, but got [%s] with error %v instead."), body, err)
+	}
+	GetOverSocket(socketPath, "/exit")
+}
+
+// Runs the new process started by the upgrade test, which serves requests on the listener handed over until it is asked to exit.
+func RunUpgradedProcess(t *testing.T, socketPath string) {
+	// The output of the test binary is shared with the old process, and is discarded so that it does not interfere with its results.
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err == nil {
+		os.Stdout = devNull
+		os.Stderr = devNull
+	}
+
+	exit := make(chan struct{})
+	testServer := NewUpgradeTestServer(t, socketPath, "child", exit)
+	err = testServer.Start()
+	if err != nil {
+		t.Fatalf("Error occurred while starting the upgraded server: %s", err.Error())
+	}
+	select {
+	case <-exit:
+	case <-time.After(10 * time.Second):
+	}
+	testServer.Close()
+}
@@ -14,8 +14,8 @@ func Test_GetHighestVersion(t *testing.T) {
 		InputVersion string
 		ExpVersion string
 	} {
-		{ "HTTP v2.0", "2.0", "1.1" },
-		{ "HTTP v3.0", "3.0", "1.1" },
+		{ "HTTP v2.0", "2.0", "2.0" },
+		{ "HTTP v3.0", "3.0", "2.0" },
 		{ "HTTP v0.9", "0.9", "0.9" },
 		{ "HTTP v1.0", "1.0", "1.0"},
 		{ "HTTP v1.1", "1.1", "1.1" },
@@ -43,7 +43,8 @@ func Test_GetResponseVersion(t *testing.T) {
 		{ "A version (HTTP/0.9) compatible with the server", "0.9", "0.9" },
 		{ "A version (HTTP/1.0) compatible with the server", "1.0", "1.0" },
 		{ "A version (HTTP/1.1) compatible with the server", "1.1", "1.1" },
-		{ "A version not compatible with the server", "2.0", "1.1" },
+		{ "A version (HTTP/2) compatible with the server", "2.0", "2.0" },
+		{ "A version not compatible with the server", "3.0", "2.0" },
 	}
 
 	for _, testCase := range testCases {
@@ -123,11 +124,11 @@ func Test_CleanRoute(t *testing.T) {
 // Test case to validate the GetAllVersions() utility function.
 func Test_GetAllVersions(t *testing.T) {
 	versions := internal.GetAllVersions()
-	if len(versions) != 3 {
-		t.Errorf(internal.TextColor.Red("Expected 3 compatible versions, but got %d versions instead"), len(versions))
+	if len(versions) != 4 {
+		t.Errorf(internal.TextColor.Red("Expected 4 compatible versions, but got %d versions instead"), len(versions))
 		return
 	} else {
-		t.Log("There are 3 versions of HTTP supported by the server as expected")
+		t.Log("There are 4 versions of HTTP supported by the server as expected")
 	}
 
 	allVersionsFound := true
@@ -146,8 +147,13 @@ func Test_GetAllVersions(t *testing.T) {
 		allVersionsFound = false
 	}
 
+	if !slices.Contains(versions, "2.0") {
+		t.Error(internal.TextColor.Red("HTTP/2 was not found among the list of compatible versions"))
+		allVersionsFound = false
+	}
+
 	if allVersionsFound {
-		t.Log("All 3 versions of HTTP [0.9, 1.0, 1.1] were found among the list of compatible versions.")
+		t.Log("All 4 versions of HTTP [0.9, 1.0, 1.1, 2.0] were found among the list of compatible versions.")
 	}
 }
 
@@ -161,7 +167,8 @@ func Test_GetAllowedMethods(t *testing.T) {
 		{ "HTTP version 0.9 - Compatible", "0.9", "GET" },
 		{ "HTTP version 1.0 - Compatible", "1.0", "GET, POST, HEAD, OPTIONS, TRACE" },
 		{ "HTTP version 1.1 - Compatible", "1.1", "GET, HEAD, POST, PUT, DELETE, TRACE, OPTIONS, CONNECT, PATCH" },
-		{ "HTTP version 2.0 - Not Compatible", "2.0", "" },
+		{ "HTTP version 2.0 - Compatible", "2.0", "GET, HEAD, POST, PUT, DELETE, TRACE, OPTIONS, CONNECT, PATCH" },
+		{ "HTTP version 3.0 - Not Compatible", "3.0", "" },
 	}
 
 	for _, testCase := range testCases {
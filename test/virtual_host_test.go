@@ -0,0 +1,139 @@
+package test
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+	"github.com/citadelofcode/proteus/internal"
+)
+
+// Helper function to create a test server with virtual hosts for "api.example.com", "*.example.com", "*.eu.example.com" and "static.example.com", served on a random local port.
+// The endpoint "/whoami" of each virtual host responds with the name of the virtual host, and the default virtual host is declared only if asked for.
+func NewVirtualHostTestServer(t testing.TB, withDefault bool) string {
+	t.Helper()
+	testServer := NewTestServer(t)
+	testServer.Use(func(request *internal.HttpRequest, response *internal.HttpResponse, next internal.StopFunction) {
+		response.Headers.Add("X-Server", "proteus")
+	})
+
+	patterns := map[string]string{ "api.example.com": "api", "*.example.com": "wildcard", "*.eu.example.com": "eu" }
+	if withDefault {
+		patterns["*"] = "default"
+	}
+	for pattern, name := range patterns {
+		virtualHost, err := testServer.AddVirtualHost(pattern, nil)
+		if err != nil {
+			t.Fatalf(internal.TextColor.Red("Error occurred while declaring the virtual host [%s]: %s"), pattern, err.Error())
+		}
+		virtualHost.Use(func(request *internal.HttpRequest, response *internal.HttpResponse, next internal.StopFunction) {
+			response.Headers.Add("X-Virtual-Host", name)
+		})
+		virtualHost.Router.Get("/whoami", func(request *internal.HttpRequest, response *internal.HttpResponse) {
+			response.Status(internal.Status200)
+			response.Send(name)
+		})
+	}
+
+	root := t.TempDir()
+	err := CreateStaticAssets(t, root)
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while creating the static assets: %s"), err.Error())
+	}
+	staticHost, _ := testServer.AddVirtualHost("static.example.com", nil)
+	err = staticHost.Static("/assets", filepath.Join(root, "static"))
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while adding the static route to the virtual host: %s"), err.Error())
+	}
+	return ServeTestServer(t, testServer)
+}
+
+// Test case to validate that requests are served by the virtual host matching their host name, using its own router and middlewares.
+func Test_VirtualHost_Routing(t *testing.T) {
+	address := NewVirtualHostTestServer(t, false)
+	testCases := []struct {
+		Name string
+		Host string
+		Path string
+		ExpStatus int
+		ExpVirtualHost string
+	} {
+		{ "Exact host name", "api.example.com", "/whoami", http.StatusOK, "api" },
+		{ "Exact host name with port and trailing dot", "API.Example.com.:8080", "/whoami", http.StatusOK, "api" },
+		{ "Subdomain matching the wildcard pattern", "shop.example.com", "/whoami", http.StatusOK, "wildcard" },
+		{ "Subdomain matching the most specific wildcard pattern", "paris.eu.example.com", "/whoami", http.StatusOK, "eu" },
+		{ "Static route of a virtual host", "static.example.com", "/assets/file-one.html", http.StatusOK, "" },
+		{ "Static route requested on another virtual host", "api.example.com", "/assets/file-one.html", http.StatusNotFound, "api" },
+		{ "Parent domain of the wildcard pattern", "example.com", "/whoami", http.StatusMisdirectedRequest, "" },
+		{ "Unknown host name", "example.org", "/whoami", http.StatusMisdirectedRequest, "" },
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(tt *testing.T) {
+			response, body, err := SendTestRequest(tt, "GET", address, testCase.Path, testCase.Host, nil)
+			if err != nil {
+				tt.Errorf(internal.TextColor.Red("Error occurred while sending the request to the test server: %s"), err.Error())
+				return
+			}
+
+			virtualHost := response.Header.Get("X-Virtual-Host")
+			if response.StatusCode != testCase.ExpStatus || virtualHost != testCase.ExpVirtualHost || response.Header.Get("X-Server") != "proteus" {
+				tt.Errorf(internal.TextColor.Red("Expected status %d from the virtual host [%s], but got status %d from [%s] instead."), testCase.ExpStatus, testCase.ExpVirtualHost, response.StatusCode, virtualHost)
+			} else if testCase.ExpStatus == http.StatusOK && testCase.ExpVirtualHost != "" && body != testCase.ExpVirtualHost {
+				tt.Errorf(internal.TextColor.Red("Expected the body [%s], but got [%s] instead."), testCase.ExpVirtualHost, body)
+			} else {
+				tt.Logf("The request for [%s] was answered with status %d as expected.", testCase.Host, response.StatusCode)
+			}
+		})
+	}
+}
+
+// Test case to validate that requests for unknown host names are served by the default virtual host, when one has been declared.
+func Test_VirtualHost_Default(t *testing.T) {
+	address := NewVirtualHostTestServer(t, true)
+	testCases := []struct {
+		Name string
+		RawRequest string
+		ExpStatus int
+		ExpBody string
+	} {
+		{ "Unknown host name", "GET /whoami HTTP/1.1\r\nHost: example.org\r\nConnection: close\r\n\r\n", http.StatusOK, "default" },
+		{ "Known host name", "GET /whoami HTTP/1.1\r\nHost: api.example.com\r\nConnection: close\r\n\r\n", http.StatusOK, "api" },
+		{ "HTTP/1.0 request without a host", "GET /whoami HTTP/1.0\r\n\r\n", http.StatusOK, "default" },
+		{ "HTTP/1.1 request without a host", "GET /whoami HTTP/1.1\r\nConnection: close\r\n\r\n", http.StatusBadRequest, "" },
+		{ "HTTP/1.1 request with two hosts", "GET /whoami HTTP/1.1\r\nHost: api.example.com\r\nHost: example.org\r\nConnection: close\r\n\r\n", http.StatusBadRequest, "" },
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(tt *testing.T) {
+			response, body, err := SendRawRequest(tt, address, testCase.RawRequest)
+			if err != nil {
+				tt.Errorf(internal.TextColor.Red("Error occurred while reading the response from the test server: %s"), err.Error())
+			} else if response.StatusCode != testCase.ExpStatus || (testCase.ExpBody != "" && body != testCase.ExpBody) {
+				tt.Errorf(internal.TextColor.Red("Expected status %d with the body [%s], but got status %d with [%s] instead."), testCase.ExpStatus, testCase.ExpBody, response.StatusCode, body)
+			} else {
+				tt.Logf("The request was answered with status %d as expected.", response.StatusCode)
+			}
+		})
+	}
+}
+
+// Test case to validate that invalid and duplicate virtual host patterns are rejected.
+func Test_VirtualHost_Registration(t *testing.T) {
+	testServer := NewTestServer(t)
+	_, err := testServer.AddVirtualHost("www.example.com", nil)
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while declaring the virtual host: %s"), err.Error())
+	}
+
+	patterns := []string{ "WWW.example.com.", "bad host", "*.*", "api.*.com", "-api.example.com" }
+	for _, pattern := range patterns {
+		t.Run(pattern, func(tt *testing.T) {
+			_, err := testServer.AddVirtualHost(pattern, nil)
+			if err != nil {
+				tt.Logf("The virtual host pattern was rejected as expected - %s", err.Error())
+			} else {
+				tt.Errorf(internal.TextColor.Red("Expected an error while declaring the virtual host [%s]."), pattern)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"github.com/citadelofcode/proteus/internal"
+)
+
+// Helper function to create a test server with a WebSocket endpoint echoing every message received, served on a random local port.
+// The endpoint is protected by a route middleware which rejects requests without a token.
+func NewWebSocketTestServer(t testing.TB, compression bool) string {
+	t.Helper()
+	testServer := NewTestServer(t)
+	testServer.WebSocketCompression = compression
+	authorize := func(request *internal.HttpRequest, response *internal.HttpResponse, stop internal.StopFunction) {
+		token, _ := request.Query.Get("token")
+		if len(token) == 0 || token[0] != "secret" {
+			response.Status(internal.Status401)
+			internal.ErrorHandler(request, response)
+			stop()
+		}
+	}
+	testServer.Router.WebSocket("/echo", func(request *internal.HttpRequest, ws *internal.WebSocketConn) {
+		for {
+			messageType, message, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			ws.WriteMessage(messageType, message)
+		}
+	}, authorize)
+	return ServeTestServer(t, testServer)
+}
+
+// Helper function to send a WebSocket handshake to the given address and return the connection, the reader for the frames sent by the server and the handshake response.
+func DialWebSocket(t testing.TB, address string, path string, extraHeaders string) (net.Conn, *bufio.Reader, *http.Response) {
+	t.Helper()
+	conn := DialTestServer(t, address)
+
+	handshake := "GET " + path + " HTTP/1.1\r\nHost: localhost\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n" + extraHeaders + "\r\n"
+	conn.Write([]byte(handshake))
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while reading the handshake response: %s"), err.Error())
+		return nil, nil, nil
+	}
+	return conn, reader, response
+}
+
+// Helper function to write a single WebSocket frame to the server, masked unless specified otherwise.
+func WriteWebSocketFrame(conn net.Conn, opcode byte, fin bool, rsv1 bool, payload []byte, masked bool) {
+	frame := []byte{ opcode, 0 }
+	if fin {
+		frame[0] |= 0x80
+	}
+	if rsv1 {
+		frame[0] |= 0x40
+	}
+	if masked {
+		frame[1] = 0x80
+	}
+	if len(payload) <= 125 {
+		frame[1] |= byte(len(payload))
+	} else {
+		frame[1] |= 126
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	}
+
+	maskKey := []byte{ 0x12, 0x34, 0x56, 0x78 }
+	body := bytes.Clone(payload)
+	if masked {
+		frame = append(frame, maskKey...)
+		for index := range body {
+			body[index] ^= maskKey[index % 4]
+		}
+	}
+	conn.Write(append(frame, body...))
+}
+
+// Helper function to read a single WebSocket frame sent by the server and return its opcode, its RSV1 flag and its payload.
+func ReadWebSocketFrame(t testing.TB, reader *bufio.Reader) (byte, bool, []byte) {
+	t.Helper()
+	header := make([]byte, 2)
+	_, err := io.ReadFull(reader, header)
+	if err != nil {
+		t.Fatalf(internal.TextColor.Red("Error occurred while reading a frame from the test server: %s"), err.Error())
+		return 0, false, nil
+	}
+
+	length := int(header[1] & 0x7f)
+	if length == 126 {
+		extended := make([]byte, 2)
+		io.ReadFull(reader, extended)
+		length = int(binary.BigEndian.Uint16(extended))
+	}
+	payload := make([]byte, length)
+	io.ReadFull(reader, payload)
+	return header[0] & 0x0f, header[0] & 0x40 != 0, payload
+}
+
+// Test case to validate the WebSocket handshake, including requests rejected by the route middlewares before the upgrade.
+func Test_WebSocket_Handshake(t *testing.T) {
+	address := NewWebSocketTestServer(t, false)
+	testCases := []struct {
+		Name string
+		Path string
+		ExtraHeaders string
+		ExpStatus int
+		ExpHeader string
+		ExpValue string
+	} {
+		{ "Valid handshake", "/echo?token=secret", "Sec-WebSocket-Version: 13\r\n", 101, "Sec-WebSocket-Accept", "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" },
+		{ "Handshake rejected by the route middleware", "/echo", "Sec-WebSocket-Version: 13\r\n", 401, "", "" },
+		{ "Handshake with an unsupported version", "/echo?token=secret", "Sec-WebSocket-Version: 8\r\n", 426, "Sec-WebSocket-Version", "13" },
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(tt *testing.T) {
+			_, _, response := DialWebSocket(tt, address, testCase.Path, testCase.ExtraHeaders)
+			if response.StatusCode != testCase.ExpStatus {
+				tt.Errorf(internal.TextColor.Red("Expected status %d for the handshake, but got %d instead."), testCase.ExpStatus, response.StatusCode)
+			} else if testCase.ExpHeader != "" && response.Header.Get(testCase.ExpHeader) != testCase.ExpValue {
+				tt.Errorf(internal.TextColor.Red("Expected the header [%s] to be [%s], but got [%s] instead."), testCase.ExpHeader, testCase.ExpValue, response.Header.Get(testCase.ExpHeader))
+			} else {
+				tt.Logf("The handshake was answered with status %d as expected.", response.StatusCode)
+			}
+		})
+	}
+}
+
+// Test case to validate the exchange of messages, control frames and the closing handshake over a WebSocket connection.
+func Test_WebSocket_Messages(t *testing.T) {
+	address := NewWebSocketTestServer(t, false)
+	conn, reader, response := DialWebSocket(t, address, "/echo?token=secret", "Sec-WebSocket-Version: 13\r\n")
+	if response.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf(internal.TextColor.Red("Expected status 101 for the handshake, but got %d instead."), response.StatusCode)
+		return
+	}
+
+	t.Run("Text message echoed by the handler", func(tt *testing.T) {
+		WriteWebSocketFrame(conn, 0x1, true, false, []byte("hello"), true)
+		opcode, _, payload := ReadWebSocketFrame(tt, reader)
+		if opcode == 0x1 && string(payload) == "hello" {
+			tt.Log("The text message was echoed back as expected.")
+		} else {
+			tt.Errorf(internal.TextColor.Red("Expected a text frame with [hello], but got opcode %d with [%s] instead."), opcode, string(payload))
+		}
+	})
+
+	t.Run("Fragmented message with a ping in between", func(tt *testing.T) {
+		WriteWebSocketFrame(conn, 0x2, false, false, []byte("frag"), true)
+		WriteWebSocketFrame(conn, 0x9, true, false, []byte("ping"), true)
+		WriteWebSocketFrame(conn, 0x0, true, false, []byte("mented"), true)
+		pongOpcode, _, pongPayload := ReadWebSocketFrame(tt, reader)
+		opcode, _, payload := ReadWebSocketFrame(tt, reader)
+		if pongOpcode != 0xA || string(pongPayload) != "ping" {
+			tt.Errorf(internal.TextColor.Red("Expected a pong frame with [ping], but got opcode %d with [%s] instead."), pongOpcode, string(pongPayload))
+		} else if opcode != 0x2 || string(payload) != "fragmented" {
+			tt.Errorf(internal.TextColor.Red("Expected a binary frame with [fragmented], but got opcode %d with [%s] instead."), opcode, string(payload))
+		} else {
+			tt.Log("The ping was answered and the fragmented message was reassembled as expected.")
+		}
+	})
+
+	t.Run("Closing handshake started by the client", func(tt *testing.T) {
+		WriteWebSocketFrame(conn, 0x8, true, false, binary.BigEndian.AppendUint16(nil, 1000), true)
+		opcode, _, payload := ReadWebSocketFrame(tt, reader)
+		if opcode == 0x8 && len(payload) >= 2 && binary.BigEndian.Uint16(payload) == 1000 {
+			tt.Log("The close frame was answered with status 1000 as expected.")
+		} else {
+			tt.Errorf(internal.TextColor.Red("Expected a close frame with status 1000, but got opcode %d with payload %v instead."), opcode, payload)
+		}
+
+		_, err := reader.ReadByte()
+		if err != io.EOF {
+			tt.Errorf(internal.TextColor.Red("Expected the server to close the connection after the closing handshake, but got %v instead."), err)
+		}
+	})
+}
+
+// Test case to validate that frames violating the protocol close the connection with status 1002.
+func Test_WebSocket_ProtocolError(t *testing.T) {
+	address := NewWebSocketTestServer(t, false)
+	conn, reader, _ := DialWebSocket(t, address, "/echo?token=secret", "Sec-WebSocket-Version: 13\r\n")
+	WriteWebSocketFrame(conn, 0x1, true, false, []byte("unmasked"), false)
+	opcode, _, payload := ReadWebSocketFrame(t, reader)
+	if opcode == 0x8 && len(payload) >= 2 && binary.BigEndian.Uint16(payload) == 1002 {
+		t.Log("The unmasked frame closed the connection with status 1002 as expected.")
+	} else {
+		t.Errorf(internal.TextColor.Red("Expected a close frame with status 1002, but got opcode %d with payload %v instead."), opcode, payload)
+	}
+}
+
+// Test case to validate that a frame declaring a huge payload length is read as its bytes arrive when the message size is not limited, instead of allocating the declared length upfront.
+func Test_WebSocket_UnlimitedFrameLength(t *testing.T) {
+	testServer := NewTestServer(t)
+	readResult := make(chan error, 1)
+	testServer.Router.WebSocket("/unlimited", func(request *internal.HttpRequest, ws *internal.WebSocketConn) {
+		ws.MaxMessageSize = 0
+		_, _, err := ws.ReadMessage()
+		readResult <- err
+	})
+	address := ServeTestServer(t, testServer)
+	conn, _, _ := DialWebSocket(t, address, "/unlimited", "Sec-WebSocket-Version: 13\r\n")
+
+	frame := []byte{ 0x82, 0x80 | 127 }
+	frame = binary.BigEndian.AppendUint64(frame, 1 << 62)
+	frame = append(frame, 0x12, 0x34, 0x56, 0x78)
+	conn.Write(append(frame, []byte("partial payload")...))
+	conn.Close()
+
+	select {
+	case err := <-readResult:
+		if err != nil {
+			t.Logf("The frame with a huge payload length failed once the connection was closed as expected - %v", err)
+		} else {
+			t.Error(internal.TextColor.Red("Was expecting an error while reading the incomplete frame, but got none."))
+		}
+	case <-time.After(5 * time.Second):
+		t.Error(internal.TextColor.Red("The handler did not return after the connection was closed."))
+	}
+}
+
+// Test case to validate that messages are compressed using the "permessage-deflate" extension when it is enabled for the server.
+func Test_WebSocket_Compression(t *testing.T) {
+	address := NewWebSocketTestServer(t, true)
+	conn, reader, response := DialWebSocket(t, address, "/echo?token=secret", "Sec-WebSocket-Version: 13\r\nSec-WebSocket-Extensions: permessage-deflate; client_max_window_bits\r\n")
+	if !strings.HasPrefix(response.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate") {
+		t.Fatalf(internal.TextColor.Red("Expected the permessage-deflate extension to be negotiated, but got [%s] instead."), response.Header.Get("Sec-WebSocket-Extensions"))
+		return
+	}
+
+	message := strings.Repeat("compressible ", 50)
+	for range 2 {
+		buffer := new(bytes.Buffer)
+		compressor, _ := flate.NewWriter(buffer, flate.BestCompression)
+		compressor.Write([]byte(message))
+		compressor.Flush()
+		WriteWebSocketFrame(conn, 0x1, true, true, bytes.TrimSuffix(buffer.Bytes(), []byte{ 0, 0, 0xff, 0xff }), true)
+
+		opcode, rsv1, payload := ReadWebSocketFrame(t, reader)
+		inflated, err := io.ReadAll(flate.NewReader(io.MultiReader(bytes.NewReader(payload), strings.NewReader("\x00\x00\xff\xff\x01\x00\x00\xff\xff"))))
+		if opcode != 0x1 || !rsv1 || err != nil || string(inflated) != message {
+			t.Errorf(internal.TextColor.Red("Expected a compressed text frame echoing the message, but got opcode %d, compressed %t and error %v instead."), opcode, rsv1, err)
+			return
+		}
+	}
+	t.Log("The compressed messages were decompressed and echoed back compressed as expected.")
+}
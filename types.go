@@ -27,3 +27,39 @@ type Router = internal.Router
 
 // Strucure to represent a single file in the local file system.
 type File = internal.File
+
+// Details of the TLS session negotiated for a request received over HTTPS.
+type TLSInfo = internal.TLSInfo
+
+// Collection of certificates presented to HTTPS clients, selected using the server name sent by the client.
+type CertificateStore = internal.CertificateStore
+
+// Snapshot of the connections handled by a server instance, returned by HttpServer.Stats().
+type ServerStats = internal.ServerStats
+
+// Size limits enforced on requests, configured for a server instance and optionally overridden for individual routes.
+type RequestLimits = internal.RequestLimits
+
+// Represents a WebSocket connection established with a client, after the request has been upgraded by a WebSocket endpoint.
+type WebSocketConn = internal.WebSocketConn
+
+// Represents a handler function that is executed once a WebSocket connection has been established for a request.
+type WebSocketHandler = internal.WebSocketHandler
+
+// Error returned by a WebSocket connection once it has been closed, containing the status code and reason of the close frame.
+type WebSocketCloseError = internal.WebSocketCloseError
+
+// Represents a single event sent to the client in a Server-Sent Events stream.
+type ServerSentEvent = internal.ServerSentEvent
+
+// Represents a stream of Server-Sent Events sent as the body of a response, started using HttpResponse.EventStream().
+type EventStream = internal.EventStream
+
+// Reverse proxy forwarding the requests it handles to one or more upstream servers, whose Handle() method is used as a route handler.
+type ProxyHandler = internal.ProxyHandler
+
+// Contains the information sent by a load balancer in the PROXY protocol header at the start of a connection.
+type ProxyHeader = internal.ProxyHeader
+
+// Virtual host declared on a server, serving the requests sent for the host names matching its pattern using its own router and middlewares.
+type VirtualHost = internal.VirtualHost